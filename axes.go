@@ -0,0 +1,471 @@
+package svg
+
+import (
+	"math"
+	"strconv"
+)
+
+// ===========================================================================
+// Axes, ticks and grid lines
+// ===========================================================================
+
+// The axes are drawn on top of the coordinates system of the sketcher: each
+// axis maps a range of values [Min, Max] to the user coordinates [Start, End]
+// (the identity by default, the plots with different scales on the x and y
+// axis may use another mapping). The major ticks are the multiples of a "nice"
+// step (1, 2 or 5 times a power of ten, see NiceTicks), and the minor ticks
+// divide each major interval in 4 or 5.
+
+const (
+	DefaultAxisTicks      = 6  // approximate number of major ticks of an axis
+	DefaultAxisTickLength = 6  // length of the major ticks (pixels)
+	DefaultAxisFontSize   = 12 // font size of the tick labels and titles
+)
+
+// AxisPosition defines where an axis is drawn, relatively to the other axis
+type AxisPosition int
+
+const (
+	AxisAtBorder         AxisPosition = iota // at the minimum of the other axis (e.g. bottom or left)
+	AxisAtOppositeBorder                     // at the maximum of the other axis (e.g. top or right)
+	AxisAtOrigin                             // at the value 0 of the other axis (at the border if 0 is out of range)
+	AxisAtValue                              // at the value At of the other axis
+)
+
+// TickFormatter returns the label of the tick value, step being the interval
+// between two major ticks
+type TickFormatter func(value, step float64) string
+
+// DefaultTickFormatter writes the value with the number of decimals of the
+// step (e.g. 0.5, 1.0, 1.5), and in scientific notation for the very large or
+// very small steps.
+func DefaultTickFormatter(value, step float64) string {
+	if step >= 1e6 || (step > 0 && step < 1e-4) {
+		return ScientificTickFormatter(1)(value, step)
+	}
+	decimals := 0
+	if step > 0 {
+		decimals = max(0, int(math.Ceil(-math.Log10(step)-1e-9)))
+	}
+	return FixedTickFormatter(decimals)(value, step)
+}
+
+// FixedTickFormatter returns a formatter writing the values with the given
+// number of decimals
+func FixedTickFormatter(decimals int) TickFormatter {
+	return func(value, step float64) string {
+		s := strconv.FormatFloat(value, 'f', decimals, 64)
+		if v, _ := strconv.ParseFloat(s, 64); v == 0 {
+			// no negative zero
+			s = strconv.FormatFloat(0, 'f', decimals, 64)
+		}
+		return s
+	}
+}
+
+// ScientificTickFormatter returns a formatter writing the values in
+// scientific notation with the given number of decimals (e.g. 1.5e+06)
+func ScientificTickFormatter(decimals int) TickFormatter {
+	return func(value, step float64) string {
+		if math.Abs(value) < step*1e-9 {
+			value = 0
+		}
+		return strconv.FormatFloat(value, 'e', decimals, 64)
+	}
+}
+
+// NiceNumber returns a "nice" number, i.e. 1, 2 or 5 times a power of ten,
+// close to x > 0: the nearest one if round is true, the smallest one greater
+// or equal to x otherwise (Heckbert, "Nice numbers for graph labels",
+// Graphics Gems, 1990). It returns 0 if x is not a positive finite number.
+func NiceNumber(x float64, round bool) float64 {
+	if !(x > 0) || math.IsInf(x, 0) {
+		return 0
+	}
+	p := math.Pow(10, math.Floor(math.Log10(x)))
+	f := x / p
+	var nice float64
+	if round {
+		switch {
+		case f < 1.5:
+			nice = 1
+		case f < 3:
+			nice = 2
+		case f < 7:
+			nice = 5
+		default:
+			nice = 10
+		}
+	} else {
+		switch {
+		case f <= 1:
+			nice = 1
+		case f <= 2:
+			nice = 2
+		case f <= 5:
+			nice = 5
+		default:
+			nice = 10
+		}
+	}
+	return nice * p
+}
+
+// maxTicks is the maximal number of ticks of a range (see stepTicks)
+const maxTicks = 1000
+
+// NiceTicks returns the major ticks of the range [vmin, vmax], i.e. the
+// multiples of a nice step in the range, about n ticks, and the step. The
+// range [vmin, vmin] has only one tick (and a zero step), as the ranges too
+// narrow for their values (e.g. [1, 1+1e-15]), whose ticks could not be told
+// apart.
+func NiceTicks(vmin, vmax float64, n int) (ticks []float64, step float64) {
+	if vmin > vmax {
+		vmin, vmax = vmax, vmin
+	}
+	if !(vmax-vmin > math.Max(math.Abs(vmin), math.Abs(vmax))*1e-12) || math.IsInf(vmax-vmin, 0) {
+		if isNonFinite(vmin) {
+			return nil, 0
+		}
+		return []float64{vmin}, 0
+	}
+	n = max(n, 2)
+	step = NiceNumber((vmax-vmin)/float64(n-1), true)
+	return stepTicks(vmin, vmax, step), step
+}
+
+// stepTicks returns the multiples of step in the range [vmin, vmax], at most
+// maxTicks. The ticks are computed from their index (no accumulation of the
+// rounding errors) and rounded to the decimals of the step (e.g. 0.6 instead
+// of 0.6000000000000001).
+func stepTicks(vmin, vmax, step float64) []float64 {
+	k10 := math.Pow10(max(0, int(math.Ceil(-math.Log10(step)))) + 1)
+	eps := step * 1e-9
+	var ticks []float64
+	for k := math.Ceil((vmin - eps) / step); k*step <= vmax+eps && len(ticks) < maxTicks; k++ {
+		v := k * step
+		if k10 > 1 {
+			v = math.Round(v*k10) / k10
+		}
+		ticks = append(ticks, v+0)
+	}
+	return ticks
+}
+
+// minorStep returns the step of the minor ticks: the major step divided in 4
+// if it is 2 times a power of ten (e.g. 0.5 for 2), in 5 otherwise
+func minorStep(step float64) float64 {
+	p := math.Pow(10, math.Floor(math.Log10(step)))
+	if math.Round(step/p) == 2 {
+		return step / 4
+	}
+	return step / 5
+}
+
+// Axis defines an axis of the Axes
+type Axis struct {
+	Min, Max   float64 // range of the axis values (the canvas boundaries if Min >= Max)
+	Start, End float64 // user coordinates of Min and Max (Min and Max if Start == End)
+	Position   AxisPosition
+	At         float64 // value of the other axis where the axis is drawn (AxisAtValue)
+	Title      string
+
+	Ticks      int           // approximate number of major ticks (DefaultAxisTicks if 0)
+	MinorTicks bool          // if true, the minor ticks are drawn between the major ticks
+	Format     TickFormatter // tick labels formatter (DefaultTickFormatter if nil)
+	Grid       bool          // if true, the grid lines of the major ticks are drawn
+	MinorGrid  bool          // if true, the grid lines of the minor ticks are drawn
+	Hidden     bool          // if true, the axis is not drawn (but its grid lines are)
+
+	// Categories are the names of the categories of a categorical axis (e.g.
+	// the x axis of a bar chart): the category i is at the value i, and the
+	// ticks are the categories, labeled with their names
+	Categories []string
+}
+
+// ticks returns the major ticks of the axis in its range, and their step
+func (a Axis) ticks() (ticks []float64, step float64) {
+	if a.Categories == nil {
+		return NiceTicks(a.Min, a.Max, a.Ticks)
+	}
+	for i := range a.Categories {
+		if v := float64(i); v >= a.Min && v <= a.Max {
+			ticks = append(ticks, v)
+		}
+	}
+	return ticks, 1
+}
+
+// user returns the user coordinate of the axis value v
+func (a Axis) user(v float64) float64 {
+	return a.Start + (v-a.Min)*(a.End-a.Start)/(a.Max-a.Min)
+}
+
+// resolved returns the axis with the default parameters set, vmin and vmax
+// being the canvas boundaries along the axis
+func (a Axis) resolved(vmin, vmax float64) Axis {
+	if !(a.Min < a.Max) {
+		a.Min, a.Max = vmin, vmax
+	}
+	if a.Start == a.End {
+		a.Start, a.End = a.Min, a.Max
+	}
+	if a.Ticks <= 0 {
+		a.Ticks = DefaultAxisTicks
+	}
+	if a.Format == nil {
+		a.Format = DefaultTickFormatter
+	}
+	if a.Categories != nil {
+		categories := a.Categories
+		a.Format = func(value, step float64) string {
+			return categories[int(math.Round(value))]
+		}
+		a.MinorTicks, a.MinorGrid = false, false
+	}
+	return a
+}
+
+// crossing returns the value of the axis a where the other axis is drawn
+func (a Axis) crossing(other Axis) float64 {
+	switch other.Position {
+	case AxisAtOppositeBorder:
+		return a.Max
+	case AxisAtOrigin:
+		if a.Min <= 0 && 0 <= a.Max {
+			return 0
+		}
+	case AxisAtValue:
+		return min(max(other.At, a.Min), a.Max)
+	}
+	return a.Min
+}
+
+// Axes defines the x and y axis of a sketch, drawn with Sketcher.DrawAxes
+type Axes struct {
+	X, Y       Axis
+	TickLength float64 // length of the major ticks (pixels), the minor ticks are half as long
+
+	Pencil        *Pencil // pencil of the axis lines, ticks and labels (see NewAxes)
+	GridPencil    *Pencil // pencil of the major grid lines (the theme one if nil)
+	SubGridPencil *Pencil // pencil of the minor grid lines (the theme one if nil)
+}
+
+// NewAxes returns the axes at the bottom and left borders of the canvas, with
+// minor ticks and without grid lines. The pencil is nil: the axes are drawn
+// with a thin line of the theme pencil color, and the theme text font with
+// the size DefaultAxisFontSize.
+func NewAxes() *Axes {
+	return &Axes{
+		X:          Axis{MinorTicks: true},
+		Y:          Axis{MinorTicks: true},
+		TickLength: DefaultAxisTickLength,
+	}
+}
+
+// DrawAxes draws the grid lines and the axes a in a group named "axes". The
+// ticks and labels are drawn outside of the area delimited by the axis
+// ranges, unless they would be outside of the canvas.
+func (s *Sketcher) DrawAxes(a *Axes) {
+	xmin, xmax, ymin, ymax := s.cs.UserCoordinatesBoundaries()
+	ax := a.X.resolved(xmin, xmax)
+	ay := a.Y.resolved(ymin, ymax)
+	xticks, xstep := ax.ticks()
+	yticks, ystep := ay.ticks()
+
+	pencil := s.Pencil
+	defer func() { s.Pencil = pencil }()
+	s.BeginGroup("axes")
+	defer s.EndGroup()
+
+	// canvas point of the axis values (x, y)
+	at := func(x, y float64) point {
+		px, py := s.canvasCoordinates(ax.user(x), ay.user(y))
+		return point{px, py}
+	}
+	line := func(p, q point) {
+		s.record(shape{kind: shapeLine, points: []point{p, q}})
+	}
+
+	// Grid lines, the minor ones first
+	grid := func(p *Pencil, xgrid, ygrid bool, xticks, yticks []float64) {
+		s.Pencil = p
+		for _, x := range xticks {
+			if xgrid {
+				line(at(x, ay.Min), at(x, ay.Max))
+			}
+		}
+		for _, y := range yticks {
+			if ygrid {
+				line(at(ax.Min, y), at(ax.Max, y))
+			}
+		}
+	}
+	subGridPencil, gridPencil := s.theme.SubGridPencil, s.theme.GridPencil
+	if a.SubGridPencil != nil {
+		subGridPencil = a.SubGridPencil
+	}
+	if a.GridPencil != nil {
+		gridPencil = a.GridPencil
+	}
+	grid(subGridPencil, ax.MinorGrid, ay.MinorGrid, minorTicks(ax, xstep), minorTicks(ay, ystep))
+	grid(gridPencil, ax.Grid, ay.Grid, xticks, yticks)
+
+	s.Pencil = a.Pencil
+	if s.Pencil == nil {
+		s.Pencil = s.theme.Pencil.Clone()
+		s.Pencil.LineWidth = 1
+		s.Pencil.FontFamily = s.theme.TextPencil.FontFamily
+		s.Pencil.FontColor = s.theme.TextPencil.FontColor
+		s.Pencil.FontSize = DefaultAxisFontSize
+	}
+	fs := float64(s.Pencil.FontSize)
+	gap := 0.4 * fs // space between the ticks and the labels
+	w, h := float64(s.cs.cnvxsize), float64(s.cs.cnvysize)
+	text := func(p point, label string) {
+		s.record(shape{kind: shapeText, points: []point{p}, text: label})
+	}
+
+	// the axis drawn at the border of the area are labeled outside of the
+	// area, the other ones (crossing the area) are not labeled at the
+	// crossing point (where the labels would overlap the other axis)
+	atBorder := func(a Axis) bool {
+		return a.Position == AxisAtBorder || a.Position == AxisAtOppositeBorder
+	}
+	x0, y0 := ax.crossing(ay), ay.crossing(ax)
+
+	if !ax.Hidden {
+		start, end := at(ax.Min, y0), at(ax.Max, y0)
+		line(start, end)
+		// side of the ticks and labels on the canvas: +1 below the axis,
+		// -1 above the axis
+		side := 1.
+		if atBorder(ax) {
+			center := at(ax.Min, 0.5*(ay.Min+ay.Max))
+			if start.Y < center.Y {
+				side = -1
+			}
+		}
+		if side > 0 && start.Y+a.TickLength+gap+fs > h {
+			side = -1
+		} else if side < 0 && start.Y-a.TickLength-gap-fs < 0 {
+			side = 1
+		}
+		baseline := start.Y + side*(a.TickLength+gap) + max(side, 0)*0.75*fs
+		for _, x := range minorTicks(ax, xstep) {
+			if !ax.MinorTicks {
+				break
+			}
+			p := at(x, y0)
+			line(p, point{p.X, p.Y + side*0.5*a.TickLength})
+		}
+		for _, x := range xticks {
+			p := at(x, y0)
+			line(p, point{p.X, p.Y + side*a.TickLength})
+			if x == x0 && !ay.Hidden && !atBorder(ay) {
+				continue
+			}
+			label := ax.Format(x, xstep)
+			lw := textWidth(label, fs)
+			text(point{min(max(p.X-0.5*lw, 0), w-lw), baseline}, label)
+		}
+		if ax.Title != "" {
+			tw := textWidth(ax.Title, fs)
+			titleline := baseline + side*(fs+gap)
+			if atBorder(ax) && titleline > fs && titleline < h {
+				text(point{0.5*(start.X+end.X) - 0.5*tw, titleline}, ax.Title)
+			} else {
+				// at the end of the axis, on the other side of the labels
+				// (or beyond the labels if out of the canvas)
+				ty := start.Y - gap
+				if side < 0 {
+					ty = start.Y + gap + 0.75*fs
+				}
+				if ty < 0.75*fs || ty > h {
+					ty = titleline
+				}
+				text(point{math.Max(start.X, end.X) - tw, ty}, ax.Title)
+			}
+		}
+	}
+
+	if !ay.Hidden {
+		start, end := at(x0, ay.Min), at(x0, ay.Max)
+		line(start, end)
+		// side of the ticks and labels on the canvas: -1 on the left of the
+		// axis, +1 on the right of the axis
+		side := -1.
+		if atBorder(ay) {
+			center := at(0.5*(ax.Min+ax.Max), ay.Min)
+			if start.X > center.X {
+				side = 1
+			}
+		}
+		labels := make([]string, len(yticks))
+		lw := 0. // width of the largest label
+		for i, y := range yticks {
+			labels[i] = ay.Format(y, ystep)
+			lw = max(lw, textWidth(labels[i], fs))
+		}
+		if side < 0 && start.X-a.TickLength-gap-lw < 0 {
+			side = 1
+		} else if side > 0 && start.X+a.TickLength+gap+lw > w {
+			side = -1
+		}
+		for _, y := range minorTicks(ay, ystep) {
+			if !ay.MinorTicks {
+				break
+			}
+			p := at(x0, y)
+			line(p, point{p.X + side*0.5*a.TickLength, p.Y})
+		}
+		for i, y := range yticks {
+			p := at(x0, y)
+			line(p, point{p.X + side*a.TickLength, p.Y})
+			if y == y0 && !ax.Hidden && !atBorder(ax) {
+				continue
+			}
+			// the labels are right aligned on the left of the axis, left
+			// aligned on the right
+			px := p.X + side*(a.TickLength+gap)
+			if side < 0 {
+				px -= textWidth(labels[i], fs)
+			}
+			text(point{px, min(max(p.Y+0.35*fs, 0.75*fs), h)}, labels[i])
+		}
+		if ay.Title != "" {
+			// above the end of the axis, or beside the end of the axis
+			// (after the labels) if out of the canvas
+			tw := textWidth(ay.Title, fs)
+			top := math.Min(start.Y, end.Y)
+			if top-gap-1.5*fs >= 0 {
+				// (above the label of the last tick)
+				px := min(max(start.X-0.5*tw, 0), w-tw)
+				text(point{px, top - gap - 0.75*fs}, ay.Title)
+			} else {
+				px := start.X + side*(a.TickLength+2*gap+lw)
+				if side < 0 {
+					px -= tw
+				}
+				text(point{min(max(px, 0), w-tw), top + 0.75*fs}, ay.Title)
+			}
+		}
+	}
+}
+
+// minorTicks returns the minor ticks of the axis (that are not major ticks),
+// step being the major step
+func minorTicks(a Axis, step float64) []float64 {
+	if step == 0 {
+		return nil
+	}
+	minor := minorStep(step)
+	eps := minor * 1e-6
+	var ticks []float64
+	for _, v := range stepTicks(a.Min, a.Max, minor) {
+		if r := math.Mod(math.Abs(v), step); r > eps && step-r > eps {
+			ticks = append(ticks, v)
+		}
+	}
+	return ticks
+}
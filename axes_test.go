@@ -0,0 +1,84 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestAxes_NiceTicks(t *testing.T) {
+	tests := []struct {
+		vmin, vmax float64
+		n          int
+		step       float64
+		ticks      string
+	}{
+		{0, 1, 6, 0.2, "[0 0.2 0.4 0.6 0.8 1]"},
+		{-0.3, 0.7, 6, 0.2, "[-0.2 0 0.2 0.4 0.6]"},
+		{3, 97, 5, 20, "[20 40 60 80]"},
+		{-1.5, 1.5, 4, 1, "[-1 0 1]"},
+	}
+	for _, test := range tests {
+		ticks, step := NiceTicks(test.vmin, test.vmax, test.n)
+		if step != test.step || fmt.Sprint(ticks) != test.ticks {
+			t.Errorf("the ticks of [%g,%g] are %v (step %g) and should be %s (step %g)",
+				test.vmin, test.vmax, ticks, step, test.ticks, test.step)
+		}
+	}
+	if ticks, step := NiceTicks(2, 2, 5); len(ticks) != 1 || step != 0 {
+		t.Errorf("an empty range should have one tick, not %v", ticks)
+	}
+	// The ranges of a few float steps have a single tick (the index of the
+	// ticks would be too large to be incremented)
+	if ticks, step := NiceTicks(1, math.Nextafter(1, 2), 6); len(ticks) != 1 || ticks[0] != 1 || step != 0 {
+		t.Errorf("the range of one float step should have one tick, not %v (step %g)", ticks, step)
+	}
+	if ticks := stepTicks(0, 1, 1e-6); len(ticks) != maxTicks {
+		t.Errorf("the range has %d ticks and should have at most %d", len(ticks), maxTicks)
+	}
+	if minor := minorTicks(Axis{Min: 0, Max: 2}, 1); fmt.Sprint(minor) != "[0.2 0.4 0.6 0.8 1.2 1.4 1.6 1.8]" {
+		t.Errorf("the minor ticks are %v", minor)
+	}
+	if label := DefaultTickFormatter(-1e-17, 0.5); label != "0.0" {
+		t.Errorf("the label is %s and should be 0.0", label)
+	}
+}
+
+func TestAxes_DrawAxes(t *testing.T) {
+	// The values of the x axis [0,10] and y axis [-1,1] are mapped to a box
+	// inside the canvas, to leave room for the labels
+	s := NewSketcher().WithTheme(LightTheme)
+	axes := NewAxes()
+	axes.X = Axis{Min: 0, Max: 10, Start: 0.1, End: 0.9, Title: "time (s)", MinorTicks: true, Grid: true}
+	axes.Y = Axis{Min: -1, Max: 1, Start: 0.1, End: 0.9, Title: "amplitude", Grid: true, MinorGrid: true}
+	s.DrawAxes(axes)
+	s.Save("output.TestAxes_DrawAxes.svg")
+	s.SavePNG("output.TestAxes_DrawAxes.png", 1)
+
+	res := s.ToSVG()
+	for _, label := range []string{">0</text>", ">10</text>", ">-1.0</text>", ">0.5</text>", ">time (s)</text>", ">amplitude</text>"} {
+		if !strings.Contains(res, label) {
+			t.Errorf("the sketch should contain the label %s", label)
+		}
+	}
+	// The x axis is at the bottom of the box (y = 0.1, i.e. 540 pixels)
+	if ref := "<line x1='60.00' y1='540.00' x2='540.00' y2='540.00' style='stroke: black; stroke-width: 1"; !strings.Contains(res, ref) {
+		t.Errorf("the sketch should contain the x axis line %s", ref)
+	}
+	if s.Pencil.LineWidth != LightTheme.Pencil.LineWidth || s.Pencil.FontSize != LightTheme.Pencil.FontSize || s.groups != 0 {
+		t.Errorf("the pencil and the groups of the sketcher should be left unchanged")
+	}
+
+	// The labels of the axis at the canvas border are drawn inside the canvas
+	s = NewSketcher()
+	axes = NewAxes()
+	axes.X.Position, axes.Y.Position = AxisAtOrigin, AxisAtOrigin
+	s.DrawAxes(axes)
+	s.Save("output.TestAxes_DrawAxes.border.svg")
+	for _, sh := range s.shapes {
+		if sh.kind == shapeText && (sh.points[0].X < 0 || sh.points[0].Y > 600) {
+			t.Errorf("the label %s is out of the canvas at %v", sh.text, sh.points[0])
+		}
+	}
+}
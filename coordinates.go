@@ -3,6 +3,7 @@ package svg
 import (
 	"fmt"
 	"math"
+	"slices"
 )
 
 // ===========================================================================
@@ -226,6 +227,51 @@ func NewCoordSysBoundedBy(cnvwidth int, points []struct{ X, Y float64 }, xoffset
 	return NewCoordSysWithRanges(cnvwidth, xmin, ymin, xmax, ymax)
 }
 
+// NewCoordSysWithRangesChecked is NewCoordSysWithRanges returning an error
+// instead of a degenerate coordinates system, i.e. if the canvas width is not
+// positive, if the ranges are empty or not finite, or if the canvas height
+// computed from the ranges is zero.
+func NewCoordSysWithRangesChecked(cnvwidth int, xmin, ymin, xmax, ymax float64) (*CoordinateSystem, error) {
+	if cnvwidth <= 0 {
+		return nil, &DrawingError{ZeroSizeCanvas, "canvas", fmt.Sprintf("width %d", cnvwidth)}
+	}
+	if !finitePoints(point{xmin, ymin}, point{xmax, ymax}) || !(xmax > xmin) || !(ymax > ymin) {
+		return nil, &DrawingError{InvalidGeometry, "ranges", fmt.Sprintf(
+			"x in [%g,%g] and y in [%g,%g] (non empty finite ranges expected)", xmin, xmax, ymin, ymax)}
+	}
+	cs := NewCoordSysWithRanges(cnvwidth, xmin, ymin, xmax, ymax)
+	if err := cs.Validate(); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// NewCoordSysBoundedByChecked is NewCoordSysBoundedBy returning an error
+// instead of a degenerate coordinates system (see
+// NewCoordSysWithRangesChecked), e.g. if the list of points is empty or if
+// the points are aligned on an horizontal or vertical line without offset.
+func NewCoordSysBoundedByChecked(cnvwidth int, points []struct{ X, Y float64 }, xoffset, yoffset float64) (*CoordinateSystem, error) {
+	if len(points) == 0 {
+		return nil, &DrawingError{InvalidGeometry, "ranges", "no points to bound"}
+	}
+	xmin, ymin, xmax, ymax := boundingBox(points)
+	return NewCoordSysWithRangesChecked(cnvwidth, xmin-xoffset, ymin-yoffset, xmax+xoffset, ymax+yoffset)
+}
+
+// Validate returns an error if the coordinates system is degenerate: a canvas
+// without width or height, or a scale that is zero or not finite (e.g. a
+// coordinates system created with an empty range).
+func (c CoordinateSystem) Validate() error {
+	if c.cnvxsize <= 0 || c.cnvysize <= 0 {
+		return &DrawingError{ZeroSizeCanvas, "canvas", fmt.Sprintf("size %dx%d", c.cnvxsize, c.cnvysize)}
+	}
+	if c.unit2pixel == 0 || slices.ContainsFunc([]float64{c.xorigin, c.yorigin, c.unit2pixel}, isNonFinite) {
+		return &DrawingError{ZeroSizeCanvas, "canvas", fmt.Sprintf(
+			"degenerate coordinates system (origin %g,%g, %g pixels per unit)", c.xorigin, c.yorigin, c.unit2pixel)}
+	}
+	return nil
+}
+
 func boundingBox(points []struct{ X, Y float64 }) (xmin, ymin, xmax, ymax float64) {
 	xmin = math.Inf(+1)
 	xmax = math.Inf(-1)
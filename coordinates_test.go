@@ -1,6 +1,7 @@
 package svg
 
 import (
+	"errors"
 	"log"
 	"testing"
 )
@@ -188,3 +189,35 @@ func TestCoordSysTopLeft(t *testing.T) {
 	}
 
 }
+
+func TestCoordSysWithRangesChecked(t *testing.T) {
+	cs, err := NewCoordSysWithRangesChecked(100, 0, 0, 2, 1)
+	if err != nil || cs.cnvysize != 50 {
+		t.Errorf("the coordinates system should be valid (height 50), not %v (%v)", cs, err)
+	}
+
+	// The degenerate coordinates systems are rejected
+	var derr *DrawingError
+	if _, err := NewCoordSysWithRangesChecked(100, 1, 0, 1, 1); !errors.As(err, &derr) || derr.Kind != InvalidGeometry {
+		t.Errorf("an empty x range should be an invalid geometry error, not %v", err)
+	}
+	if _, err := NewCoordSysWithRangesChecked(0, 0, 0, 1, 1); !errors.As(err, &derr) || derr.Kind != ZeroSizeCanvas {
+		t.Errorf("a zero width should be a zero size canvas error, not %v", err)
+	}
+	if _, err := NewCoordSysWithRangesChecked(100, 0, 0, 1000, 1); !errors.As(err, &derr) || derr.Kind != ZeroSizeCanvas {
+		t.Errorf("a zero height should be a zero size canvas error, not %v", err)
+	}
+	if _, err := NewCoordSysBoundedByChecked(100, nil, 0.1, 0.1); err == nil {
+		t.Errorf("an empty list of points should be an error")
+	}
+	points := []struct{ X, Y float64 }{{0, 1}, {2, 1}}
+	if _, err := NewCoordSysBoundedByChecked(100, points, 0, 0); err == nil {
+		t.Errorf("points on an horizontal line without offset should be an error")
+	}
+	if _, err := NewCoordSysBoundedByChecked(100, points, 0, 0.5); err != nil {
+		t.Errorf("points on an horizontal line with offset should be valid, not %v", err)
+	}
+	if err := NewCoordSysBottomLeft(100, 100, 0).Validate(); err == nil {
+		t.Errorf("a coordinates system with a zero range should not be valid")
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"io"
 	"log"
+	"os"
 
 	svg "github.com/gboulant/dingo-svg"
 )
@@ -42,5 +43,21 @@ func demo01() error {
 	if err != nil {
 		return err
 	}
-	return nil
+	err = s.SavePNG("output.demo01.png", 1)
+	if err != nil {
+		return err
+	}
+	err = s.SaveTikZ("output.demo01.tex", true)
+	if err != nil {
+		return err
+	}
+	if !*preview {
+		return nil
+	}
+
+	// Quick preview of the convex hull in the terminal (option -preview)
+	opts := svg.DefaultTerminalOptions
+	opts.Columns = 60
+	opts.Colors = true
+	return s.WriteTerminal(os.Stdout, opts)
 }
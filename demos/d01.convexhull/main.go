@@ -1,8 +1,14 @@
 package main
 
-import "log"
+import (
+	"flag"
+	"log"
+)
+
+var preview = flag.Bool("preview", false, "preview the convex hull in the terminal (with colors)")
 
 func main() {
+	flag.Parse()
 	if err := demo01(); err != nil {
 		log.Fatal(err)
 	}
@@ -17,6 +17,12 @@ func demo01_cardinalsine() error {
 
 	gridsize := 80
 	v := DrawIsometricView(f, gridsize, xymax)
+	if err := v.SaveHTMLCanvas("output.demo01.cardinalsine.html"); err != nil {
+		return err
+	}
+	if err := v.SaveOptimizedSVG("output.demo01.cardinalsine.svgz"); err != nil {
+		return err
+	}
 	return v.Save("output.demo01.cardinalsine.svg")
 }
 
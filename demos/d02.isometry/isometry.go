@@ -70,3 +70,15 @@ func (v IsometricView) DrawPolygon(polygon3d [][3]float64, fill bool) {
 func (v IsometricView) Save(svgpath string) error {
 	return v.sk.Save(svgpath)
 }
+
+// SaveHTMLCanvas saves the view as an HTML page that draws the view in a
+// canvas (faster than the SVG for the views with a lot of polygons)
+func (v IsometricView) SaveHTMLCanvas(htmlpath string) error {
+	return v.sk.SaveHTMLCanvas(htmlpath)
+}
+
+// SaveOptimizedSVG saves the view as a compact SVG file (gzip compressed if
+// the extension of svgpath is .svgz)
+func (v IsometricView) SaveOptimizedSVG(svgpath string) error {
+	return v.sk.SaveOptimizedSVG(svgpath, svg.DefaultSVGOptions)
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"fmt"
+
+	svg "github.com/gboulant/dingo-svg"
 )
 
 type catalog []struct {
 	name string
-	make func(svgpath string) error
+	make func() *svg.Sketcher
 }
 
 func main() {
@@ -15,10 +17,26 @@ func main() {
 		{"rM_ecolier", rM_ecolier},
 	}
 
+	// All the templates are also gathered in a PDF document (one page per
+	// template) to be printed on A4 sheets
+	pdf := svg.NewPDFDocument()
 	for _, sketcher := range rMsketchers {
+		s := sketcher.make()
 		svgpath := fmt.Sprintf("output.%s.svg", sketcher.name)
-		if err := sketcher.make(svgpath); err != nil {
+		if err := s.Save(svgpath); err != nil {
 			fmt.Printf("err: sketcher %s failed du to error %s\n", sketcher.name, err)
 		}
+		pdf.AddPageOnPaper(s, svg.PaperA4, 0)
+
+		// The templates are also plotted with a pen plotter (HPGL). The
+		// strokes are reordered to reduce the pen up moves.
+		fmt.Printf("%s plot: %s\n", sketcher.name, s.PlotterReport(svg.DefaultPlotterOptions))
+		hpglpath := fmt.Sprintf("output.%s.hpgl", sketcher.name)
+		if err := s.SaveHPGL(hpglpath, svg.DefaultPlotterOptions); err != nil {
+			fmt.Printf("err: plotter export of %s failed du to error %s\n", sketcher.name, err)
+		}
+	}
+	if err := pdf.Save("output.rM_templates.pdf"); err != nil {
+		fmt.Printf("err: pdf export failed du to error %s\n", err)
 	}
 }
@@ -2,28 +2,16 @@ package main
 
 import svg "github.com/gboulant/dingo-svg"
 
-type COLORS_MAP map[string]string
-
-var COLORS_CATALOG map[string]COLORS_MAP = map[string]COLORS_MAP{
-	"original": {
-		"blue": "blue",
-		"red":  "red",
-	},
-	// See conversion at https://www.orbworks.com/cedocs/color.htm
-	"grayscale": {
-		"blue": "Silver",
-		"red":  "Gray",
-	},
-}
-
-func rM_ecolier(svgpath string) error {
+func rM_ecolier() *svg.Sketcher {
 	s := NewRemarkableSketcher()
 	xmin, xmax, ymin, ymax := s.CoordinatesSystem().UserCoordinatesBoundaries()
 
-	colors := COLORS_CATALOG["original"]
-	p_redbold := svg.NewPencil(colors["red"], 3)
-	p_bluethin := svg.NewPencil(colors["blue"], 1)
-	p_bluebold := svg.NewPencil(colors["blue"], 3)
+	// The colors are remapped by the theme (e.g. to gray levels with the
+	// svg.EInkTheme)
+	theme := s.Theme()
+	p_redbold := svg.NewPencil(theme.Color("red"), 3)
+	p_bluethin := svg.NewPencil(theme.Color("blue"), 1)
+	p_bluebold := svg.NewPencil(theme.Color("blue"), 3)
 
 	wmargin := 34.
 	hheader := 20. // mm
@@ -109,5 +97,5 @@ func rM_ecolier(svgpath string) error {
 		x += cellsize
 	}
 
-	return s.Save(svgpath)
+	return s
 }
@@ -2,7 +2,7 @@ package main
 
 import svg "github.com/gboulant/dingo-svg"
 
-func rM_millimeters(svgpath string) error {
+func rM_millimeters() *svg.Sketcher {
 	s := NewRemarkableSketcher()
 
 	// Prepare the pencils
@@ -62,5 +62,5 @@ func rM_millimeters(svgpath string) error {
 	s.Edge(xmargin, ymargin-1, xmargin+cellsize, ymargin-1)
 	s.Text(xmargin+3, ymargin-3, "1 cm")
 
-	return s.Save(svgpath)
+	return s
 }
@@ -24,9 +24,12 @@ var (
 	RM_WIDTH_MILLIMETER float64 = inch2millimeter(RM_WIDTH_INCH)
 )
 
+// RM_THEME is the theme of the templates. Use svg.EInkTheme to get the
+// grayscale version of the templates.
+var RM_THEME *svg.Theme = svg.LightTheme
+
 func NewRemarkableSketcher() *svg.Sketcher {
 	cs := svg.NewCoordSysBottomLeft(RM_WIDTH_PX, RM_HEIGHT_PX, RM_WIDTH_MILLIMETER)
-	sk := svg.NewSketcher().WithCoordinateSystem(cs)
-	sk.WithBackgroundColor("white")
+	sk := svg.NewSketcher().WithCoordinateSystem(cs).WithTheme(RM_THEME)
 	return sk
 }
@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"strings"
+
+	svg "github.com/gboulant/dingo-svg"
 )
 
 const (
@@ -26,7 +29,33 @@ func demo02_sketch() error {
 	return makesketch(notes, "output.guitarneck.svg")
 }
 
+// demo03_annotate loads the sketch of the guitar neck and circles all the
+// notes La
+func demo03_annotate() error {
+	notes, err := LoadNotesData(csvnamespath, csvfreqspath)
+	if err != nil {
+		return err
+	}
+	sk := svg.NewSketcher().WithCoordinateSystem(neckCoordSys(notes))
+	if err := sk.LoadSVG("datafiles/guitarneck.svg"); err != nil {
+		return err
+	}
+	sk.Pencil.LineColor = "red"
+	sk.Pencil.LineWidth = 3
+	for _, stringNotes := range notes {
+		for _, note := range stringNotes {
+			if strings.HasPrefix(note.Name, "La") && !strings.HasPrefix(note.Name, "La#") {
+				sk.Circle(float64(note.FretNumber+1), float64(note.StringNumber), 0.4, false)
+			}
+		}
+	}
+	return sk.Save("output.guitarneck.annotated.svg")
+}
+
 func main() {
 	demo01_table()
 	demo02_sketch()
+	if err := demo03_annotate(); err != nil {
+		fmt.Println(err)
+	}
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	svg "github.com/gboulant/dingo-svg"
 )
@@ -16,21 +18,29 @@ func vline(sk *svg.Sketcher, x float64, ymin, ymax float64) {
 	sk.LineTo(x, ymax)
 }
 
-func makesketch(notes Notes, svgpath string) error {
+// neckCoordSys returns the coordinates system of the sketch of the guitar
+// neck, whose unit is the size of a cell (one cell per fret)
+func neckCoordSys(notes Notes) *svg.CoordinateSystem {
 	nbstrings := len(notes)
 	nbfrets := len(notes[0])
 
 	cnvwidth := 1280
 	cnvheight := cnvwidth * nbstrings / nbfrets
+	xrange := float64(nbfrets + 1)
+	return svg.NewCoordSysTopLeft(cnvwidth, cnvheight, xrange)
+}
+
+func makesketch(notes Notes, svgpath string) error {
+	nbstrings := len(notes)
+	nbfrets := len(notes[0])
 
 	// The choice of the cell size is in fact arbitrary. It only defined
 	//the xrange to consider and it has no effect on the size of the
 	//element because all positions are computed with this unit size.
 	xcellsize := 1.
-	xrange := xcellsize * float64(nbfrets+1)
 	recsize := xcellsize * 0.6
 
-	cs := svg.NewCoordSysTopLeft(cnvwidth, cnvheight, xrange)
+	cs := neckCoordSys(notes)
 	sk := svg.NewSketcher().WithCoordinateSystem(cs).WithBackgroundColor("white")
 	sk.Pencil.FontFamily = "monospace"
 	sk.Pencil.FontSize = 18
@@ -92,5 +102,17 @@ func makesketch(notes Notes, svgpath string) error {
 		}
 	}
 
-	return sk.Save(svgpath)
+	if err := sk.Save(svgpath); err != nil {
+		return err
+	}
+
+	// The same display list is replayed into the other backends
+	basepath := strings.TrimSuffix(svgpath, filepath.Ext(svgpath))
+	if err := sk.SavePNG(basepath+".png", 1); err != nil {
+		return err
+	}
+	if err := sk.SavePDF(basepath + ".pdf"); err != nil {
+		return err
+	}
+	return sk.SaveEPS(basepath + ".eps")
 }
@@ -61,6 +61,7 @@ func demo02() {
 	X := 10.
 	Y := 10.
 
+	sk.BeginGroup("walls")
 	x := X
 	y := Y
 	w := W
@@ -79,6 +80,22 @@ func demo02() {
 	walls[3] = walls[2].NextLeft(h)
 	sp = &Space{Walls: walls}
 	sp.Draw(sk)
+	sk.EndGroup()
+
+	// The door between the two spaces, with the swing of the door leaf
+	sk.BeginGroup("doors")
+	d := 8.
+	x = X + W
+	y = Y + 5
+	sk.Edge(x, y, x-d, y)
+	sk.Arc(x, y, d, 90, 180)
+	sk.EndGroup()
+
+	sk.BeginGroup("labels")
+	sk.Text(X+2, Y+2, "Living room")
+	sk.Text(X+W+2, Y+2, "Bedroom")
+	sk.EndGroup()
 
 	sk.Save("output.demo02.svg")
+	sk.SaveDXF("output.demo02.dxf")
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// scannedplan simulates a scanned floor plan, i.e. a raster image of size
+// width x height pixels (1 pixel = 1 cm) with the walls drawn in dark gray on
+// a light paper background.
+func scannedplan(width, height int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	paper := color.Gray{Y: 235}
+	ink := color.Gray{Y: 60}
+	for i := range width {
+		for j := range height {
+			img.SetGray(i, j, paper)
+		}
+	}
+	wall := func(x0, y0, x1, y1 int) {
+		for i := x0; i < x1; i++ {
+			for j := y0; j < y1; j++ {
+				img.SetGray(i, j, ink)
+			}
+		}
+	}
+	// outer walls (the image y axis is oriented top down)
+	wall(0, 0, width, 20)
+	wall(0, height-20, width, height)
+	wall(0, 0, 20, height)
+	wall(width-20, 0, width, height)
+	// inner wall with a door
+	wall(width/2-10, 0, width/2+10, height/2)
+	wall(width/2-10, height/2+90, width/2+10, height)
+	return img
+}
+
+// demo03 traces the walls of the rooms over a scanned plan embedded as a
+// raster image in the sketch (1 user unit = 1 m).
+func demo03() error {
+	cs := svg.NewCoordSysBottomLeft(cnvwidth, cnvheight, xrange)
+	sk := svg.NewSketcher().WithCoordinateSystem(cs)
+
+	X := 10.
+	Y := 10.
+	W := 80.
+	H := 50.
+
+	sk.ImageStyle.Opacity = 0.6
+	sk.ImageStyle.AspectRatio = svg.ImageAspectRatioNone
+	if err := sk.Image(X, Y, W, H, scannedplan(800, 500)); err != nil {
+		return err
+	}
+
+	sk.Pencil.LineColor = "red"
+	NewSpace(X+1, Y+1, W/2-2, H-2).Draw(sk)
+	NewSpace(X+W/2+1, Y+1, W/2-2, H-2).Draw(sk)
+
+	return sk.Save("output.demo03.svg")
+}
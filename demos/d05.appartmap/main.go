@@ -1,6 +1,10 @@
 package main
 
-import svg "github.com/gboulant/dingo-svg"
+import (
+	"log"
+
+	svg "github.com/gboulant/dingo-svg"
+)
 
 const (
 	cnvwidth  = svg.DefaultCanvasWidth
@@ -11,4 +15,7 @@ const (
 func main() {
 	demo01()
 	demo02()
+	if err := demo03(); err != nil {
+		log.Fatal(err)
+	}
 }
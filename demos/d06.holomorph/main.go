@@ -5,6 +5,7 @@ import (
 	"math/cmplx"
 
 	svg "github.com/gboulant/dingo-svg"
+	"github.com/gboulant/dingo-svg/plot"
 )
 
 // -----------------------------------------------------------
@@ -130,7 +131,10 @@ func DrawFunctionGrid(f func(z complex128) complex128, gridsize int, xymax float
 
 	cnvwidth := svg.DefaultCanvasWidth
 	csystem := svg.NewCoordSysWithRanges(cnvwidth, xmin, ymin, xmax, ymax)
-	sk := svg.NewSketcher().WithCoordinateSystem(csystem)
+	// One decimal is enough for the grids (the SVG files are much smaller),
+	// and the cells where the function diverges are not drawn
+	sk := svg.NewSketcher().WithCoordinateSystem(csystem).WithPrecision(1, true)
+	sk.WithNonFinitePolicy(svg.NonFiniteSkip)
 
 	// Draw the source grid
 	sk.Pencil.LineWidth = 1
@@ -196,6 +200,12 @@ func DrawFunctionGrid(f func(z complex128) complex128, gridsize int, xymax float
 		{dx, dy},
 	}, true)
 
+	// The real and imaginary axis through the origin
+	axes := svg.NewAxes()
+	axes.X.Position, axes.Y.Position = svg.AxisAtOrigin, svg.AxisAtOrigin
+	axes.X.Title, axes.Y.Title = "Re", "Im"
+	sk.DrawAxes(axes)
+
 	return sk
 }
 
@@ -244,7 +254,36 @@ func demo01() error {
 
 // -----------------------------------------------------------
 
+// DrawDomainColoring dessine la fonction par coloration de domaine: la teinte
+// d'un point z est l'argument de f(z) et sa luminosité croît avec le module,
+// du noir pour les zéros au blanc pour les pôles.
+func DrawDomainColoring(f func(z complex128) complex128, xymax float64, title string) *plot.Figure {
+	fig := plot.New(plot.DefaultWidth, plot.DefaultHeight)
+	fig.Title = title
+	fig.XLabel, fig.YLabel = "Re", "Im"
+	d := fig.DomainColoring(f, plot.Range{Min: -xymax, Max: xymax}, plot.Range{Min: -xymax, Max: xymax})
+	d.PhaseLines = 12
+	d.ModulusLines = true
+	return fig
+}
+
+func demo02() error {
+	fig := DrawDomainColoring(cmplx_sine, 2.4, "sin(z)")
+	if err := fig.Save("output.demo02.sine.svg"); err != nil {
+		return err
+	}
+	fig = DrawDomainColoring(cmplx_func01, 2., "z/(1-z)")
+	if err := fig.Save("output.demo02.func01.svg"); err != nil {
+		return err
+	}
+	fig = DrawDomainColoring(cmplx_inverse, 2., "1/z")
+	return fig.Save("output.demo02.inverse.svg")
+}
+
+// -----------------------------------------------------------
+
 func main() {
 	proto01()
 	demo01()
+	demo02()
 }
@@ -0,0 +1,302 @@
+package svg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ===========================================================================
+// DXF backend (CAD)
+// ===========================================================================
+
+// The DXF backend writes the recorded shapes as the entities of an ASCII DXF
+// file (release 12), readable by most of the CAD tools. The coordinates are
+// written in user coordinates (e.g. metres), not in pixels. The shapes drawn in
+// a group (see BeginGroup) are drawn on a layer named after the group path, the
+// other shapes on the default layer "0". The DXF R12 format has no fill and no
+// raster image: the filled shapes are written as outlines, and the images are
+// ignored, as the shapes without outline (e.g. of line color "none").
+
+// dxfColors are the standard colors of the AutoCAD Color Index (ACI). The
+// color 7 is the foreground color (black or white depending on the
+// background of the CAD tool).
+var dxfColors = []struct {
+	index   int
+	r, g, b uint8
+}{
+	{1, 255, 0, 0}, {2, 255, 255, 0}, {3, 0, 255, 0}, {4, 0, 255, 255},
+	{5, 0, 0, 255}, {6, 255, 0, 255}, {7, 0, 0, 0}, {7, 255, 255, 255},
+	{8, 128, 128, 128}, {9, 192, 192, 192},
+}
+
+// dxfColor returns the index of the ACI color nearest to the color colorname
+// (7 if the color can not be parsed)
+func dxfColor(colorname string) int {
+	c, err := ParseColor(colorname)
+	if err != nil || c.A == 0 {
+		return 7
+	}
+	a := int(c.A)
+	r, g, b := int(c.R)*255/a, int(c.G)*255/a, int(c.B)*255/a
+	index, dmin := 7, -1
+	for _, aci := range dxfColors {
+		dr, dg, db := r-int(aci.r), g-int(aci.g), b-int(aci.b)
+		if d := dr*dr + dg*dg + db*db; dmin < 0 || d < dmin {
+			index, dmin = aci.index, d
+		}
+	}
+	return index
+}
+
+// dxfLayerName returns the name of the layer of the shapes drawn in the group
+// path group. The R12 layer names are made of upper case letters, digits, $, -
+// and _ (the other characters are replaced by _, and the nested groups are
+// separated by -), and have at most 31 characters. The names of different
+// groups may then be equal (see dxfWriter.layer).
+func dxfLayerName(group string) string {
+	if group == "" {
+		return "0"
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r == '/':
+			return '-'
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '$', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, group)
+	if len(name) > 31 {
+		name = name[:31]
+	}
+	return name
+}
+
+// dxfText escapes the non ASCII characters of the text with the \U+XXXX
+// notation
+func dxfText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r < 32 || r > 126 {
+			fmt.Fprintf(&b, "\\U+%04X", r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+type dxfWriter struct {
+	b        strings.Builder
+	cs       *CoordinateSystem
+	decimals int               // number of decimals of the user coordinates
+	layers   map[string]bool   // names of the layers used by the entities
+	paths    map[string]string // name of the layer of each group path
+	groups   []string          // names of the opened groups
+	current  point             // current point (canvas coordinates)
+}
+
+// code writes a group (code and value) of the DXF file
+func (w *dxfWriter) code(code int, value string) {
+	fmt.Fprintf(&w.b, "%3d\n%s\n", code, value)
+}
+
+// coordinates writes the user coordinates (x, y) with the group codes code
+// (x), code+10 (y) and code+20 (z)
+func (w *dxfWriter) coordinates(code int, x, y float64) {
+	w.code(code, fmtnum(x, w.decimals))
+	w.code(code+10, fmtnum(y, w.decimals))
+	w.code(code+20, "0.0")
+}
+
+// point writes the user coordinates of the canvas point p
+func (w *dxfWriter) point(code int, p point) {
+	x, y := w.cs.userCoordinates(p.X, p.Y)
+	w.coordinates(code, x, y)
+}
+
+// layer returns the name of the layer of the current group. The name of a
+// group whose name is already the one of another group gets a suffix (e.g.
+// NAME-2), so that the groups are not merged in the same layer.
+func (w *dxfWriter) layer() string {
+	path := strings.Join(w.groups, "/")
+	if layer, ok := w.paths[path]; ok {
+		return layer
+	}
+	name := dxfLayerName(path)
+	layer := name
+	for n := 2; w.layers[layer]; n++ {
+		suffix := fmt.Sprintf("-%d", n)
+		layer = name[:min(len(name), 31-len(suffix))] + suffix
+	}
+	w.paths[path] = layer
+	w.layers[layer] = true
+	return layer
+}
+
+// entity writes the header of an entity (type, layer and color)
+func (w *dxfWriter) entity(kind string, colorname string) {
+	w.code(0, kind)
+	w.code(8, w.layer())
+	w.code(62, fmt.Sprint(dxfColor(colorname)))
+}
+
+func (w *dxfWriter) polyline(points []point, closed bool, p Pencil) {
+	w.entity("POLYLINE", p.LineColor)
+	w.code(66, "1") // vertices follow
+	w.coordinates(10, 0, 0)
+	flags := 0
+	if closed {
+		flags = 1
+	}
+	w.code(70, fmt.Sprint(flags))
+	layer := w.layer()
+	for _, p := range points {
+		w.code(0, "VERTEX")
+		w.code(8, layer)
+		w.point(10, p)
+	}
+	w.code(0, "SEQEND")
+	w.code(8, layer)
+}
+
+func (w *dxfWriter) Begin(width, height int, background string) {}
+func (w *dxfWriter) End()                                       {}
+
+func (w *dxfWriter) MoveTo(x, y float64) {
+	w.current = point{x, y}
+}
+
+func (w *dxfWriter) LineTo(x, y float64, p Pencil) {
+	start, end := w.current, point{x, y}
+	w.current = end
+	if finitePoints(start, end) && visibleColor(p.LineColor) {
+		w.entity("LINE", p.LineColor)
+		w.point(10, start)
+		w.point(11, end)
+	}
+}
+
+func (w *dxfWriter) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	if finitePoints(points...) && visibleColor(p.LineColor) {
+		w.polyline(points, closed, p)
+	}
+}
+
+func (w *dxfWriter) Circle(cx, cy, r float64, fill bool, p Pencil) {
+	if finitePoints(point{cx, cy}) && visibleColor(p.LineColor) {
+		w.entity("CIRCLE", p.LineColor)
+		w.point(10, point{cx, cy})
+		w.code(40, fmtnum(r/w.cs.unit2pixel, w.decimals))
+	}
+}
+
+func (w *dxfWriter) Arc(cx, cy, r, startAngle, sweepAngle float64, p Pencil) {
+	if finitePoints(point{cx, cy}) && visibleColor(p.LineColor) {
+		// The DXF arcs are counterclockwise in the user coordinates system
+		start, end := userArcAngles(w.cs, startAngle, sweepAngle)
+		w.entity("ARC", p.LineColor)
+		w.point(10, point{cx, cy})
+		w.code(40, fmtnum(r/w.cs.unit2pixel, w.decimals))
+		w.code(50, psnum(start))
+		w.code(51, psnum(end))
+	}
+}
+
+func (w *dxfWriter) Text(x, y float64, text string, p Pencil) {
+	if finitePoints(point{x, y}) {
+		// The height of a DXF text is the height of the capital letters
+		height := float64(p.FontSize) * fontCapRatio / w.cs.unit2pixel
+		w.entity("TEXT", p.FontColor)
+		w.point(10, point{x, y})
+		w.code(40, fmtnum(height, w.decimals))
+		w.code(1, dxfText(text))
+	}
+}
+
+// Image is ignored (the raster images are not supported by the DXF release 12)
+func (w *dxfWriter) Image(x, y, width, height float64, href string, style ImageStyle) {}
+
+// BeginGroup and EndGroup select the layer of the entities: the layer of a
+// group is named after the path of the nested groups (see dxfLayerName)
+func (w *dxfWriter) BeginGroup(name string) {
+	w.groups = append(w.groups, name)
+}
+
+func (w *dxfWriter) EndGroup() {
+	if len(w.groups) > 0 {
+		w.groups = w.groups[:len(w.groups)-1]
+	}
+}
+
+// ToDXF returns the sketch as an ASCII DXF document (release 12), whose
+// coordinates are the user coordinates
+func (s Sketcher) ToDXF() string {
+	cs := s.cs
+	w := &dxfWriter{
+		cs: cs, decimals: userDecimals(cs),
+		layers: map[string]bool{"0": true}, paths: map[string]string{"": "0"},
+	}
+	s.Render(w)
+	entities := w.b.String()
+	w.b.Reset()
+
+	xmin, xmax, ymin, ymax := cs.UserCoordinatesBoundaries()
+	w.code(0, "SECTION")
+	w.code(2, "HEADER")
+	w.code(9, "$ACADVER")
+	w.code(1, "AC1009")
+	w.code(9, "$EXTMIN")
+	w.coordinates(10, xmin, ymin)
+	w.code(9, "$EXTMAX")
+	w.coordinates(10, xmax, ymax)
+	w.code(0, "ENDSEC")
+
+	w.code(0, "SECTION")
+	w.code(2, "TABLES")
+	w.code(0, "TABLE")
+	w.code(2, "LTYPE")
+	w.code(70, "1")
+	w.code(0, "LTYPE")
+	w.code(2, "CONTINUOUS")
+	w.code(70, "64")
+	w.code(3, "Solid line")
+	w.code(72, "65")
+	w.code(73, "0")
+	w.code(40, "0.0")
+	w.code(0, "ENDTAB")
+	w.code(0, "TABLE")
+	w.code(2, "LAYER")
+	w.code(70, fmt.Sprint(len(w.layers)))
+	for _, layer := range sortedKeys(w.layers) {
+		w.code(0, "LAYER")
+		w.code(2, layer)
+		w.code(70, "0")
+		w.code(62, "7")
+		w.code(6, "CONTINUOUS")
+	}
+	w.code(0, "ENDTAB")
+	w.code(0, "ENDSEC")
+
+	w.code(0, "SECTION")
+	w.code(2, "ENTITIES")
+	w.b.WriteString(entities)
+	w.code(0, "ENDSEC")
+	w.code(0, "EOF")
+	return w.b.String()
+}
+
+// SaveDXF saves the sketch as an ASCII DXF file (release 12)
+func (s Sketcher) SaveDXF(dxfpath string) error {
+	file, err := os.OpenFile(dxfpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(s.ToDXF())
+	return err
+}
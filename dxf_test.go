@@ -0,0 +1,95 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDXF_ToDXF(t *testing.T) {
+	cs := NewCoordSysBottomLeft(DefaultCanvasWidth, DefaultCanvasHeight, 10)
+	s := NewSketcher().WithCoordinateSystem(cs)
+	s.BeginGroup("walls")
+	s.Rectangle(1, 1, 6, 4, true)
+	s.Edge(4, 1, 4, 5)
+	s.EndGroup()
+	s.BeginGroup("doors")
+	s.Pencil.LineColor = "red"
+	s.Arc(4, 3, 1, 180, 270)
+	s.EndGroup()
+	s.Circle(8, 8, 0.5, false)
+	s.Pencil.FontSize = 30
+	s.Text(1, 6, "Séjour")
+	s.SaveDXF("output.TestDXF_ToDXF.dxf")
+
+	res := s.ToDXF()
+	refs := []string{
+		"  9\n$ACADVER\n  1\nAC1009\n",
+		"  9\n$EXTMAX\n 10\n10\n 20\n10\n 30\n0.0\n",
+		"  0\nLAYER\n  2\nWALLS\n",
+		"  0\nLAYER\n  2\nDOORS\n",
+		"  0\nPOLYLINE\n  8\nWALLS\n 62\n7\n 66\n1\n",
+		"  0\nVERTEX\n  8\nWALLS\n 10\n7\n 20\n5\n 30\n0.0\n",
+		"  0\nLINE\n  8\nWALLS\n 62\n7\n 10\n4\n 20\n1\n 30\n0.0\n 11\n4\n 21\n5\n 31\n0.0\n",
+		"  0\nARC\n  8\nDOORS\n 62\n1\n 10\n4\n 20\n3\n 30\n0.0\n 40\n1\n 50\n180\n 51\n270\n",
+		"  0\nCIRCLE\n  8\n0\n 62\n1\n 10\n8\n 20\n8\n 30\n0.0\n 40\n0.5\n",
+		"  0\nTEXT\n  8\n0\n 62\n7\n 10\n1\n 20\n6\n 30\n0.0\n 40\n0.35\n  1\nS\\U+00E9jour\n",
+		"  0\nEOF\n",
+	}
+	for _, ref := range refs {
+		if !strings.Contains(res, ref) {
+			t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+		}
+	}
+}
+
+func TestDXF_LayerName(t *testing.T) {
+	tests := map[string]string{
+		"":                "0",
+		"walls":           "WALLS",
+		"rooms/kitchen 1": "ROOMS-KITCHEN_1",
+	}
+	for group, ref := range tests {
+		if res := dxfLayerName(group); res != ref {
+			t.Errorf("the layer name of %q is %q and should be %q", group, res, ref)
+		}
+	}
+}
+
+func TestDXF_Layers(t *testing.T) {
+	// The groups whose layer names are equal once cut to 31 characters (or
+	// with the characters replaced) are drawn on distinct layers
+	long := strings.Repeat("building", 4)
+	s := NewSketcher()
+	for _, group := range []string{long + "1", long + "2", "kitchen 1", "kitchen_1", "0", long + "1"} {
+		s.BeginGroup(group)
+		s.Edge(0.1, 0.1, 0.9, 0.9)
+		s.EndGroup()
+	}
+	res := s.ToDXF()
+	name := strings.ToUpper(long)[:31]
+	for _, ref := range []string{
+		"  0\nLAYER\n  2\n" + name + "\n",
+		"  0\nLAYER\n  2\n" + name[:29] + "-2\n",
+		"  0\nLAYER\n  2\nKITCHEN_1\n",
+		"  0\nLAYER\n  2\nKITCHEN_1-2\n",
+		"  0\nLAYER\n  2\n0-2\n",
+		"  2\nLAYER\n 70\n6\n",
+	} {
+		if !strings.Contains(res, ref) {
+			t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+		}
+	}
+	if n := strings.Count(res, "  0\nLINE\n  8\n"+name+"\n"); n != 2 {
+		t.Errorf("the layer %s has %d lines and should have the 2 lines of its group", name, n)
+	}
+
+	// The shapes without outline are not written
+	s = NewSketcher()
+	s.Pencil.LineColor = NoColor
+	s.Pencil.FillColor = "red"
+	s.Rectangle(0.1, 0.1, 0.5, 0.5, true)
+	s.Circle(0.5, 0.5, 0.1, true)
+	if res := s.ToDXF(); strings.Contains(res, "POLYLINE") || strings.Contains(res, "CIRCLE") {
+		t.Errorf("the filled shapes without outline should not be written:\n%s", res)
+	}
+}
@@ -0,0 +1,266 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// ===========================================================================
+// Encapsulated PostScript backend (EPS)
+// ===========================================================================
+
+// The EPS backend writes the recorded shapes as PostScript drawing commands.
+// Contrary to the canvas (whose y axis is oriented top down), PostScript has a
+// y axis oriented bottom up, as the user coordinates system. Then the paths are
+// written in user coordinates, and the user coordinates system is declared
+// once in the prolog as a transformation matrix (procedure U). The line widths,
+// the dash patterns and the font sizes are expressed in points (1 canvas pixel
+// = 0.75 point).
+
+const epsPrologPattern = `%%%%BeginProlog
+/U { /savedmatrix matrix currentmatrix def [%s %s %s %s %s %s] concat } bind def
+/P { savedmatrix setmatrix } bind def
+/reencode { findfont dup length dict begin { 1 index /FID ne { def } { pop pop } ifelse } forall
+  /Encoding ISOLatin1Encoding def currentdict end definefont pop } bind def
+%s%%%%EndProlog
+`
+
+type epsWriter struct {
+	b        strings.Builder
+	cs       *CoordinateSystem
+	decimals int             // number of decimals of the user coordinates
+	fonts    map[string]bool // standard font names used by the texts
+	current  point           // current point (canvas coordinates)
+}
+
+func (w *epsWriter) printf(format string, args ...any) {
+	fmt.Fprintf(&w.b, format, args...)
+}
+
+// userPoint returns the user coordinates of the canvas point p
+func (w *epsWriter) userPoint(p point) (x, y string) {
+	ux, uy := w.cs.userCoordinates(p.X, p.Y)
+	return fmtnum(ux, w.decimals), fmtnum(uy, w.decimals)
+}
+
+// path writes the path of the polyline in user coordinates (the matrix is
+// restored once the path is built, then the path is painted in points)
+func (w *epsWriter) path(points []point, closed bool) {
+	w.printf("newpath U\n")
+	for i, p := range points {
+		op := "lineto"
+		if i == 0 {
+			op = "moveto"
+		}
+		x, y := w.userPoint(p)
+		w.printf("%s %s %s\n", x, y, op)
+	}
+	if closed {
+		w.printf("closepath\n")
+	}
+	w.printf("P\n")
+}
+
+func (w *epsWriter) circle(center point, radius float64) {
+	x, y := w.userPoint(center)
+	r := radius / w.cs.unit2pixel
+	w.printf("newpath U %s %s %s 0 360 arc closepath P\n", x, y, fmtnum(r, w.decimals))
+}
+
+// arc writes the arc path in user coordinates (the angles are counterclockwise
+// in the user coordinates system, as the PostScript arc operator)
+func (w *epsWriter) arc(center point, radius, startAngle, sweepAngle float64) {
+	x, y := w.userPoint(center)
+	r := radius / w.cs.unit2pixel
+	start, end := userArcAngles(w.cs, startAngle, sweepAngle)
+	w.printf("newpath U %s %s %s %s %s arc P\n", x, y, fmtnum(r, w.decimals), psnum(start), psnum(end))
+}
+
+// setcolor sets the current color and returns false if nothing should be
+// painted with this color (PostScript has no transparency, the alpha channel
+// is ignored)
+func (w *epsWriter) setcolor(colorname string) bool {
+	r, g, b, ok := rgb(colorname)
+	if ok {
+		w.printf("%s %s %s setrgbcolor\n", psnum(r), psnum(g), psnum(b))
+	}
+	return ok
+}
+
+// paint paints the current path with the pencil p (fill and/or stroke)
+func (w *epsWriter) paint(p Pencil, fill bool) {
+	if fill {
+		w.printf("gsave\n")
+		if w.setcolor(p.FillColor) {
+			w.printf("fill\n")
+		}
+		w.printf("grestore\n")
+	}
+	if p.LineWidth > 0 && w.setcolor(p.LineColor) {
+		w.printf("%s setlinewidth\n", psnum(float64(p.LineWidth)*pointsPerPixel))
+		if len(p.Dash) > 0 {
+			dash := make([]string, len(p.Dash))
+			for i, l := range p.Dash {
+				dash[i] = psnum(l * pointsPerPixel)
+			}
+			w.printf("[%s] 0 setdash\n", strings.Join(dash, " "))
+		}
+		w.printf("stroke\n")
+	}
+}
+
+// Image writes the image in hexadecimal RGB samples (the alpha channel is
+// composed over a white background)
+func (w *epsWriter) Image(x, y, width, height float64, href string, style ImageStyle) {
+	img, err := loadImage(href)
+	if err != nil || !finitePoints(point{x, y}) {
+		return
+	}
+	b := img.Bounds()
+	x, y, width, height = imageRectangle(x, y, width, height, style, b.Dx(), b.Dy())
+	// position of the bottom left corner of the image in points
+	ch := float64(w.cs.cnvysize)
+	px, py := x*pointsPerPixel, (ch-y-height)*pointsPerPixel
+	w.printf("gsave %s %s translate %s %s scale\n",
+		psnum(px), psnum(py), psnum(width*pointsPerPixel), psnum(height*pointsPerPixel))
+	w.printf("/picstr %d string def\n", 3*b.Dx())
+	w.printf("%d %d 8 [%d 0 0 %d 0 %d] { currentfile picstr readhexstring pop } false 3 colorimage\n",
+		b.Dx(), b.Dy(), b.Dx(), -b.Dy(), b.Dy())
+	var line strings.Builder
+	for j := b.Min.Y; j < b.Max.Y; j++ {
+		for i := b.Min.X; i < b.Max.X; i++ {
+			r, g, bl, a := img.At(i, j).RGBA()
+			white := 0xffff - a
+			fmt.Fprintf(&line, "%02x%02x%02x", (r+white)>>8, (g+white)>>8, (bl+white)>>8)
+			if line.Len() >= 72 {
+				w.printf("%s\n", line.String())
+				line.Reset()
+			}
+		}
+	}
+	if line.Len() > 0 {
+		w.printf("%s\n", line.String())
+	}
+	w.printf("grestore\n")
+}
+
+// Each shape is drawn in a saved graphic state (gsave ... grestore)
+
+func (w *epsWriter) Begin(width, height int, background string) {
+	if background != Transparent && w.setcolor(background) {
+		w.printf("0 0 %s %s rectfill\n", psnum(float64(width)*pointsPerPixel), psnum(float64(height)*pointsPerPixel))
+	}
+}
+
+func (w *epsWriter) End() {}
+
+func (w *epsWriter) MoveTo(x, y float64) {
+	w.current = point{x, y}
+}
+
+func (w *epsWriter) LineTo(x, y float64, p Pencil) {
+	points := []point{w.current, {x, y}}
+	w.current = point{x, y}
+	if finitePoints(points...) {
+		w.printf("gsave\n")
+		w.path(points, false)
+		w.paint(p, false)
+		w.printf("grestore\n")
+	}
+}
+
+func (w *epsWriter) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	if finitePoints(points...) {
+		w.printf("gsave\n")
+		w.path(points, closed)
+		w.paint(p, closed && fill)
+		w.printf("grestore\n")
+	}
+}
+
+func (w *epsWriter) Circle(cx, cy, r float64, fill bool, p Pencil) {
+	if finitePoints(point{cx, cy}) {
+		w.printf("gsave\n")
+		w.circle(point{cx, cy}, r)
+		w.paint(p, fill)
+		w.printf("grestore\n")
+	}
+}
+
+func (w *epsWriter) Arc(cx, cy, r, startAngle, sweepAngle float64, p Pencil) {
+	if finitePoints(point{cx, cy}) {
+		w.printf("gsave\n")
+		w.arc(point{cx, cy}, r, startAngle, sweepAngle)
+		w.paint(p, false)
+		w.printf("grestore\n")
+	}
+}
+
+func (w *epsWriter) Text(x, y float64, text string, p Pencil) {
+	if !finitePoints(point{x, y}) {
+		return
+	}
+	w.printf("gsave\n")
+	if w.setcolor(p.FontColor) {
+		fontname := standardFontName(p.FontFamily, p.FontWeight)
+		w.fonts[fontname] = true
+		ux, uy := w.userPoint(point{x, y})
+		// The text is drawn upright in the page coordinates system
+		w.printf("newpath U %s %s moveto P /%s-Latin1 %s selectfont %s show\n",
+			ux, uy, fontname, psnum(float64(p.FontSize)*pointsPerPixel), psString(text))
+	}
+	w.printf("grestore\n")
+}
+
+func (w *epsWriter) BeginGroup(name string) {}
+func (w *epsWriter) EndGroup()              {}
+
+// ToEPS returns the sketch as an Encapsulated PostScript document. The
+// bounding box is the canvas, whose size is given in points (1 pixel = 0.75
+// point).
+func (s Sketcher) ToEPS() string {
+	cs := s.cs
+	w := &epsWriter{cs: cs, decimals: userDecimals(cs), fonts: map[string]bool{}}
+	width := float64(cs.cnvxsize) * pointsPerPixel
+	height := float64(cs.cnvysize) * pointsPerPixel
+
+	s.Render(w)
+	body := w.b.String()
+
+	// The transformation matrix from the user coordinates to the page
+	// coordinates (points, origin at the bottom left corner of the page)
+	k := pointsPerPixel * cs.unit2pixel
+	a, d := k*cs.xsign, -k*cs.ysign
+	tx := pointsPerPixel * cs.xorigin
+	ty := pointsPerPixel * (float64(cs.cnvysize) - cs.yorigin)
+
+	var fonts strings.Builder
+	for _, fontname := range sortedKeys(w.fonts) {
+		fmt.Fprintf(&fonts, "/%s-Latin1 /%s reencode\n", fontname, fontname)
+	}
+
+	var eps strings.Builder
+	eps.WriteString("%!PS-Adobe-3.0 EPSF-3.0\n")
+	fmt.Fprintf(&eps, "%%%%BoundingBox: 0 0 %d %d\n", int(math.Ceil(width)), int(math.Ceil(height)))
+	fmt.Fprintf(&eps, "%%%%HiResBoundingBox: 0 0 %s %s\n", psnum(width), psnum(height))
+	eps.WriteString("%%Creator: dingo-svg\n%%LanguageLevel: 2\n%%Pages: 1\n%%EndComments\n")
+	fmt.Fprintf(&eps, epsPrologPattern, psnum(a), "0", "0", psnum(d), psnum(tx), psnum(ty), fonts.String())
+	eps.WriteString("%%Page: 1 1\n")
+	eps.WriteString(body)
+	eps.WriteString("showpage\n%%EOF\n")
+	return eps.String()
+}
+
+// SaveEPS saves the sketch as an Encapsulated PostScript file
+func (s Sketcher) SaveEPS(epspath string) error {
+	file, err := os.OpenFile(epspath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(s.ToEPS())
+	return err
+}
@@ -0,0 +1,54 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEPS_ToEPS(t *testing.T) {
+	cs := NewCoordSysCentered(400, 200, 4)
+	s := NewSketcher().WithCoordinateSystem(cs).WithBackgroundColor("white")
+	s.Pencil.Dash = []float64{4, 2}
+	s.Edge(-1, -0.5, 1, 0.5)
+	s.Pencil.Dash = nil
+	s.Pencil.FillColor = "orange"
+	s.Circle(0, 0, 0.25, true)
+	s.Pencil.FontWeight = "bold"
+	s.Text(-1.5, 0.6, "Éléments (EPS)")
+	s.Image(1, -0.8, 0.5, 0.5, testimage(10, 10))
+	s.SaveEPS("output.TestEPS_ToEPS.eps")
+
+	res := s.ToEPS()
+	refs := []string{
+		"%!PS-Adobe-3.0 EPSF-3.0\n",
+		"%%BoundingBox: 0 0 300 150\n",
+		// user coordinates system: 100 pixels per unit, origin at the center
+		"/U { /savedmatrix matrix currentmatrix def [75 0 0 75 150 75] concat } bind def",
+		// the edge is written in user coordinates
+		"-1 -0.5 moveto\n1 0.5 lineto\n",
+		"[3 1.5] 0 setdash\n",
+		"0 0 0.25 0 360 arc",
+		"/Helvetica-Bold-Latin1 /Helvetica-Bold reencode",
+		"(\\311l\\351ments \\(EPS\\)) show",
+		"colorimage",
+		"showpage\n%%EOF\n",
+	}
+	for _, ref := range refs {
+		if !strings.Contains(res, ref) {
+			t.Errorf("the EPS document should contain %q", ref)
+		}
+	}
+}
+
+func TestEPS_TopLeftCoordinateSystem(t *testing.T) {
+	// With a top left coordinates system, the user y axis is oriented top
+	// down, then the transformation matrix inverts the y axis
+	cs := NewCoordSysTopLeft(DefaultCanvasWidth, DefaultCanvasHeight, 10)
+	s := NewSketcher().WithCoordinateSystem(cs)
+	s.Polygon(testpoints(), false)
+	res := s.ToEPS()
+	ref := "[45 0 0 -45 0 450] concat"
+	if !strings.Contains(res, ref) {
+		t.Errorf("the EPS document should contain %q", ref)
+	}
+}
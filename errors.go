@@ -0,0 +1,130 @@
+package svg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ===========================================================================
+// Drawing errors (strict mode)
+// ===========================================================================
+
+// The drawing functions do not return errors: the invalid shapes are drawn as
+// they are (or skipped, see NonFinitePolicy). In strict mode (see
+// Sketcher.WithStrictMode), the sketcher also records a DrawingError for each
+// problem detected while drawing. The errors are returned by Sketcher.Err and
+// Sketcher.Save.
+
+// ErrorKind is the category of a drawing error
+type ErrorKind int
+
+const (
+	InvalidGeometry      ErrorKind = iota // e.g. a polygon with less than 3 points, a negative radius
+	NonFiniteCoordinates                  // NaN or infinite coordinates (see NonFinitePolicy)
+	ZeroSizeCanvas                        // canvas without width or height, or degenerate scale
+	UnknownColor                          // color that can not be parsed by ParseColor
+)
+
+// String returns the name of the kind of error
+func (k ErrorKind) String() string {
+	names := [...]string{"invalid geometry", "non finite coordinates", "zero size canvas", "unknown color"}
+	if int(k) >= 0 && int(k) < len(names) {
+		return names[k]
+	}
+	return fmt.Sprintf("error %d", int(k))
+}
+
+// DrawingError is an error detected while drawing a sketch. Shape is the
+// name of the drawn element (e.g. "polygon", "canvas" or "background").
+type DrawingError struct {
+	Kind    ErrorKind
+	Shape   string
+	Message string
+}
+
+func (e *DrawingError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Shape, e.Kind, e.Message)
+}
+
+// maxDrawingErrors is the maximal number of errors recorded by a sketcher (a
+// curve sampled with many points may produce as many errors)
+const maxDrawingErrors = 20
+
+// fail records a drawing error
+func (s *Sketcher) fail(kind ErrorKind, shape string, format string, args ...any) {
+	if len(s.errs) == maxDrawingErrors {
+		s.errsDropped++
+		return
+	}
+	s.errs = append(s.errs, &DrawingError{kind, shape, fmt.Sprintf(format, args...)})
+}
+
+// Err returns the errors recorded while drawing (nil if none), joined with
+// errors.Join. Each error is a *DrawingError (use errors.As to get the first
+// one). In strict mode, the coordinates system and the background color are
+// checked too.
+func (s Sketcher) Err() error {
+	var errs []error
+	if s.strict {
+		if err := s.cs.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+		if _, err := ParseColor(s.backgroundColor); err != nil {
+			errs = append(errs, &DrawingError{UnknownColor, "background", fmt.Sprintf("%q", s.backgroundColor)})
+		}
+	}
+	errs = append(errs, s.errs...)
+	if s.errsDropped > 0 {
+		errs = append(errs, fmt.Errorf("%d more drawing errors", s.errsDropped))
+	}
+	return errors.Join(errs...)
+}
+
+// checkShape reports the problems of the shape in strict mode: the invalid
+// geometry and the unknown colors of the pencil used to draw the shape
+func (s *Sketcher) checkShape(sh shape) {
+	if !s.strict {
+		return
+	}
+	name := sh.kind.String()
+	switch sh.kind {
+	case shapePolygon:
+		if len(sh.points) < 3 {
+			s.fail(InvalidGeometry, name, "%d points (at least 3 expected)", len(sh.points))
+		}
+	case shapeCircle, shapeArc:
+		if sh.radius < 0 {
+			s.fail(InvalidGeometry, name, "negative radius %s", fmtnum(sh.radius, 2))
+		}
+	case shapeImage:
+		if sh.width == 0 || sh.height == 0 {
+			s.fail(InvalidGeometry, name, "empty rectangle %sx%s", fmtnum(sh.width, 2), fmtnum(sh.height, 2))
+		}
+	}
+
+	var colors []string
+	switch sh.kind {
+	case shapeText:
+		colors = append(colors, s.Pencil.FontColor)
+	case shapeLine, shapePolygon, shapePolyline, shapeCircle, shapeArc:
+		if s.Pencil.LineWidth > 0 {
+			colors = append(colors, s.Pencil.LineColor)
+		}
+		if sh.fill {
+			colors = append(colors, s.Pencil.FillColor)
+		}
+	}
+	for _, c := range colors {
+		if s.colorChecked[c] {
+			continue
+		}
+		if s.colorChecked == nil {
+			s.colorChecked = map[string]bool{}
+		}
+		// each color is checked (and reported) only once
+		s.colorChecked[c] = true
+		if _, err := ParseColor(c); err != nil {
+			s.fail(UnknownColor, name, "%q", c)
+		}
+	}
+}
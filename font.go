@@ -0,0 +1,230 @@
+package svg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ===========================================================================
+// Stroke font
+// ===========================================================================
+
+// The backends that can not delegate the text rendering to a viewer (raster
+// images, pen plotters, terminal) draw the texts with this simple monospace
+// stroke font. Each glyph is drawn on a grid of 4 units wide, with the baseline
+// at y=0, the capital height at y=6, the x-height at y=4 and the descender at
+// y=-2. A glyph is defined by a list of polylines separated by ";", each
+// polyline being a list of "x,y" points separated by spaces.
+
+const (
+	glyphAdvance   = 5.  // horizontal advance of a glyph (font units)
+	glyphCapHeight = 6.  // height of the capital letters (font units)
+	fontCapRatio   = 0.7 // capital height as a fraction of the font size
+)
+
+var glyphDefinitions = map[rune]string{
+	' ': "", '!': "2,6 2,2; 2,0.5 2,0", '"': "1,6 1,4.5; 3,6 3,4.5",
+	'#':  "1,0.5 1,5.5; 3,0.5 3,5.5; 0,2 4,2; 0,4 4,4",
+	'$':  "4,5 3,6 1,6 0,5 0,4 1,3 3,3 4,2 4,1 3,0 1,0 0,1; 2,6.5 2,-0.5",
+	'%':  "0,0 4,6; 0,6 1,6 1,5 0,5 0,6; 3,1 4,1 4,0 3,0 3,1",
+	'&':  "4,0 1,4 1,5 2,6 3,5 3,4 0,2 0,1 1,0 2,0 4,2",
+	'\'': "2,6 2,4.5", '(': "3,7 2,6 1.5,4 1.5,2 2,0 3,-1", ')': "1,7 2,6 2.5,4 2.5,2 2,0 1,-1",
+	'*': "2,5 2,1; 0,4 4,2; 0,2 4,4", '+': "2,5 2,1; 0,3 4,3", ',': "2,0.5 2,0 1,-1",
+	'-': "0.5,3 3.5,3", '.': "2,0.5 2,0", '/': "0,0 4,6",
+	'0': "1,0 3,0 4,1 4,5 3,6 1,6 0,5 0,1 1,0; 0,1 4,5",
+	'1': "1,5 2,6 2,0; 1,0 3,0",
+	'2': "0,5 1,6 3,6 4,5 4,4 0,0 4,0",
+	'3': "0,5 1,6 3,6 4,5 4,4 3,3 1,3; 3,3 4,2 4,1 3,0 1,0 0,1",
+	'4': "3,0 3,6 0,2 4,2",
+	'5': "4,6 0,6 0,3 3,3 4,2 4,1 3,0 0,0",
+	'6': "4,5 3,6 1,6 0,5 0,1 1,0 3,0 4,1 4,2 3,3 0,3",
+	'7': "0,6 4,6 1,0",
+	'8': "1,3 0,4 0,5 1,6 3,6 4,5 4,4 3,3 1,3 0,2 0,1 1,0 3,0 4,1 4,2 3,3",
+	'9': "4,3 1,3 0,4 0,5 1,6 3,6 4,5 4,1 3,0 1,0 0,1",
+	':': "2,4 2,3.5; 2,0.5 2,0", ';': "2,4 2,3.5; 2,0.5 2,0 1,-1",
+	'<': "4,5 0,3 4,1", '=': "0,2 4,2; 0,4 4,4", '>': "0,5 4,3 0,1",
+	'?': "0,5 1,6 3,6 4,5 4,4 2,2.5 2,1.5; 2,0.5 2,0",
+	'@': "3,2 3,4 1,4 1,2 3,2 4,2 4,5 3,6 1,6 0,5 0,1 1,0 4,0",
+	'A': "0,0 0,4 2,6 4,4 4,0; 0,3 4,3",
+	'B': "0,0 0,6 3,6 4,5 4,4 3,3 0,3; 3,3 4,2 4,1 3,0 0,0",
+	'C': "4,5 3,6 1,6 0,5 0,1 1,0 3,0 4,1",
+	'D': "0,0 0,6 2,6 4,4 4,2 2,0 0,0",
+	'E': "4,6 0,6 0,0 4,0; 0,3 3,3",
+	'F': "4,6 0,6 0,0; 0,3 3,3",
+	'G': "4,5 3,6 1,6 0,5 0,1 1,0 3,0 4,1 4,3 2,3",
+	'H': "0,0 0,6; 4,0 4,6; 0,3 4,3",
+	'I': "1,6 3,6; 2,6 2,0; 1,0 3,0",
+	'J': "2,6 4,6; 3,6 3,1 2,0 1,0 0,1",
+	'K': "0,0 0,6; 4,6 0,2; 1,3 4,0",
+	'L': "0,6 0,0 4,0",
+	'M': "0,0 0,6 2,3 4,6 4,0",
+	'N': "0,0 0,6 4,0 4,6",
+	'O': "1,0 3,0 4,1 4,5 3,6 1,6 0,5 0,1 1,0",
+	'P': "0,0 0,6 3,6 4,5 4,4 3,3 0,3",
+	'Q': "1,0 3,0 4,1 4,5 3,6 1,6 0,5 0,1 1,0; 2,2 4,0",
+	'R': "0,0 0,6 3,6 4,5 4,4 3,3 0,3; 2,3 4,0",
+	'S': "4,5 3,6 1,6 0,5 0,4 1,3 3,3 4,2 4,1 3,0 1,0 0,1",
+	'T': "0,6 4,6; 2,6 2,0",
+	'U': "0,6 0,1 1,0 3,0 4,1 4,6",
+	'V': "0,6 2,0 4,6",
+	'W': "0,6 1,0 2,4 3,0 4,6",
+	'X': "0,0 4,6; 0,6 4,0",
+	'Y': "0,6 2,3 4,6; 2,3 2,0",
+	'Z': "0,6 4,6 0,0 4,0",
+	'[': "3,7 2,7 2,-1 3,-1", '\\': "0,6 4,0", ']': "1,7 2,7 2,-1 1,-1",
+	'^': "1,5 2,6 3,5", '_': "0,-1 4,-1", '`': "1.5,6 2.5,5",
+	'a': "1,4 3,4 4,3 4,0; 4,2 1,2 0,1 1,0 3,0 4,1",
+	'b': "0,6 0,0 3,0 4,1 4,3 3,4 0,4",
+	'c': "4,4 1,4 0,3 0,1 1,0 4,0",
+	'd': "4,6 4,0 1,0 0,1 0,3 1,4 4,4",
+	'e': "0,2 4,2 4,3 3,4 1,4 0,3 0,1 1,0 4,0",
+	'f': "4,6 3,6 2,5 2,0; 1,4 3,4",
+	'g': "4,4 4,-1 3,-2 1,-2; 4,1 3,0 1,0 0,1 0,3 1,4 4,4",
+	'h': "0,6 0,0; 0,4 3,4 4,3 4,0",
+	'i': "2,4 2,0; 2,5.5 2,5",
+	'j': "3,4 3,-1 2,-2 1,-2; 3,5.5 3,5",
+	'k': "0,6 0,0; 4,4 0,1.5; 1.5,2.5 4,0",
+	'l': "1,6 2,6 2,0; 1,0 3,0",
+	'm': "0,0 0,4; 0,3 1,4 2,3 2,0; 2,3 3,4 4,3 4,0",
+	'n': "0,0 0,4; 0,3 1,4 3,4 4,3 4,0",
+	'o': "1,0 3,0 4,1 4,3 3,4 1,4 0,3 0,1 1,0",
+	'p': "0,-2 0,4 3,4 4,3 4,1 3,0 0,0",
+	'q': "4,-2 4,4 1,4 0,3 0,1 1,0 4,0",
+	'r': "0,0 0,4; 0,3 1,4 4,4",
+	's': "4,4 1,4 0,3 1,2 3,2 4,1 3,0 0,0",
+	't': "2,6 2,1 3,0 4,0; 1,4 3,4",
+	'u': "0,4 0,1 1,0 3,0 4,1; 4,4 4,0",
+	'v': "0,4 2,0 4,4",
+	'w': "0,4 1,0 2,3 3,0 4,4",
+	'x': "0,0 4,4; 0,4 4,0",
+	'y': "0,4 2,0; 4,4 1,-2",
+	'z': "0,4 4,4 0,0 4,0",
+	'{': "3,7 2,6 2,4 1,3 2,2 2,0 3,-1", '|': "2,7 2,-1", '}': "1,7 2,6 2,4 3,3 2,2 2,0 1,-1",
+	'~': "0,3 1,4 3,3 4,4",
+}
+
+// accentDefinitions are the accent glyphs, defined relatively to the top of the
+// letter (the y coordinates are offsets from the top of the letter)
+var accentDefinitions = map[rune]string{
+	'\u0301': "1.5,0.5 2.5,1.5",                 // acute
+	'\u0300': "1.5,1.5 2.5,0.5",                 // grave
+	'\u0302': "1,0.5 2,1.5 3,0.5",               // circumflex
+	'\u0308': "1,1 1,1.5; 3,1 3,1.5",            // diaeresis
+	'\u0327': "2,0 2,-0.5 1,-1.5",               // cedilla (relative to the baseline)
+	'\u0303': "0.5,0.5 1.5,1.5 2.5,0.5 3.5,1.5", // tilde
+}
+
+// accentedLetters maps the accented latin letters to their base letter and
+// accent
+var accentedLetters = map[rune][2]rune{
+	'à': {'a', '\u0300'}, 'â': {'a', '\u0302'}, 'ä': {'a', '\u0308'}, 'á': {'a', '\u0301'},
+	'é': {'e', '\u0301'}, 'è': {'e', '\u0300'}, 'ê': {'e', '\u0302'}, 'ë': {'e', '\u0308'},
+	'î': {'i', '\u0302'}, 'ï': {'i', '\u0308'}, 'í': {'i', '\u0301'},
+	'ô': {'o', '\u0302'}, 'ö': {'o', '\u0308'}, 'ó': {'o', '\u0301'},
+	'ù': {'u', '\u0300'}, 'û': {'u', '\u0302'}, 'ü': {'u', '\u0308'}, 'ú': {'u', '\u0301'},
+	'ç': {'c', '\u0327'}, 'ñ': {'n', '\u0303'},
+	'À': {'A', '\u0300'}, 'Â': {'A', '\u0302'}, 'Ä': {'A', '\u0308'},
+	'É': {'E', '\u0301'}, 'È': {'E', '\u0300'}, 'Ê': {'E', '\u0302'}, 'Ë': {'E', '\u0308'},
+	'Î': {'I', '\u0302'}, 'Ï': {'I', '\u0308'}, 'Ô': {'O', '\u0302'}, 'Ö': {'O', '\u0308'},
+	'Ù': {'U', '\u0300'}, 'Û': {'U', '\u0302'}, 'Ü': {'U', '\u0308'}, 'Ç': {'C', '\u0327'},
+}
+
+// glyph is a list of polylines in font units
+type glyph [][]point
+
+var glyphs map[rune]glyph = parseGlyphs(glyphDefinitions)
+var accents map[rune]glyph = parseGlyphs(accentDefinitions)
+
+func parseGlyph(definition string) glyph {
+	var g glyph
+	for _, stroke := range strings.Split(definition, ";") {
+		var polyline []point
+		for _, p := range strings.Fields(stroke) {
+			xy := strings.Split(p, ",")
+			x, _ := strconv.ParseFloat(xy[0], 64)
+			y, _ := strconv.ParseFloat(xy[1], 64)
+			polyline = append(polyline, point{x, y})
+		}
+		if len(polyline) > 0 {
+			g = append(g, polyline)
+		}
+	}
+	return g
+}
+
+func parseGlyphs(definitions map[rune]string) map[rune]glyph {
+	glyphs := make(map[rune]glyph, len(definitions))
+	for r, definition := range definitions {
+		glyphs[r] = parseGlyph(definition)
+	}
+	return glyphs
+}
+
+// runeGlyph returns the glyph of the character r. The accented letters are
+// composed from their base letter and the accent. The characters that are not
+// defined in the font are drawn with a question mark.
+func runeGlyph(r rune) glyph {
+	if g, ok := glyphs[r]; ok {
+		return g
+	}
+	if la, ok := accentedLetters[r]; ok {
+		base := glyphs[la[0]]
+		top := 4.
+		if la[0] >= 'A' && la[0] <= 'Z' {
+			top = glyphCapHeight
+		}
+		if la[1] == '\u0327' {
+			top = 0
+		}
+		if la[0] == 'i' {
+			// no dot on the accented i
+			base = base[:1]
+		}
+		g := append(glyph{}, base...)
+		for _, stroke := range accents[la[1]] {
+			polyline := make([]point, len(stroke))
+			for i, p := range stroke {
+				polyline[i] = point{p.X, top + p.Y}
+			}
+			g = append(g, polyline)
+		}
+		return g
+	}
+	return glyphs['?']
+}
+
+// fontUnit returns the size in pixels of a font unit for the font size
+func fontUnit(fontsize float64) float64 {
+	return fontsize * fontCapRatio / glyphCapHeight
+}
+
+// textWidth returns the width in pixels of the text drawn with the stroke font
+// of size fontsize
+func textWidth(text string, fontsize float64) float64 {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return fontUnit(fontsize) * (glyphAdvance*float64(n) - 1)
+}
+
+// textStrokes returns the polylines (canvas coordinates) to draw the text with
+// the stroke font of size fontsize, the baseline of the text starting at the
+// canvas point (px, py).
+func textStrokes(text string, px, py float64, fontsize float64) [][]point {
+	unit := fontUnit(fontsize)
+	var strokes [][]point
+	x0 := px
+	for _, r := range text {
+		for _, stroke := range runeGlyph(r) {
+			polyline := make([]point, len(stroke))
+			for i, p := range stroke {
+				// the canvas y axis is oriented top down
+				polyline[i] = point{x0 + unit*p.X, py - unit*p.Y}
+			}
+			strokes = append(strokes, polyline)
+		}
+		x0 += unit * glyphAdvance
+	}
+	return strokes
+}
@@ -0,0 +1,45 @@
+package svg
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// ===========================================================================
+// Formatting of the numbers and keys written by the backends
+// ===========================================================================
+
+// fmtnum formats a number with the given number of decimals at most, without
+// trailing zeros
+func fmtnum(v float64, decimals int) string {
+	k := math.Pow10(decimals)
+	v = math.Round(v*k) / k
+	if v == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// psnum formats a number for the PostScript and PDF content streams (3
+// decimals at most, without trailing zeros)
+func psnum(v float64) string {
+	return fmtnum(v, 3)
+}
+
+// userDecimals returns the number of decimals required to write the user
+// coordinates with a precision of 1/100 pixel
+func userDecimals(cs *CoordinateSystem) int {
+	return max(0, int(math.Ceil(math.Log10(cs.unit2pixel)))) + 2
+}
+
+// sortedKeys returns the keys of the map in increasing order (the documents
+// are written in a deterministic order)
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
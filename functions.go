@@ -0,0 +1,184 @@
+package svg
+
+import (
+	"math"
+)
+
+// ===========================================================================
+// Curves of functions (adaptive sampling)
+// ===========================================================================
+
+// The curves are sampled adaptively: the parameter interval is divided in
+// curveInitialSamples intervals, and each interval is divided in two until the
+// polyline deviates from the curve by less than curveTolerance pixels (then
+// the curved parts get more points than the straight parts), and the segments
+// are shorter than curveMaxSegment pixels (a midpoint aligned with the end
+// points of a large interval does not prove that the curve is straight). The
+// curve is broken where the function is not defined (NaN or infinite values),
+// and where the function is discontinuous (e.g. the poles of tan or 1/x), i.e.
+// where two points remain far from each other on the canvas while the interval
+// between them can not be divided anymore.
+
+const (
+	curveInitialSamples = 64
+	curveMaxDepth       = 10  // maximal number of divisions of an initial interval
+	curveTolerance      = 0.5 // maximal deviation of the polyline (pixels)
+	curveMaxSegment     = 20  // maximal length of a segment (pixels)
+	curveMaxJump        = 10  // minimal length of a discontinuity (pixels)
+)
+
+// curveSample is a point of the curve for the parameter t (ok is false if
+// the point is not defined)
+type curveSample struct {
+	t  float64
+	p  point
+	ok bool
+}
+
+type curveSampler struct {
+	fx, fy         func(t float64) float64
+	xscale, yscale float64 // number of pixels in a unit along x and y
+	lines          [][]point
+	line           []point // current polyline
+}
+
+func (c *curveSampler) at(t float64) curveSample {
+	p := point{c.fx(t), c.fy(t)}
+	return curveSample{t, p, isFinitePoint(p)}
+}
+
+// breakLine ends the current polyline
+func (c *curveSampler) breakLine() {
+	if len(c.line) > 1 {
+		c.lines = append(c.lines, c.line)
+	}
+	c.line = nil
+}
+
+// length returns the length (pixels) of the segment [a, b]
+func (c *curveSampler) length(a, b point) float64 {
+	return math.Hypot((b.X-a.X)*c.xscale, (b.Y-a.Y)*c.yscale)
+}
+
+// deviation returns the distance (pixels) from the point m to the segment
+// [a, b]
+func (c *curveSampler) deviation(a, m, b point) float64 {
+	ax, ay := a.X*c.xscale, a.Y*c.yscale
+	mx, my := m.X*c.xscale-ax, m.Y*c.yscale-ay
+	bx, by := b.X*c.xscale-ax, b.Y*c.yscale-ay
+	l2 := bx*bx + by*by
+	t := 0.
+	if l2 > 0 {
+		t = min(max((mx*bx+my*by)/l2, 0), 1)
+	}
+	return math.Hypot(mx-t*bx, my-t*by)
+}
+
+// subdivide adds the points of the curve from the sample a (excluded) to the
+// sample b (included)
+func (c *curveSampler) subdivide(a, b curveSample, depth int) {
+	if !a.ok && !b.ok && depth > 0 {
+		return
+	}
+	if depth < curveMaxDepth {
+		m := c.at(0.5 * (a.t + b.t))
+		if !a.ok || !b.ok || !m.ok || c.length(a.p, b.p) > curveMaxSegment || c.deviation(a.p, m.p, b.p) > curveTolerance {
+			c.subdivide(a, m, depth+1)
+			c.subdivide(m, b, depth+1)
+			return
+		}
+		c.line = append(c.line, b.p)
+		return
+	}
+
+	// the interval can not be divided anymore
+	switch {
+	case !b.ok:
+		c.breakLine()
+	case !a.ok:
+		c.line = append(c.line, b.p)
+	case c.length(a.p, b.p) > curveMaxJump:
+		// discontinuity
+		c.breakLine()
+		c.line = append(c.line, b.p)
+	default:
+		c.line = append(c.line, b.p)
+	}
+}
+
+// SampleCurve returns the polylines approximating the parametric curve (fx(t),
+// fy(t)) for t in [tmin, tmax]. xscale and yscale are the number of pixels in
+// a unit along the x and y axis: the polylines deviate from the curve by less
+// than half a pixel. The curve is broken where it is not defined or
+// discontinuous.
+func SampleCurve(fx, fy func(t float64) float64, tmin, tmax, xscale, yscale float64) [][]struct{ X, Y float64 } {
+	c := &curveSampler{fx: fx, fy: fy, xscale: math.Abs(xscale), yscale: math.Abs(yscale)}
+	a := c.at(tmin)
+	if a.ok {
+		c.line = append(c.line, a.p)
+	}
+	for i := 1; i <= curveInitialSamples; i++ {
+		b := c.at(tmin + (tmax-tmin)*float64(i)/curveInitialSamples)
+		c.subdivide(a, b, 0)
+		a = b
+	}
+	c.breakLine()
+	return c.lines
+}
+
+// Curve draws the open polyline defined by the points as a single shape (a
+// polyline element in SVG), contrary to Polyline that draws each edge as a
+// line: the dash pattern of the pencil goes on along the whole polyline.
+func (s *Sketcher) Curve(points []struct{ X, Y float64 }) {
+	if len(points) == 0 {
+		return
+	}
+	cpoints := make([]point, len(points))
+	for i, p := range points {
+		px, py := s.canvasCoordinates(p.X, p.Y)
+		cpoints[i] = point{px, py}
+	}
+	s.record(shape{kind: shapePolyline, points: cpoints})
+	last := points[len(points)-1]
+	s.x, s.y = last.X, last.Y
+}
+
+// drawCurve draws the polylines of the curve, with the resolution of the
+// coordinates system of the sketcher
+func (s *Sketcher) drawCurve(fx, fy func(t float64) float64, tmin, tmax float64) {
+	scale := s.cs.unit2pixel
+	for _, line := range SampleCurve(fx, fy, tmin, tmax, scale, scale) {
+		s.Curve(line)
+	}
+}
+
+// PlotFunc draws the curve of the function y = f(x) for x in [xmin, xmax]
+// (see SampleCurve)
+func (s *Sketcher) PlotFunc(f func(x float64) float64, xmin, xmax float64) {
+	s.drawCurve(func(t float64) float64 { return t }, f, xmin, xmax)
+}
+
+// PlotParametric draws the parametric curve (fx(t), fy(t)) for t in [tmin,
+// tmax] (see SampleCurve)
+func (s *Sketcher) PlotParametric(fx, fy func(t float64) float64, tmin, tmax float64) {
+	s.drawCurve(fx, fy, tmin, tmax)
+}
+
+// PlotPolar draws the polar curve r = f(theta) for theta in [thetamin,
+// thetamax] (radians, counterclockwise from the x axis). The default range,
+// if thetamin equals thetamax, is [0, 2π].
+func (s *Sketcher) PlotPolar(f func(theta float64) float64, thetamin, thetamax float64) {
+	if thetamin == thetamax {
+		thetamin, thetamax = 0, 2*math.Pi
+	}
+	fx, fy := PolarFuncs(f)
+	s.drawCurve(fx, fy, thetamin, thetamax)
+}
+
+// PolarFuncs returns the parametric functions x(theta) and y(theta) of the
+// polar curve r = f(theta)
+func PolarFuncs(f func(theta float64) float64) (fx, fy func(theta float64) float64) {
+	fx = func(theta float64) float64 { return f(theta) * math.Cos(theta) }
+	fy = func(theta float64) float64 { return f(theta) * math.Sin(theta) }
+	return fx, fy
+}
@@ -0,0 +1,79 @@
+package svg
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFunctions_SampleCurve(t *testing.T) {
+	identity := func(t float64) float64 { return t }
+
+	// A straight line is not divided, and a curve is divided according to
+	// the resolution
+	line := SampleCurve(identity, identity, 0, 1, 600, 600)
+	if len(line) != 1 || len(line[0]) != curveInitialSamples+1 {
+		t.Errorf("the straight line should be one polyline with %d points", curveInitialSamples+1)
+	}
+	sine := SampleCurve(identity, math.Sin, 0, 2*math.Pi, 600, 600)
+	if len(sine) != 1 || len(sine[0]) <= len(line[0]) {
+		t.Errorf("the sine should be one polyline with more points than the line")
+	}
+	if lowres := SampleCurve(identity, math.Sin, 0, 2*math.Pi, 100, 100); len(lowres[0]) >= len(sine[0]) {
+		t.Errorf("the sine should have less points at a lower resolution")
+	}
+
+	// The curve is broken at the poles of tan (no vertical spike) and where
+	// the function is not defined
+	tan := SampleCurve(identity, math.Tan, -5, 5, 60, 60)
+	if len(tan) != 5 {
+		t.Errorf("tan on [-5,5] should be drawn with 5 polylines, not %d", len(tan))
+	}
+	inverse := SampleCurve(identity, func(x float64) float64 { return 1 / x }, -1, 1, 300, 300)
+	if len(inverse) != 2 {
+		t.Errorf("1/x on [-1,1] should be drawn with 2 polylines, not %d", len(inverse))
+	}
+	for _, polyline := range inverse {
+		for i := 1; i < len(polyline); i++ {
+			if polyline[i-1].X < 0 && polyline[i].X > 0 {
+				t.Errorf("the polyline of 1/x should not cross x=0")
+			}
+		}
+	}
+	sqrt := SampleCurve(identity, math.Sqrt, -1, 1, 300, 300)
+	if len(sqrt) != 1 || sqrt[0][0].X > 1e-3 {
+		t.Errorf("the square root should start close to x=0, not %v", sqrt[0][0])
+	}
+}
+
+func TestFunctions_PlotFunc(t *testing.T) {
+	cs := NewCoordSysCentered(600, 600, 12)
+	s := NewSketcher().WithCoordinateSystem(cs).WithBackgroundColor("white")
+	s.Pencil.LineWidth = 1
+	s.Edge(-6, 0, 6, 0)
+	s.Edge(0, -6, 0, 6)
+	s.Pencil.LineColor = "blue"
+	s.PlotFunc(math.Tan, -6, 6)
+	s.Pencil.LineColor = "red"
+	s.PlotFunc(func(x float64) float64 { return 1 / x }, -6, 6)
+	s.Pencil.LineColor = "green"
+	s.PlotPolar(func(theta float64) float64 { return 4 * math.Cos(3*theta) }, 0, math.Pi)
+	s.Pencil.LineColor = "orange"
+	s.PlotParametric(math.Sin, func(t float64) float64 { return math.Sin(2 * t) }, 0, 2*math.Pi)
+	s.Save("output.TestFunctions_PlotFunc.svg")
+	s.SavePNG("output.TestFunctions_PlotFunc.png", 1)
+
+	// The position is the last point of the last curve
+	if x, y := s.Position(); math.Abs(x) > 1e-9 || math.Abs(y) > 1e-9 {
+		t.Errorf("the position is (%g,%g) and should be (0,0)", x, y)
+	}
+
+	// The default range of the polar curves is a full turn
+	full, empty := NewSketcher(), NewSketcher()
+	circle := func(theta float64) float64 { return 0.3 }
+	full.PlotPolar(circle, 0, 2*math.Pi)
+	empty.PlotPolar(circle, 0, 0)
+	if res := empty.ToSVG(); res != full.ToSVG() || !strings.Contains(res, "<polyline") {
+		t.Errorf("the polar curve without range should be drawn on [0, 2π]:\n%s", res)
+	}
+}
@@ -0,0 +1,75 @@
+package svg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ===========================================================================
+// G-code backend (pen plotter)
+// ===========================================================================
+
+// The G-code backend writes the strokes of the plotter passes (see plotter.go)
+// as G-code instructions, in millimetres and absolute coordinates. The pen up
+// moves are rapid moves (G0) and the pen down moves are linear moves (G1). The
+// feed rate of the plotter options is set at the beginning of the program,
+// before any pen down move: it is modal and the rapid moves do not change it,
+// then it also applies to the pen down command if this is a linear move (e.g.
+// "G1 Z0"). The feed rate is always written (DefaultPlotterDrawSpeed if the
+// DrawSpeed of the options is not positive), since the controllers reject the
+// linear moves without a feed rate (e.g. GRBL). There is no travel rate: the
+// rapid moves are done at the maximal speed of the machine, whatever the feed
+// rate. The commands that lift, lower and change the pen depend on the
+// machine, and are given by the options.
+
+type gcodeWriter struct {
+	b strings.Builder
+}
+
+func (w *gcodeWriter) command(command string) {
+	if command != "" {
+		w.b.WriteString(command + "\n")
+	}
+}
+
+func (w *gcodeWriter) move(g string, p point) {
+	fmt.Fprintf(&w.b, "%s X%s Y%s\n", g, fmtnum(p.X, 3), fmtnum(p.Y, 3))
+}
+
+// ToGCode returns the sketch as a G-code program, with a pass per pen color
+func (s Sketcher) ToGCode(opts PlotterOptions) string {
+	w := &gcodeWriter{}
+	w.command("; dingo-svg")
+	w.command("G21 ; millimetres")
+	w.command("G90 ; absolute coordinates")
+	w.command(fmt.Sprintf("F%s ; feed rate (mm/min)", fmtnum(opts.drawSpeed(), 0)))
+	w.command(opts.PenUp)
+	for i, pass := range s.plotterPasses(opts) {
+		w.command(fmt.Sprintf("; pen %d: %s", i+1, pass.color))
+		w.command(opts.PenChange)
+		for _, stroke := range pass.strokes {
+			w.move("G0", stroke[0])
+			w.command(opts.PenDown)
+			for _, p := range stroke[1:] {
+				w.move("G1", p)
+			}
+			w.command(opts.PenUp)
+		}
+	}
+	w.move("G0", point{0, 0})
+	w.command("M2")
+	return w.b.String()
+}
+
+// SaveGCode saves the sketch as a G-code file
+func (s Sketcher) SaveGCode(gcodepath string, opts PlotterOptions) error {
+	file, err := os.OpenFile(gcodepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(s.ToGCode(opts))
+	return err
+}
@@ -0,0 +1,57 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// ===========================================================================
+// HPGL backend (pen plotter)
+// ===========================================================================
+
+// The HPGL backend writes the strokes of the plotter passes (see plotter.go)
+// as HPGL instructions. The coordinates are given in plotter units (1/40 mm),
+// the pens are selected with the instruction SP (pen 1 for the first pass, pen
+// 2 for the second, etc.), and the pen moves are the instructions PU (pen up)
+// and PD (pen down). The draw speed is set with the instruction VS (cm/s).
+
+// hpglUnitsPerMillimeter is the number of plotter units in a millimetre
+const hpglUnitsPerMillimeter = 40.
+
+func hpglCoordinates(p point) string {
+	return fmt.Sprintf("%d,%d", int(math.Round(p.X*hpglUnitsPerMillimeter)), int(math.Round(p.Y*hpglUnitsPerMillimeter)))
+}
+
+// ToHPGL returns the sketch as a HPGL program, one pen per color
+func (s Sketcher) ToHPGL(opts PlotterOptions) string {
+	var b strings.Builder
+	b.WriteString("IN;\n")
+	fmt.Fprintf(&b, "VS%s;\n", psnum(opts.drawSpeed()/600)) // mm/min -> cm/s
+	for i, pass := range s.plotterPasses(opts) {
+		fmt.Fprintf(&b, "SP%d;\n", i+1)
+		for _, stroke := range pass.strokes {
+			coords := make([]string, len(stroke)-1)
+			for j, p := range stroke[1:] {
+				coords[j] = hpglCoordinates(p)
+			}
+			fmt.Fprintf(&b, "PU%s;\nPD%s;\n", hpglCoordinates(stroke[0]), strings.Join(coords, ","))
+		}
+		b.WriteString("PU;\n")
+	}
+	b.WriteString("SP0;\n")
+	return b.String()
+}
+
+// SaveHPGL saves the sketch as a HPGL file
+func (s Sketcher) SaveHPGL(hpglpath string, opts PlotterOptions) error {
+	file, err := os.OpenFile(hpglpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(s.ToHPGL(opts))
+	return err
+}
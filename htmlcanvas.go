@@ -0,0 +1,266 @@
+package svg
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// ===========================================================================
+// HTML canvas backend (JavaScript)
+// ===========================================================================
+
+// The HTML canvas backend writes the recorded shapes as a JavaScript script
+// that replays the drawing on the 2D context of an HTML canvas element. The
+// coordinates are the canvas coordinates (pixels), and the style of the context
+// (colors, line width, dash, font) is set only when it changes. The drawing
+// commands are wrapped in short functions (see htmlCanvasPrelude) to keep the
+// script small for the sketches with a lot of shapes, for which the browser is
+// faster than with the SVG DOM.
+
+const htmlCanvasPattern = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>dingo-svg</title>
+</head>
+<body>
+<canvas id="sketch" width="%d" height="%d"></canvas>
+<script>
+%s</script>
+</body>
+</html>
+`
+
+// htmlCanvasPrelude defines the drawing functions of the script:
+// L (line), P (polygon, or polyline if open), C (circle), A (arc), T (text) and I (image). The
+// images are loaded before the drawing, to keep the order of the shapes (an
+// image that can not be loaded is skipped).
+const htmlCanvasPrelude = `const ctx = document.getElementById("sketch").getContext("2d");
+function L(x1, y1, x2, y2) {
+  ctx.beginPath(); ctx.moveTo(x1, y1); ctx.lineTo(x2, y2); ctx.stroke();
+}
+function P(p, fill, stroke, open) {
+  ctx.beginPath(); ctx.moveTo(p[0], p[1]);
+  for (let i = 2; i < p.length; i += 2) ctx.lineTo(p[i], p[i + 1]);
+  if (!open) ctx.closePath(); if (fill) ctx.fill(); if (stroke) ctx.stroke();
+}
+function C(x, y, r, fill, stroke) {
+  ctx.beginPath(); ctx.arc(x, y, r, 0, 2 * Math.PI);
+  if (fill) ctx.fill(); if (stroke) ctx.stroke();
+}
+function A(x, y, r, a1, a2, ccw) {
+  ctx.beginPath(); ctx.arc(x, y, r, a1, a2, ccw); ctx.stroke();
+}
+function T(x, y, text) {
+  ctx.fillText(text, x, y);
+}
+function I(img, x, y, w, h, meet, opacity) {
+  if (!img) return;
+  if (meet) {
+    const k = Math.min(w / img.width, h / img.height);
+    x += (w - k * img.width) / 2; y += (h - k * img.height) / 2;
+    w = k * img.width; h = k * img.height;
+  }
+  ctx.save(); ctx.globalAlpha = opacity; ctx.drawImage(img, x, y, w, h); ctx.restore();
+}
+`
+
+type canvasWriter struct {
+	b       strings.Builder
+	images  []string          // sources of the images
+	current point             // current point
+	style   map[string]string // current values of the context properties
+}
+
+func (w *canvasWriter) printf(format string, args ...any) {
+	fmt.Fprintf(&w.b, format, args...)
+}
+
+// set sets the property of the context if its value changes
+func (w *canvasWriter) set(property, value string) {
+	if w.style[property] != value {
+		w.style[property] = value
+		w.printf("ctx.%s = %s;\n", property, value)
+	}
+}
+
+// jsString returns the JavaScript string literal of s (the JSON encoding
+// escapes the characters < and > that could close the script element)
+func jsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func jsnum(v float64) string {
+	return fmtnum(v, 2)
+}
+
+func jsbool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// visibleColor returns true if something is painted with the color colorname
+func visibleColor(colorname string) bool {
+	c, err := ParseColor(colorname)
+	return err == nil && c.A > 0
+}
+
+// stroke sets the stroke style of the pencil and returns false if the lines
+// are not visible
+func (w *canvasWriter) stroke(p Pencil) bool {
+	if p.LineWidth <= 0 || !visibleColor(p.LineColor) {
+		return false
+	}
+	w.set("strokeStyle", jsString(p.LineColor))
+	w.set("lineWidth", fmt.Sprint(p.LineWidth))
+	dash := make([]string, len(p.Dash))
+	for i, l := range p.Dash {
+		dash[i] = jsnum(l)
+	}
+	if value := "[" + strings.Join(dash, ",") + "]"; w.style["dash"] != value {
+		w.style["dash"] = value
+		w.printf("ctx.setLineDash(%s);\n", value)
+	}
+	return true
+}
+
+// fill sets the fill style to the color colorname and returns false if the
+// color is not visible
+func (w *canvasWriter) fill(colorname string) bool {
+	if !visibleColor(colorname) {
+		return false
+	}
+	w.set("fillStyle", jsString(colorname))
+	return true
+}
+
+func (w *canvasWriter) coordinates(points []point) string {
+	coords := make([]string, 2*len(points))
+	for i, p := range points {
+		coords[2*i], coords[2*i+1] = jsnum(p.X), jsnum(p.Y)
+	}
+	return strings.Join(coords, ",")
+}
+
+func (w *canvasWriter) Begin(width, height int, background string) {
+	if background != Transparent && w.fill(background) {
+		w.printf("ctx.fillRect(0,0,%d,%d);\n", width, height)
+	}
+}
+
+func (w *canvasWriter) End() {}
+
+func (w *canvasWriter) MoveTo(x, y float64) {
+	w.current = point{x, y}
+}
+
+func (w *canvasWriter) LineTo(x, y float64, p Pencil) {
+	points := []point{w.current, {x, y}}
+	w.current = point{x, y}
+	if finitePoints(points...) && w.stroke(p) {
+		w.printf("L(%s);\n", w.coordinates(points))
+	}
+}
+
+func (w *canvasWriter) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	if !finitePoints(points...) {
+		return
+	}
+	if !closed {
+		if w.stroke(p) {
+			w.printf("P([%s],0,1,1);\n", w.coordinates(points))
+		}
+		return
+	}
+	fill = fill && w.fill(p.FillColor)
+	stroke := w.stroke(p)
+	if fill || stroke {
+		w.printf("P([%s],%s,%s);\n", w.coordinates(points), jsbool(fill), jsbool(stroke))
+	}
+}
+
+func (w *canvasWriter) Circle(cx, cy, r float64, fill bool, p Pencil) {
+	if !finitePoints(point{cx, cy}) {
+		return
+	}
+	fill = fill && w.fill(p.FillColor)
+	stroke := w.stroke(p)
+	if fill || stroke {
+		w.printf("C(%s,%s,%s,%s,%s);\n", jsnum(cx), jsnum(cy), jsnum(r), jsbool(fill), jsbool(stroke))
+	}
+}
+
+func (w *canvasWriter) Arc(cx, cy, r, startAngle, sweepAngle float64, p Pencil) {
+	if !finitePoints(point{cx, cy}) || !w.stroke(p) {
+		return
+	}
+	// The angles of the canvas are measured from the x axis towards the y
+	// axis (clockwise on the screen), as the angles of the renderer
+	a1 := startAngle * math.Pi / 180
+	a2 := (startAngle + sweepAngle) * math.Pi / 180
+	w.printf("A(%s,%s,%s,%s,%s,%s);\n", jsnum(cx), jsnum(cy), jsnum(r),
+		fmtnum(a1, 4), fmtnum(a2, 4), jsbool(sweepAngle < 0))
+}
+
+func (w *canvasWriter) Text(x, y float64, text string, p Pencil) {
+	if !finitePoints(point{x, y}) || !w.fill(p.FontColor) {
+		return
+	}
+	w.set("font", jsString(fmt.Sprintf("%s %dpx %s", p.FontWeight, p.FontSize, p.FontFamily)))
+	w.printf("T(%s,%s,%s);\n", jsnum(x), jsnum(y), jsString(text))
+}
+
+func (w *canvasWriter) Image(x, y, width, height float64, href string, style ImageStyle) {
+	if !finitePoints(point{x, y}) {
+		return
+	}
+	w.images = append(w.images, href)
+	meet := style.AspectRatio != ImageAspectRatioNone
+	w.printf("I(images[%d],%s,%s,%s,%s,%s,%s);\n", len(w.images)-1, jsnum(x), jsnum(y),
+		jsnum(width), jsnum(height), jsbool(meet), jsnum(style.Opacity))
+}
+
+func (w *canvasWriter) BeginGroup(name string) {}
+func (w *canvasWriter) EndGroup()              {}
+
+// ToHTMLCanvas returns a standalone HTML document with a canvas element and a
+// script that draws the sketch on the canvas
+func (s Sketcher) ToHTMLCanvas() string {
+	w := &canvasWriter{style: map[string]string{}}
+	s.Render(w)
+
+	var script strings.Builder
+	script.WriteString(htmlCanvasPrelude)
+	sources := make([]string, len(w.images))
+	for i, href := range w.images {
+		sources[i] = jsString(href)
+	}
+	fmt.Fprintf(&script, "const sources = [%s];\n", strings.Join(sources, ","))
+	script.WriteString("function draw(images) {\n")
+	script.WriteString(w.b.String())
+	script.WriteString("}\n")
+	script.WriteString(`Promise.all(sources.map(src => new Promise(resolve => {
+  const img = new Image(); img.onload = () => resolve(img); img.onerror = () => resolve(null);
+  img.src = src;
+}))).then(draw);
+`)
+	return fmt.Sprintf(htmlCanvasPattern, s.cs.cnvxsize, s.cs.cnvysize, script.String())
+}
+
+// SaveHTMLCanvas saves the sketch as a standalone HTML file (see ToHTMLCanvas)
+func (s Sketcher) SaveHTMLCanvas(htmlpath string) error {
+	file, err := os.OpenFile(htmlpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(s.ToHTMLCanvas())
+	return err
+}
@@ -0,0 +1,40 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLCanvas_ToHTMLCanvas(t *testing.T) {
+	s := NewSketcher().WithBackgroundColor("white")
+	s.Edge(0.2, 0.2, 0.8, 0.8)
+	s.Edge(0.8, 0.8, 0.8, 0.2)
+	s.Pencil.FillColor = "blue"
+	s.Polygon(testpoints(), true)
+	s.Circle(0.5, 0.5, 0.1, false)
+	s.Arc(0.5, 0.5, 0.2, 0, 90)
+	s.Text(0.1, 0.9, "</script>")
+	s.Image(0.1, 0.1, 0.2, 0.2, testimage(4, 4))
+	s.SaveHTMLCanvas("output.TestHTMLCanvas_ToHTMLCanvas.html")
+
+	res := s.ToHTMLCanvas()
+	refs := []string{
+		"<canvas id=\"sketch\" width=\"600\" height=\"600\"></canvas>\n",
+		"ctx.fillStyle = \"white\";\nctx.fillRect(0,0,600,600);\n",
+		// the style is set only once for the two lines
+		"ctx.strokeStyle = \"black\";\nctx.lineWidth = 2;\nctx.setLineDash([]);\nL(120,480,480,120);\nL(480,120,480,480);\n",
+		"ctx.fillStyle = \"blue\";\nP([120,480,180,120,360,240,480,120,480,480],1,1);\n",
+		"C(300,300,60,0,1);\n",
+		// the arc goes counterclockwise on the screen (y axis bottom up)
+		"A(300,300,120,0,-1.5708,1);\n",
+		// the text can not close the script element
+		"T(60,60,\"\\u003c/script\\u003e\");\n",
+		"I(images[0],60,420,120,120,1,1);\n",
+		"const sources = [\"data:image/png;base64,",
+	}
+	for _, ref := range refs {
+		if !strings.Contains(res, ref) {
+			t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+)
+
+// ===========================================================================
+// Raster images
+// ===========================================================================
+
+// The link to the image is the xlink:href attribute of SVG 1.1, with its
+// namespace declared on the element (the SVG 2 href attribute is not read by
+// the SVG 1.1 renderers, e.g. rsvg, while the SVG 2 renderers still read
+// xlink:href)
+const (
+	xlinkNamespace = "http://www.w3.org/1999/xlink"
+	imagePattern   = "<image xmlns:xlink='" + xlinkNamespace + "' x='%s' y='%s' width='%s' height='%s' preserveAspectRatio='%s' opacity='%.2f' xlink:href='%s'/>"
+)
+
+const (
+	DefaultImageOpacity     = 1.
+	DefaultImageAspectRatio = "xMidYMid meet"
+	// The image is stretched to fill the whole rectangle
+	ImageAspectRatioNone = "none"
+)
+
+// ImageStyle defines how the raster images are placed in the sketch. The
+// AspectRatio is the value of the SVG attribute preserveAspectRatio (e.g.
+// "xMidYMid meet" to fit the image in the rectangle while keeping the aspect
+// ratio, or "none" to stretch the image to the rectangle).
+type ImageStyle struct {
+	Opacity     float64 // from 0 (transparent) to 1 (opaque)
+	AspectRatio string
+}
+
+var defaultImageStyle = ImageStyle{
+	Opacity:     DefaultImageOpacity,
+	AspectRatio: DefaultImageAspectRatio,
+}
+
+// imageMimeType returns the MIME type of the encoded image data (PNG, JPEG or
+// GIF), guessed from the signature of the data.
+func imageMimeType(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png", nil
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return "image/jpeg", nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "image/gif", nil
+	}
+	return "", fmt.Errorf("unsupported image format (should be PNG, JPEG or GIF)")
+}
+
+// imageDataURI returns the data URI that embeds the encoded image data
+func imageDataURI(data []byte) (string, error) {
+	mimetype, err := imageMimeType(data)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + mimetype + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// decodeDataURI returns the image data and the MIME type embedded in the data
+// URI (base64 encoding)
+func decodeDataURI(uri string) (data []byte, mimetype string, err error) {
+	header, encoded, found := strings.Cut(uri, ",")
+	if !found || !strings.HasPrefix(header, "data:") || !strings.HasSuffix(header, ";base64") {
+		return nil, "", fmt.Errorf("invalid image data URI")
+	}
+	data, err = base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", err
+	}
+	mimetype = strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+	return data, mimetype, nil
+}
+
+// canvasRectangle returns the canvas rectangle (top left corner and size in
+// pixels) corresponding to the user rectangle defined by the corner (x, y) and
+// the size width x height, whatever the orientation of the axis.
+func (s Sketcher) canvasRectangle(x, y, width, height float64) (px, py, pwidth, pheight float64) {
+	px1, py1 := s.canvasCoordinates(x, y)
+	px2, py2 := s.canvasCoordinates(x+width, y+height)
+	px, py = math.Min(px1, px2), math.Min(py1, py2)
+	pwidth, pheight = math.Abs(px2-px1), math.Abs(py2-py1)
+	return px, py, pwidth, pheight
+}
+
+func (s *Sketcher) imageHref(x, y, width, height float64, href string) {
+	px, py, pw, ph := s.canvasRectangle(x, y, width, height)
+	s.record(shape{
+		kind: shapeImage, points: []point{{px, py}},
+		width: pw, height: ph, href: href, imagestyle: s.ImageStyle,
+	})
+	s.x = x
+	s.y = y
+}
+
+// Image draws the raster image img in the rectangle defined by the corner (x,
+// y) and the size width x height (in user coordinates). The image is embedded
+// in the sketch as a PNG data URI. The image is always drawn upright, whatever
+// the orientation of the coordinates system axis.
+func (s *Sketcher) Image(x, y, width, height float64, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return s.ImageData(x, y, width, height, buf.Bytes())
+}
+
+// ImageData draws the encoded image data (PNG, JPEG or GIF) in the rectangle
+// defined by the corner (x, y) and the size width x height. The data are
+// embedded in the sketch as a data URI, without decoding.
+func (s *Sketcher) ImageData(x, y, width, height float64, data []byte) error {
+	uri, err := imageDataURI(data)
+	if err != nil {
+		return err
+	}
+	s.imageHref(x, y, width, height, uri)
+	return nil
+}
+
+// ImageFile draws the image file imgpath (PNG, JPEG or GIF) in the rectangle
+// defined by the corner (x, y) and the size width x height. If embed is true,
+// the file content is embedded in the sketch as a data URI, otherwise the
+// sketch only links the file (then the path should be relative to the location
+// of the SVG file, or absolute).
+func (s *Sketcher) ImageFile(x, y, width, height float64, imgpath string, embed bool) error {
+	if !embed {
+		s.imageHref(x, y, width, height, imgpath)
+		return nil
+	}
+	data, err := os.ReadFile(imgpath)
+	if err != nil {
+		return err
+	}
+	return s.ImageData(x, y, width, height, data)
+}
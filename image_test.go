@@ -0,0 +1,70 @@
+package svg
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testimage returns a small image with a color gradient
+func testimage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range width {
+		for j := range height {
+			r := uint8(255 * i / width)
+			g := uint8(255 * j / height)
+			img.Set(i, j, color.RGBA{r, g, 128, 255})
+		}
+	}
+	return img
+}
+
+func TestSketcher_Image(t *testing.T) {
+	s := NewSketcher()
+	s.ImageStyle.Opacity = 0.5
+	if err := s.Image(0.1, 0.2, 0.4, 0.3, testimage(40, 30)); err != nil {
+		t.Fatal(err)
+	}
+	s.Rectangle(0.1, 0.2, 0.4, 0.3, false)
+	s.Save("output.TestSketcher_Image.svg")
+
+	res := s.ToSVG()
+	// With the default coordinates system (y axis bottom up), the top left
+	// corner of the image is the point (0.1, 0.5)
+	ref := "<image xmlns:xlink='http://www.w3.org/1999/xlink' x='60.00' y='300.00' width='240.00' height='180.00' preserveAspectRatio='xMidYMid meet' opacity='0.50' xlink:href='data:image/png;base64,"
+	if !strings.Contains(res, ref) {
+		t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+	}
+}
+
+func TestSketcher_ImageFile(t *testing.T) {
+	imgpath := "output.TestSketcher_ImageFile.png"
+	file, err := os.Create(imgpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(file, testimage(60, 60))
+	file.Close()
+
+	s := NewSketcher()
+	if err := s.ImageFile(0.1, 0.1, 0.3, 0.3, imgpath, true); err != nil {
+		t.Error(err)
+	}
+	if err := s.ImageFile(0.5, 0.5, 0.3, 0.3, imgpath, false); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(s.ToSVG(), "xlink:href='"+imgpath+"'") {
+		t.Errorf("the svg should contain a link to the image %s", imgpath)
+	}
+	s.Save("output.TestSketcher_ImageFile.svg")
+
+	if err := s.ImageFile(0, 0, 1, 1, "output.nosuchfile.png", true); err == nil {
+		t.Errorf("an error should be raised for a missing file")
+	}
+	if err := s.ImageData(0, 0, 1, 1, []byte("not an image")); err == nil {
+		t.Errorf("an error should be raised for an unknown image format")
+	}
+}
@@ -0,0 +1,512 @@
+package svg
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ===========================================================================
+// PDF backend
+// ===========================================================================
+
+// The PDF backend writes the recorded shapes as vector graphics in a PDF
+// document (PDF 1.4), without any external dependency. The texts are written
+// with the standard 14 fonts of PDF (Helvetica, Times and Courier families),
+// that are available in all the PDF readers. A document can contain several
+// pages, each page being a sketch.
+
+// pointsPerPixel is the size in points (1/72 inch) of a canvas pixel, which is
+// the CSS pixel (1/96 inch).
+const pointsPerPixel = 0.75
+
+// PaperSize is the size of a sheet of paper, in points (1/72 inch)
+type PaperSize struct {
+	Name          string
+	Width, Height float64
+}
+
+// Landscape returns the paper size in landscape orientation
+func (p PaperSize) Landscape() PaperSize {
+	return PaperSize{p.Name + " landscape", math.Max(p.Width, p.Height), math.Min(p.Width, p.Height)}
+}
+
+var (
+	PaperA3     = PaperSize{"A3", 841.89, 1190.55}
+	PaperA4     = PaperSize{"A4", 595.28, 841.89}
+	PaperA5     = PaperSize{"A5", 419.53, 595.28}
+	PaperLetter = PaperSize{"Letter", 612, 792}
+	PaperLegal  = PaperSize{"Legal", 612, 1008}
+)
+
+// PDFDocument is a multi pages PDF document. Each page contains the drawing of
+// a sketch, whose size is either the size of the sketch canvas or the size of
+// a sheet of paper (the drawing is then scaled to fit the paper).
+type PDFDocument struct {
+	pages []pdfPage
+}
+
+type pdfPage struct {
+	sketch Sketcher
+	paper  *PaperSize // nil if the page size is the canvas size
+	margin float64    // margin between the drawing and the paper boundaries (points)
+}
+
+func NewPDFDocument() *PDFDocument {
+	return &PDFDocument{}
+}
+
+// AddPage adds a page with the drawing of the sketch s. The page size is the
+// size of the canvas of the sketch.
+func (d *PDFDocument) AddPage(s *Sketcher) {
+	d.pages = append(d.pages, pdfPage{sketch: *s})
+}
+
+// AddPageOnPaper adds a page of the given paper size with the drawing of the
+// sketch s. The drawing is scaled to fit the paper (minus the margin, in
+// points) and centered on the page.
+func (d *PDFDocument) AddPageOnPaper(s *Sketcher, paper PaperSize, margin float64) {
+	d.pages = append(d.pages, pdfPage{sketch: *s, paper: &paper, margin: margin})
+}
+
+// NumberOfPages returns the number of pages of the document
+func (d PDFDocument) NumberOfPages() int {
+	return len(d.pages)
+}
+
+// --------------------------------------------------------------------
+// PDF objects writer
+
+type pdfWriter struct {
+	objects [][]byte // the content of the object n is objects[n-1]
+}
+
+// reserve allocates an object number, whose content is set later
+func (w *pdfWriter) reserve() int {
+	w.objects = append(w.objects, nil)
+	return len(w.objects)
+}
+
+func (w *pdfWriter) set(n int, content string) {
+	w.objects[n-1] = []byte(content)
+}
+
+func (w *pdfWriter) add(content string) int {
+	n := w.reserve()
+	w.set(n, content)
+	return n
+}
+
+// addStream adds a stream object compressed with the Flate filter. The extra
+// entries are added to the stream dictionary.
+func (w *pdfWriter) addStream(data []byte, extra string) int {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	var obj bytes.Buffer
+	fmt.Fprintf(&obj, "<< /Length %d /Filter /FlateDecode %s>>\nstream\n", buf.Len(), extra)
+	obj.Write(buf.Bytes())
+	obj.WriteString("\nendstream")
+	n := w.reserve()
+	w.objects[n-1] = obj.Bytes()
+	return n
+}
+
+func (w *pdfWriter) writeTo(out io.Writer, root int) error {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(w.objects))
+	for i, obj := range w.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		buf.Write(obj)
+		buf.WriteString("\nendobj\n")
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.objects)+1, root, xref)
+	_, err := out.Write(buf.Bytes())
+	return err
+}
+
+// --------------------------------------------------------------------
+// Conversion of the fonts and the texts
+
+// standardFontName returns the name of the standard font (one of the standard
+// 14 fonts of PDF and PostScript) that matches the font family and the font
+// weight of the pencil
+func standardFontName(family, weight string) string {
+	bold := weight == "bold" || weight == "bolder" || weight == "600" || weight == "700" || weight == "800" || weight == "900"
+	family = strings.ToLower(family)
+	switch {
+	case strings.Contains(family, "courier") || strings.Contains(family, "mono"):
+		if bold {
+			return "Courier-Bold"
+		}
+		return "Courier"
+	case strings.Contains(family, "times") || family == "serif" || strings.Contains(family, "roman"):
+		if bold {
+			return "Times-Bold"
+		}
+		return "Times-Roman"
+	}
+	if bold {
+		return "Helvetica-Bold"
+	}
+	return "Helvetica"
+}
+
+// psString returns the PostScript (and PDF) literal string of the text encoded
+// in Latin-1, which is also the WinAnsi encoding for the characters above 160
+// (the characters that can not be encoded are replaced by a question mark)
+func psString(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// --------------------------------------------------------------------
+// Conversion of the shapes into PDF content stream
+
+// bezierCircle is the distance of the control points to the end points of the
+// cubic Bezier curves approximating a quarter of circle of radius 1.
+const bezierCircle = 0.5522847498
+
+type pdfContent struct {
+	buf     bytes.Buffer
+	current point              // current point (canvas coordinates)
+	fonts   map[string]string  // font name -> resource name
+	gstates map[string]float64 // graphic state resource name -> opacity
+	images  []pdfImage
+}
+
+type pdfImage struct {
+	name string
+	img  image.Image
+}
+
+func (c *pdfContent) printf(format string, args ...any) {
+	fmt.Fprintf(&c.buf, format, args...)
+}
+
+// color sets the color (stroke color if stroke is true, fill color otherwise)
+// and the corresponding opacity. It returns false if the color is transparent
+// or unknown, i.e. if nothing should be painted.
+func (c *pdfContent) color(colorname string, stroke bool) bool {
+	col, err := ParseColor(colorname)
+	if err != nil || col.A == 0 {
+		return false
+	}
+	a := float64(col.A)
+	r, g, b := float64(col.R)/a, float64(col.G)/a, float64(col.B)/a
+	op, alphakey := "rg", "ca"
+	if stroke {
+		op, alphakey = "RG", "CA"
+	}
+	c.printf("%s %s %s %s\n", psnum(r), psnum(g), psnum(b), op)
+	if col.A < 255 {
+		opacity := a / 255
+		gsname := fmt.Sprintf("GS%s%03d", alphakey, int(math.Round(opacity*100)))
+		c.gstates[gsname] = opacity
+		c.printf("/%s gs\n", gsname)
+	}
+	return true
+}
+
+func (c *pdfContent) path(points []point, closed bool) {
+	for i, p := range points {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		c.printf("%s %s %s\n", psnum(p.X), psnum(p.Y), op)
+	}
+	if closed {
+		c.printf("h\n")
+	}
+}
+
+func (c *pdfContent) circle(center point, r float64) {
+	k := bezierCircle * r
+	x, y := center.X, center.Y
+	c.printf("%s %s m\n", psnum(x+r), psnum(y))
+	c.printf("%s %s %s %s %s %s c\n", psnum(x+r), psnum(y+k), psnum(x+k), psnum(y+r), psnum(x), psnum(y+r))
+	c.printf("%s %s %s %s %s %s c\n", psnum(x-k), psnum(y+r), psnum(x-r), psnum(y+k), psnum(x-r), psnum(y))
+	c.printf("%s %s %s %s %s %s c\n", psnum(x-r), psnum(y-k), psnum(x-k), psnum(y-r), psnum(x), psnum(y-r))
+	c.printf("%s %s %s %s %s %s c\nh\n", psnum(x+k), psnum(y-r), psnum(x+r), psnum(y-k), psnum(x+r), psnum(y))
+}
+
+// paint paints the path drawn by drawpath (fill and/or stroke) with the pencil
+// p, in a saved graphic state
+func (c *pdfContent) paint(p Pencil, fill bool, stroke bool, drawpath func()) {
+	c.printf("q\n")
+	defer c.printf("Q\n")
+	fill = fill && c.color(p.FillColor, false)
+	stroke = stroke && p.LineWidth > 0 && c.color(p.LineColor, true)
+	if !fill && !stroke {
+		return
+	}
+	if stroke {
+		c.printf("%d w\n", p.LineWidth)
+		if len(p.Dash) > 0 {
+			c.printf("[%s] 0 d\n", strings.ReplaceAll(p.dashArray(), ",", " "))
+		}
+	}
+	drawpath()
+	switch {
+	case fill && stroke:
+		c.printf("B\n")
+	case fill:
+		c.printf("f\n")
+	default:
+		c.printf("S\n")
+	}
+}
+
+func (c *pdfContent) Begin(width, height int, background string) {
+	if c.color(background, false) {
+		c.printf("0 0 %d %d re f\n", width, height)
+	}
+}
+
+func (c *pdfContent) End() {}
+
+func (c *pdfContent) MoveTo(x, y float64) {
+	c.current = point{x, y}
+}
+
+func (c *pdfContent) LineTo(x, y float64, p Pencil) {
+	points := []point{c.current, {x, y}}
+	c.current = point{x, y}
+	if finitePoints(points...) {
+		c.paint(p, false, true, func() { c.path(points, false) })
+	}
+}
+
+func (c *pdfContent) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	if finitePoints(points...) {
+		c.paint(p, closed && fill, true, func() { c.path(points, closed) })
+	}
+}
+
+func (c *pdfContent) Circle(cx, cy, r float64, fill bool, p Pencil) {
+	if finitePoints(point{cx, cy}) {
+		c.paint(p, fill, true, func() { c.circle(point{cx, cy}, r) })
+	}
+}
+
+func (c *pdfContent) Arc(cx, cy, r, startAngle, sweepAngle float64, p Pencil) {
+	if finitePoints(point{cx, cy}) {
+		points := arcPoints(cx, cy, r, startAngle, sweepAngle)
+		c.paint(p, false, true, func() { c.path(points, false) })
+	}
+}
+
+func (c *pdfContent) Text(x, y float64, text string, p Pencil) {
+	if !finitePoints(point{x, y}) {
+		return
+	}
+	c.printf("q\n")
+	defer c.printf("Q\n")
+	if !c.color(p.FontColor, false) {
+		return
+	}
+	fontname := standardFontName(p.FontFamily, p.FontWeight)
+	resname, ok := c.fonts[fontname]
+	if !ok {
+		resname = fmt.Sprintf("F%d", len(c.fonts)+1)
+		c.fonts[fontname] = resname
+	}
+	// The text matrix flips the y axis back, since the canvas coordinates
+	// system is oriented top down
+	c.printf("BT /%s %d Tf 1 0 0 -1 %s %s Tm %s Tj ET\n",
+		resname, p.FontSize, psnum(x), psnum(y), psString(text))
+}
+
+func (c *pdfContent) Image(x, y, width, height float64, href string, style ImageStyle) {
+	img, err := loadImage(href)
+	if err != nil || !finitePoints(point{x, y}) {
+		return
+	}
+	b := img.Bounds()
+	x, y, w, h := imageRectangle(x, y, width, height, style, b.Dx(), b.Dy())
+	name := fmt.Sprintf("Im%d", len(c.images)+1)
+	c.images = append(c.images, pdfImage{name, img})
+	c.printf("q\n")
+	if opacity := min(max(style.Opacity, 0), 1); opacity < 1 {
+		gsname := fmt.Sprintf("GSca%03d", int(math.Round(opacity*100)))
+		c.gstates[gsname] = opacity
+		c.printf("/%s gs\n", gsname)
+	}
+	// The image is drawn in the unit square, with its first row at y=1
+	c.printf("%s 0 0 %s %s %s cm /%s Do\n", psnum(w), psnum(-h), psnum(x), psnum(y+h), name)
+	c.printf("Q\n")
+}
+
+func (c *pdfContent) BeginGroup(name string) {}
+func (c *pdfContent) EndGroup()              {}
+
+// addImage adds the image XObject (and its alpha mask) to the PDF objects
+func (w *pdfWriter) addImage(img image.Image) int {
+	b := img.Bounds()
+	rgb := make([]byte, 0, 3*b.Dx()*b.Dy())
+	alpha := make([]byte, 0, b.Dx()*b.Dy())
+	opaque := true
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			if a > 0 {
+				// the PDF image samples are not alpha premultiplied
+				r, g, bl = r*0xffff/a, g*0xffff/a, bl*0xffff/a
+			}
+			rgb = append(rgb, byte(r>>8), byte(g>>8), byte(bl>>8))
+			alpha = append(alpha, byte(a>>8))
+			opaque = opaque && a == 0xffff
+		}
+	}
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 8 ", b.Dx(), b.Dy())
+	smask := ""
+	if !opaque {
+		n := w.addStream(alpha, dict+"/ColorSpace /DeviceGray ")
+		smask = fmt.Sprintf("/SMask %d 0 R ", n)
+	}
+	return w.addStream(rgb, dict+"/ColorSpace /DeviceRGB "+smask)
+}
+
+// pageGeometry returns the size of the page and the transformation from the
+// canvas coordinates to the page coordinates (scale factor and position of the
+// bottom left corner of the canvas)
+func (p pdfPage) pageGeometry() (pw, ph, scale, ox, oy float64) {
+	cw := float64(p.sketch.cs.cnvxsize)
+	ch := float64(p.sketch.cs.cnvysize)
+	if p.paper == nil {
+		return cw * pointsPerPixel, ch * pointsPerPixel, pointsPerPixel, 0, 0
+	}
+	pw, ph = p.paper.Width, p.paper.Height
+	scale = math.Min((pw-2*p.margin)/cw, (ph-2*p.margin)/ch)
+	ox = 0.5 * (pw - scale*cw)
+	oy = 0.5 * (ph - scale*ch)
+	return pw, ph, scale, ox, oy
+}
+
+// Write writes the PDF document to the writer out
+func (d PDFDocument) Write(out io.Writer) error {
+	if len(d.pages) == 0 {
+		return fmt.Errorf("the PDF document has no page")
+	}
+	w := &pdfWriter{}
+	catalog := w.reserve()
+	pagesobj := w.reserve()
+
+	fontobjs := map[string]int{} // font name -> object number
+	var kids []string
+	for _, page := range d.pages {
+		c := &pdfContent{fonts: map[string]string{}, gstates: map[string]float64{}}
+		pw, ph, scale, ox, oy := page.pageGeometry()
+		s := page.sketch
+		ch := float64(s.cs.cnvysize)
+
+		// The canvas coordinates system (y axis top down) is mapped on the page
+		// coordinates system (y axis bottom up) by the current transformation
+		// matrix.
+		c.printf("%s 0 0 %s %s %s cm\n", psnum(scale), psnum(-scale), psnum(ox), psnum(oy+scale*ch))
+		s.Render(c)
+
+		// Resources of the page
+		var resources strings.Builder
+		resources.WriteString("<< ")
+		if len(c.fonts) > 0 {
+			resources.WriteString("/Font << ")
+			for _, fontname := range sortedKeys(c.fonts) {
+				n, ok := fontobjs[fontname]
+				if !ok {
+					n = w.add(fmt.Sprintf(
+						"<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontname))
+					fontobjs[fontname] = n
+				}
+				fmt.Fprintf(&resources, "/%s %d 0 R ", c.fonts[fontname], n)
+			}
+			resources.WriteString(">> ")
+		}
+		if len(c.gstates) > 0 {
+			resources.WriteString("/ExtGState << ")
+			for _, gsname := range sortedKeys(c.gstates) {
+				key := gsname[2:4]
+				fmt.Fprintf(&resources, "/%s << /%s %s >> ", gsname, key, psnum(c.gstates[gsname]))
+			}
+			resources.WriteString(">> ")
+		}
+		if len(c.images) > 0 {
+			resources.WriteString("/XObject << ")
+			for _, im := range c.images {
+				fmt.Fprintf(&resources, "/%s %d 0 R ", im.name, w.addImage(im.img))
+			}
+			resources.WriteString(">> ")
+		}
+		resources.WriteString(">>")
+
+		content := w.addStream(c.buf.Bytes(), "")
+		pageobj := w.add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pagesobj, psnum(pw), psnum(ph), resources.String(), content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageobj))
+	}
+
+	w.set(pagesobj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesobj))
+	return w.writeTo(out, catalog)
+}
+
+// Save saves the PDF document in the file pdfpath
+func (d PDFDocument) Save(pdfpath string) error {
+	file, err := os.OpenFile(pdfpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return d.Write(file)
+}
+
+// --------------------------------------------------------------------
+// Sketch export functions
+
+// SavePDF saves the sketch as a single page PDF document, whose page size is
+// the canvas size.
+func (s Sketcher) SavePDF(pdfpath string) error {
+	d := NewPDFDocument()
+	d.AddPage(&s)
+	return d.Save(pdfpath)
+}
+
+// SavePDFOnPaper saves the sketch as a single page PDF document, whose page
+// size is the paper size. The drawing is scaled to fit the paper (minus the
+// margin, in points).
+func (s Sketcher) SavePDFOnPaper(pdfpath string, paper PaperSize, margin float64) error {
+	d := NewPDFDocument()
+	d.AddPageOnPaper(&s, paper, margin)
+	return d.Save(pdfpath)
+}
@@ -0,0 +1,94 @@
+package svg
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// checkPDFStructure checks that the cross reference table of the PDF document
+// gives the right offsets of the objects
+func checkPDFStructure(t *testing.T, data []byte) {
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) {
+		t.Fatalf("the document should start with the PDF header")
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatalf("the document should end with the startxref section")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("the startxref offset %d should point to the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		header := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(data[offset:], []byte(header)) {
+			t.Errorf("the offset %d should point to the object %d", offset, i+1)
+		}
+	}
+}
+
+func TestPDF_SavePDF(t *testing.T) {
+	s := NewSketcher().WithBackgroundColor("white")
+	s.Pencil.FillColor = "rgba(255,0,0,0.5)"
+	s.Rectangle(0.1, 0.1, 0.5, 0.3, true)
+	s.Circle(0.5, 0.5, 0.2, false)
+	s.Pencil.FontFamily = "monospace"
+	s.Text(0.1, 0.8, "Texte (accentué)")
+	s.Image(0.6, 0.6, 0.3, 0.3, testimage(30, 30))
+	if err := s.SavePDF("output.TestPDF_SavePDF.pdf"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	d := NewPDFDocument()
+	d.AddPage(s)
+	if err := d.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	checkPDFStructure(t, data)
+	for _, ref := range []string{"/MediaBox [0 0 450 450]", "/BaseFont /Courier", "/Subtype /Image", "/ca 0.5"} {
+		if !bytes.Contains(data, []byte(ref)) {
+			t.Errorf("the document should contain %q", ref)
+		}
+	}
+}
+
+func TestPDF_MultiPages(t *testing.T) {
+	d := NewPDFDocument()
+
+	s1 := NewSketcher()
+	s1.Polygon(testpoints(), false)
+	d.AddPage(s1)
+
+	s2 := NewSketcher().WithCoordinateSystem(NewCoordSysBottomLeft(800, 400, 2))
+	s2.Polyline(testpoints(), true)
+	s2.Text(0.2, 0.1, "A4 landscape")
+	d.AddPageOnPaper(s2, PaperA4.Landscape(), 36)
+
+	if d.NumberOfPages() != 2 {
+		t.Errorf("the document has %d pages (should be %d)", d.NumberOfPages(), 2)
+	}
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	checkPDFStructure(t, data)
+	if !bytes.Contains(data, []byte("/Count 2")) {
+		t.Errorf("the document should contain 2 pages")
+	}
+	if !bytes.Contains(data, []byte("/MediaBox [0 0 841.89 595.28]")) {
+		t.Errorf("the second page should have the size of an A4 landscape paper")
+	}
+	d.Save("output.TestPDF_MultiPages.pdf")
+
+	if err := NewPDFDocument().Write(&buf); err == nil {
+		t.Errorf("an error should be raised for a document without page")
+	}
+}
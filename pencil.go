@@ -1,6 +1,10 @@
 package svg
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // ===========================================================================
 // Pencil and style management
@@ -8,6 +12,7 @@ import "fmt"
 
 const (
 	drawStylePattern = "stroke: %s; stroke-width: %d; fill: %s"
+	dashStylePattern = "; stroke-dasharray: %s"
 	textStylePattern = "font-family:%s; font-size:%d; font-weight:%s; fill: %s"
 )
 
@@ -30,6 +35,9 @@ type Pencil struct {
 	LineWidth int
 	FillColor string
 	FillMode  bool // if true, fill any closed shape with the FillColor color
+	// Dash is the dash pattern of the lines, i.e. the lengths (pixels) of
+	// the alternating dashes and gaps. The line is solid if Dash is empty.
+	Dash []float64
 
 	// Parameters for the text
 	FontFamily string
@@ -56,7 +64,20 @@ func (p Pencil) DrawStyleWithFillMode(fill bool) string {
 	if !fill {
 		fillcolor = "none"
 	}
-	return fmt.Sprintf(drawStylePattern, p.LineColor, p.LineWidth, fillcolor)
+	style := fmt.Sprintf(drawStylePattern, p.LineColor, p.LineWidth, fillcolor)
+	if len(p.Dash) > 0 {
+		style += fmt.Sprintf(dashStylePattern, p.dashArray())
+	}
+	return style
+}
+
+// dashArray returns the dash pattern as a comma separated list of lengths
+func (p Pencil) dashArray() string {
+	lengths := make([]string, len(p.Dash))
+	for i, l := range p.Dash {
+		lengths[i] = strconv.FormatFloat(l, 'f', -1, 64)
+	}
+	return strings.Join(lengths, ",")
 }
 
 func (p Pencil) DrawStyle() string {
@@ -73,6 +94,7 @@ func (p Pencil) Clone() *Pencil {
 		LineWidth:  p.LineWidth,
 		FillColor:  p.FillColor,
 		FillMode:   p.FillMode,
+		Dash:       append([]float64(nil), p.Dash...),
 		FontFamily: p.FontFamily,
 		FontWeight: p.FontWeight,
 		FontSize:   p.FontSize,
@@ -0,0 +1,377 @@
+package plot
+
+import (
+	"math"
+	"slices"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Bar charts and histograms
+// ===========================================================================
+
+// The bars are laid out on a categorical axis (see svg.Axis.Categories): the
+// category i is at the value i of the axis, and its bars share the fraction
+// BarWidth of the interval [i-0.5, i+0.5]. The bar series of a figure are
+// drawn side by side (grouped) or on top of each other (stacked).
+
+const DefaultBarWidth = 0.8 // fraction of the category interval filled by the bars
+
+// BarMode defines how the bar series of a figure are combined
+type BarMode int
+
+const (
+	BarsGrouped BarMode = iota // the bars of a category are side by side
+	BarsStacked                // the bars of a category are on top of each other
+)
+
+// BarSeries is a series of bars: Values[i] is the length of the bar of the
+// category i
+type BarSeries struct {
+	Values []float64
+	Label  string // label of the series in the legend (not in the legend if empty)
+	Color  string // color of the bars (the next color of the theme palette if empty)
+
+	colorIndex int
+}
+
+// barChart is the element drawing the bar series of a figure
+type barChart struct {
+	figure *Figure
+	names  []string // names of the categories
+	series []*BarSeries
+}
+
+// Bars adds to the figure a series of bars, one bar for each category (the
+// categories of the first bar series are the categories of the figure). The
+// layout of the bars is defined by the figure fields BarMode, BarWidth and
+// HorizontalBars.
+func (f *Figure) Bars(categories []string, values []float64) *BarSeries {
+	if f.bars == nil {
+		f.bars = &barChart{figure: f, names: categories}
+		f.add(f.bars)
+	}
+	s := &BarSeries{Values: values, colorIndex: f.nextColor()}
+	f.bars.series = append(f.bars.series, s)
+	return s
+}
+
+// position returns the position on the categorical axis of the category i
+func (b *barChart) position(i int) float64 {
+	if b.figure.HorizontalBars {
+		// the first category is at the top
+		return float64(len(b.names) - 1 - i)
+	}
+	return float64(i)
+}
+
+// extents returns the start and end values of the bar of the series k for
+// the category i (the bars are stacked from zero, the positive values upward
+// and the negative values downward)
+func (b *barChart) extents(k, i int) (start, end float64) {
+	value := func(k int) float64 {
+		if i < len(b.series[k].Values) && finite(b.series[k].Values[i]) {
+			return b.series[k].Values[i]
+		}
+		return 0
+	}
+	v := value(k)
+	if b.figure.BarMode != BarsStacked {
+		return 0, v
+	}
+	for j := range k {
+		if w := value(j); (w > 0) == (v > 0) {
+			start += w
+		}
+	}
+	return start, start + v
+}
+
+func (b *barChart) bounds() (x, y Range) {
+	values := Range{0, 0}
+	for k := range b.series {
+		for i := range b.names {
+			start, end := b.extents(k, i)
+			values = values.Extend(start, end)
+		}
+	}
+	positions := Range{-0.5, float64(len(b.names)) - 0.5}
+	if b.figure.HorizontalBars {
+		return values, positions
+	}
+	return positions, values
+}
+
+func (b *barChart) zeroBased() (x, y bool) {
+	return b.figure.HorizontalBars, !b.figure.HorizontalBars
+}
+
+func (b *barChart) categories() (x, y []string) {
+	if b.figure.HorizontalBars {
+		reversed := slices.Clone(b.names)
+		slices.Reverse(reversed)
+		return nil, reversed
+	}
+	return b.names, nil
+}
+
+// setBarPencil sets the pencil of the sketcher to fill the bars with the
+// color, with a thin border of the background color
+func setBarPencil(sk *svg.Sketcher, theme *svg.Theme, color string) {
+	sk.Pencil.FillColor = color
+	sk.Pencil.LineColor = theme.BackgroundColor
+	if theme.BackgroundColor == svg.Transparent {
+		sk.Pencil.LineColor = "white"
+	}
+	sk.Pencil.LineWidth = 1
+	sk.Pencil.Dash = nil
+}
+
+// setCellPencil sets the pencil of the sketcher to fill the cells of a grid
+// with the color, with a border of the same color so that there is no gap
+// between the adjacent cells
+func setCellPencil(sk *svg.Sketcher, color string) {
+	sk.Pencil.FillColor, sk.Pencil.LineColor = color, color
+	sk.Pencil.LineWidth = 1
+	sk.Pencil.Dash = nil
+}
+
+func (b *barChart) draw(sk *svg.Sketcher, fr frame) {
+	width := b.figure.BarWidth
+	if width <= 0 {
+		width = DefaultBarWidth
+	}
+	n := len(b.series)
+	for k, s := range b.series {
+		setBarPencil(sk, fr.theme, barColor(s.Color, s.colorIndex, fr.theme))
+		for i := range b.names {
+			// interval of the bar on the categorical axis
+			p := b.position(i)
+			lo, hi := p-0.5*width, p+0.5*width
+			if b.figure.BarMode != BarsStacked {
+				slot := k
+				if b.figure.HorizontalBars {
+					// the first series is at the top, as in the legend
+					slot = n - 1 - k
+				}
+				lo, hi = lo+width*float64(slot)/float64(n), lo+width*float64(slot+1)/float64(n)
+			}
+			start, end := b.extents(k, i)
+			if start == end {
+				continue
+			}
+			if b.figure.HorizontalBars {
+				drawBox(sk, fr, start, lo, end, hi)
+			} else {
+				drawBox(sk, fr, lo, start, hi, end)
+			}
+		}
+	}
+}
+
+// drawBox draws the rectangle of the data corners (x1, y1) and (x2, y2),
+// clipped to the plot area
+func drawBox(sk *svg.Sketcher, fr frame, x1, y1, x2, y2 float64) {
+	px1, py1 := fr.point(x1, y1)
+	px2, py2 := fr.point(x2, y2)
+	left, right := math.Max(math.Min(px1, px2), fr.left), math.Min(math.Max(px1, px2), fr.right)
+	bottom, top := math.Max(math.Min(py1, py2), fr.bottom), math.Min(math.Max(py1, py2), fr.top)
+	if left < right && bottom < top {
+		sk.Rectangle(left, bottom, right-left, top-bottom, true)
+	}
+}
+
+func barColor(color string, index int, theme *svg.Theme) string {
+	if color != "" {
+		return color
+	}
+	return theme.PaletteColor(index)
+}
+
+// boxEntry returns the legend entry of a filled box
+func boxEntry(label, color string, index int) legendEntry {
+	return legendEntry{label, func(sk *svg.Sketcher, x, y float64) {
+		theme := sk.Theme()
+		setBarPencil(sk, theme, barColor(color, index, theme))
+		sk.Rectangle(x-8, y-6, 16, 12, true)
+	}}
+}
+
+func (b *barChart) legend() []legendEntry {
+	var entries []legendEntry
+	for _, s := range b.series {
+		if s.Label != "" {
+			entries = append(entries, boxEntry(s.Label, s.Color, s.colorIndex))
+		}
+	}
+	return entries
+}
+
+// --------------------------------------------------------------------
+// Histograms
+
+// BinRule is the rule computing the bins of a histogram
+type BinRule int
+
+const (
+	BinsSturges          BinRule = iota // 1 + log2(n) bins
+	BinsFreedmanDiaconis                // bins of width 2 IQR / n^(1/3)
+	BinsFixedWidth                      // bins of width Binning.Width
+)
+
+// Binning defines the bins of a histogram
+type Binning struct {
+	Rule  BinRule
+	Width float64 // width of the bins (BinsFixedWidth)
+}
+
+const maxBins = 1000
+
+// HistogramBins returns the edges of the bins of the histogram of the values
+// (the non finite values are ignored): the bin i is [edges[i], edges[i+1]). If
+// the width of the bins can not be computed (e.g. the interquartile range of
+// the values is zero), the Sturges rule is used.
+func HistogramBins(values []float64, b Binning) []float64 {
+	var data []float64
+	for _, v := range values {
+		if finite(v) {
+			data = append(data, v)
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	slices.Sort(data)
+	n := len(data)
+	vmin, vmax := data[0], data[n-1]
+	if vmin == vmax {
+		return []float64{vmin - 0.5, vmin + 0.5}
+	}
+
+	width := 0.
+	switch b.Rule {
+	case BinsFreedmanDiaconis:
+		iqr := quantile(data, 0.75) - quantile(data, 0.25)
+		width = 2 * iqr / math.Cbrt(float64(n))
+	case BinsFixedWidth:
+		width = b.Width
+	}
+	if !(width > 0) || (vmax-vmin)/width > maxBins {
+		bins := 1 + math.Ceil(math.Log2(float64(n)))
+		width = (vmax - vmin) / bins
+	}
+	// the bins are aligned on the multiples of the width if the width is
+	// given, on the minimum value otherwise
+	start := vmin
+	if b.Rule == BinsFixedWidth && width == b.Width {
+		start = math.Floor(vmin/width) * width
+	}
+	var edges []float64
+	for i := 0; ; i++ {
+		e := start + float64(i)*width
+		edges = append(edges, e)
+		if e > vmax {
+			break
+		}
+		if i > 0 && math.Abs(e-vmax) < 1e-9*width {
+			// the maximum value is in the last bin
+			edges[i] = vmax
+			break
+		}
+	}
+	return edges
+}
+
+// quantile returns the quantile q of the sorted values (linear interpolation)
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// Histogram is the histogram of a set of values: Counts[i] is the number of
+// values in the bin [Edges[i], Edges[i+1]) (the last bin includes its upper
+// edge)
+type Histogram struct {
+	Edges  []float64
+	Counts []float64
+	Label  string
+	Color  string
+	// if true, the bars show the density (count / (total count * width))
+	// instead of the count, then the area of the histogram is 1
+	Density bool
+
+	colorIndex int
+}
+
+// Histogram adds to the figure the histogram of the values, with the bins
+// computed by the binning rule
+func (f *Figure) Histogram(values []float64, b Binning) *Histogram {
+	edges := HistogramBins(values, b)
+	h := &Histogram{Edges: edges, colorIndex: f.nextColor()}
+	if len(edges) > 1 {
+		h.Counts = make([]float64, len(edges)-1)
+		for _, v := range values {
+			i, found := slices.BinarySearch(edges, v)
+			if !finite(v) || (i == 0 && !found) || i >= len(edges) {
+				continue
+			}
+			if !found || i == len(edges)-1 {
+				// v is in [edges[i-1], edges[i]), or is the maximum
+				i--
+			}
+			h.Counts[i]++
+		}
+	}
+	f.add(h)
+	return h
+}
+
+// heights returns the heights of the bars (counts or densities)
+func (h *Histogram) heights() []float64 {
+	if !h.Density {
+		return h.Counts
+	}
+	total := 0.
+	for _, c := range h.Counts {
+		total += c
+	}
+	heights := make([]float64, len(h.Counts))
+	for i, c := range h.Counts {
+		if w := h.Edges[i+1] - h.Edges[i]; total > 0 && w > 0 {
+			heights[i] = c / (total * w)
+		}
+	}
+	return heights
+}
+
+func (h *Histogram) bounds() (x, y Range) {
+	if len(h.Edges) < 2 {
+		return noRange, noRange
+	}
+	return noRange.Extend(h.Edges...), noRange.Extend(0).Extend(h.heights()...)
+}
+
+func (h *Histogram) zeroBased() (x, y bool) {
+	return false, true
+}
+
+func (h *Histogram) draw(sk *svg.Sketcher, fr frame) {
+	setBarPencil(sk, fr.theme, barColor(h.Color, h.colorIndex, fr.theme))
+	for i, v := range h.heights() {
+		if v > 0 {
+			drawBox(sk, fr, h.Edges[i], 0, h.Edges[i+1], v)
+		}
+	}
+}
+
+func (h *Histogram) legend() []legendEntry {
+	if h.Label == "" {
+		return nil
+	}
+	return []legendEntry{boxEntry(h.Label, h.Color, h.colorIndex)}
+}
@@ -0,0 +1,172 @@
+package plot
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestFigure_Bars(t *testing.T) {
+	fruits := []string{"apples", "pears", "plums", "cherries"}
+	harvests := [][]float64{{12, 7, 9, 4}, {10, 8, -3, 6}}
+	for _, mode := range []struct {
+		name       string
+		mode       BarMode
+		horizontal bool
+		extents    [][][2]float64 // extents of the bars of the series
+	}{
+		{"grouped", BarsGrouped, false, [][][2]float64{
+			{{0, 12}, {0, 7}, {0, 9}, {0, 4}}, {{0, 10}, {0, 8}, {-3, 0}, {0, 6}},
+		}},
+		{"stacked", BarsStacked, false, [][][2]float64{
+			{{0, 12}, {0, 7}, {0, 9}, {0, 4}}, {{12, 22}, {7, 15}, {-3, 0}, {4, 10}},
+		}},
+		{"horizontal", BarsGrouped, true, [][][2]float64{
+			{{0, 12}, {0, 7}, {0, 9}, {0, 4}}, {{0, 10}, {0, 8}, {-3, 0}, {0, 6}},
+		}},
+	} {
+		f := New(DefaultWidth, DefaultHeight)
+		f.Title = "Harvest (" + mode.name + ")"
+		f.BarMode = mode.mode
+		f.HorizontalBars = mode.horizontal
+		f.Grid = true
+		f.Bars(fruits, harvests[0]).Label = "2024"
+		f.Bars(fruits, harvests[1]).Label = "2025"
+		r, fr := render(t, f, "TestFigure_Bars."+mode.name)
+
+		// The category names are the tick labels of the categorical axis
+		texts := r.texts("axes")
+		for _, name := range fruits {
+			if !slices.Contains(texts, name) {
+				t.Errorf("%s: the category %s should be a tick label", mode.name, name)
+			}
+		}
+
+		// The category range is around the categories, and the value range
+		// includes zero and the stacks
+		values, positions := fr.y, fr.x
+		if mode.horizontal {
+			values, positions = fr.x, fr.y
+		}
+		if positions != (Range{-0.5, 3.5}) {
+			t.Errorf("%s: the category range is %v and should be [-0.5, 3.5]", mode.name, positions)
+		}
+		top := 12.
+		if mode.mode == BarsStacked {
+			top = 22
+		}
+		if values.Min > -3 || values.Max < top {
+			t.Errorf("%s: the value range %v should contain [-3, %g]", mode.name, values, top)
+		}
+
+		// The bars are the rectangles of the values, in the slots of their
+		// series (the first category and series at the top with horizontal
+		// bars), or on top of each other
+		bars := r.find("element-0", "polygon")
+		if len(bars) != 8 {
+			t.Errorf("%s: the chart has %d bars and should have 8", mode.name, len(bars))
+			continue
+		}
+		for k, extents := range mode.extents {
+			for i, e := range extents {
+				lo := float64(i) - 0.5*DefaultBarWidth
+				hi := lo + DefaultBarWidth
+				switch {
+				case mode.horizontal:
+					p := float64(len(fruits) - 1 - i)
+					lo = p - 0.5*DefaultBarWidth + 0.5*DefaultBarWidth*float64(1-k)
+					hi = lo + 0.5*DefaultBarWidth
+				case mode.mode == BarsGrouped:
+					lo += 0.5 * DefaultBarWidth * float64(k)
+					hi = lo + 0.5*DefaultBarWidth
+				}
+				left, bottom, right, top := box(bars[4*k+i].points)
+				x1, y1 := fr.data(left, bottom)
+				x2, y2 := fr.data(right, top)
+				want := [4]float64{lo, e[0], hi, e[1]}
+				if mode.horizontal {
+					want = [4]float64{e[0], lo, e[1], hi}
+				}
+				if got := [4]float64{x1, y1, x2, y2}; !equalValues(got[:], want[:]) {
+					t.Errorf("%s: the bar %d of the series %d is %v and should be %v", mode.name, i, k, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestHistogramBins(t *testing.T) {
+	values := []float64{1, 2, 2, 3, 3, 3, 4, 4, 5, math.NaN()}
+	edges := HistogramBins(values, Binning{Rule: BinsFixedWidth, Width: 2})
+	if want := []float64{0, 2, 4, 6}; !equalValues(edges, want) {
+		t.Errorf("the fixed width edges are %v and should be %v", edges, want)
+	}
+	// Sturges: 1 + log2(9) rounded up = 5 bins
+	edges = HistogramBins(values, Binning{Rule: BinsSturges})
+	if len(edges) != 6 || edges[0] != 1 || edges[5] != 5 {
+		t.Errorf("the Sturges edges %v should be 5 bins over [1, 5]", edges)
+	}
+	if edges := HistogramBins([]float64{2, 2}, Binning{}); len(edges) != 2 {
+		t.Errorf("the edges of a single value are %v and should be a single bin", edges)
+	}
+}
+
+func TestFigure_Histogram(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	values := make([]float64, 1000)
+	for i := range values {
+		values[i] = rng.NormFloat64()
+	}
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Normal distribution"
+	f.Legend = LegendTopLeft
+	h := f.Histogram(values, Binning{Rule: BinsFreedmanDiaconis})
+	h.Label = "samples"
+	h.Density = true
+	f.PlotFunc(func(x float64) float64 { return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi) }, -4, 4).Label = "density"
+	r, fr := render(t, f, "TestFigure_Histogram")
+
+	total := 0.
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total != 1000 {
+		t.Errorf("the histogram counts %g values and should count 1000", total)
+	}
+	if fr.y.Min != 0 {
+		t.Errorf("the y range %v should start at zero", fr.y)
+	}
+
+	// The bars stand on zero, between the edges of their bins, and their
+	// areas add up to 1 (density)
+	bars := r.find("element-0", "polygon")
+	if len(bars) == 0 || len(bars) > len(h.Edges)-1 {
+		t.Fatalf("the histogram has %d bars for %d bins", len(bars), len(h.Edges)-1)
+	}
+	sum := 0.
+	for _, bar := range bars {
+		left, bottom, right, top := box(bar.points)
+		x1, y1 := fr.data(left, bottom)
+		x2, y2 := fr.data(right, top)
+		if !near(y1, 0, 1e-9) || !slices.ContainsFunc(h.Edges, func(e float64) bool { return near(e, x1, 1e-9) }) {
+			t.Errorf("the bar [%g,%g]x[%g,%g] should stand on zero at an edge", x1, x2, y1, y2)
+		}
+		sum += (x2 - x1) * (y2 - y1)
+	}
+	if !near(sum, 1, 1e-6) {
+		t.Errorf("the area of the histogram is %g and should be 1", sum)
+	}
+}
+
+func equalValues(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package plot
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Colormaps
+// ===========================================================================
+
+// Colormap maps the values of [0, 1] to colors, by linear interpolation
+// between equally spaced color stops. The colormaps show the values of the
+// elements drawn as colored areas (e.g. heatmaps), with a color bar.
+type Colormap struct {
+	Name  string
+	stops []color.RGBA
+}
+
+// NewColormap returns the colormap going through the colors (at least two
+// colors, whose names are parsed with svg.ParseColor)
+func NewColormap(name string, colors ...string) (*Colormap, error) {
+	if len(colors) < 2 {
+		return nil, fmt.Errorf("colormap %s: %d colors (at least 2 expected)", name, len(colors))
+	}
+	c := &Colormap{Name: name}
+	for _, colorname := range colors {
+		rgba, err := svg.ParseColor(colorname)
+		if err != nil {
+			return nil, fmt.Errorf("colormap %s: %w", name, err)
+		}
+		c.stops = append(c.stops, rgba)
+	}
+	return c, nil
+}
+
+// mustColormap returns the colormap of the colors, or panics if a color is
+// invalid (for the predefined colormaps)
+func mustColormap(name string, colors ...string) *Colormap {
+	c, err := NewColormap(name, colors...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// The predefined colormaps: the sequential colormaps Viridis, Magma and Grays
+// (perceptually uniform, from dark to light), and the diverging colormap
+// CoolWarm (blue to red through light gray, for values around a center).
+var (
+	Viridis = mustColormap("viridis", "#440154", "#482878", "#3e4989", "#31688e",
+		"#26828e", "#1f9e89", "#35b779", "#6ece58", "#b5de2b", "#fde725")
+	Magma = mustColormap("magma", "#000004", "#1c1044", "#4f127b", "#812581",
+		"#b5367a", "#e55964", "#fb8761", "#fec287", "#fcfdbf")
+	Grays    = mustColormap("grays", "#000000", "#ffffff")
+	CoolWarm = mustColormap("coolwarm", "#3b4cc0", "#7396f5", "#b0cbfc", "#dddddd",
+		"#f6bfa6", "#ea7b60", "#b40426")
+)
+
+// At returns the color of the value t (clamped to [0, 1], NaN being 0)
+func (c *Colormap) At(t float64) color.RGBA {
+	if !(t > 0) {
+		return c.stops[0]
+	}
+	if t >= 1 {
+		return c.stops[len(c.stops)-1]
+	}
+	pos := t * float64(len(c.stops)-1)
+	i := int(pos)
+	f := pos - float64(i)
+	a, b := c.stops[i], c.stops[i+1]
+	mix := func(u, v uint8) uint8 {
+		return uint8(math.Round(float64(u) + f*(float64(v)-float64(u))))
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+// Color returns the color of the value t as an hexadecimal color (see At)
+func (c *Colormap) Color(t float64) string {
+	return hexColor(c.At(t))
+}
+
+// Reversed returns the colormap with the colors in the reverse order
+func (c *Colormap) Reversed() *Colormap {
+	r := &Colormap{Name: c.Name + "_r", stops: make([]color.RGBA, len(c.stops))}
+	for i, s := range c.stops {
+		r.stops[len(c.stops)-1-i] = s
+	}
+	return r
+}
+
+// scale returns the position in [0, 1] of the value v in the range r
+func (r Range) scale(v float64) float64 {
+	if r.Max == r.Min {
+		return 0.5
+	}
+	return (v - r.Min) / (r.Max - r.Min)
+}
+
+// hexColor returns the hexadecimal notation (#rrggbb) of the color
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// textColor returns the color of a text written on the color background:
+// black on light colors, white on dark colors
+func textColor(background color.RGBA) string {
+	luminance := 0.299*float64(background.R) + 0.587*float64(background.G) + 0.114*float64(background.B)
+	if luminance > 140 {
+		return "black"
+	}
+	return "white"
+}
+
+// --------------------------------------------------------------------
+// Color bar
+
+const (
+	colorBarWidth  = 16 // width of the color bar (pixels)
+	colorBarGap    = 20 // gap between the plot area and the color bar (pixels)
+	colorBarRoom   = 90 // room on the right of the plot area for the color bar and its labels
+	colorBarStripe = 64 // number of stripes drawing the color gradient
+)
+
+// colorScaled is implemented by the elements whose colors show values with a
+// colormap: the figure draws the color bar of the first one on the right of
+// the plot area. ok is false if the element has no color bar.
+type colorScaled interface {
+	colorBar() (cmap *Colormap, r Range, label string, ok bool)
+}
+
+// colorBar returns the color bar of the figure, if any
+func (f *Figure) colorBar() (cmap *Colormap, r Range, label string, ok bool) {
+	for _, e := range f.elements {
+		if c, isScaled := e.(colorScaled); isScaled {
+			if cmap, r, label, ok = c.colorBar(); ok {
+				return cmap, r, label, true
+			}
+		}
+	}
+	return nil, Range{}, "", false
+}
+
+// drawColorBar draws the color bar of the colormap for the range r on the right
+// of the plot area, with the ticks of the values and the label above the bar
+func (f *Figure) drawColorBar(sk *svg.Sketcher, fr frame, cmap *Colormap, r Range, label string) {
+	sk.BeginGroup("colorbar")
+	defer sk.EndGroup()
+	pencil := sk.Pencil
+	defer func() { sk.Pencil = pencil }()
+
+	x := fr.right + colorBarGap
+	h := (fr.top - fr.bottom) / colorBarStripe
+	sk.Pencil = fr.theme.Pencil.Clone()
+	for i := range colorBarStripe {
+		setCellPencil(sk, cmap.Color((float64(i)+0.5)/colorBarStripe))
+		sk.Rectangle(x, fr.bottom+float64(i)*h, colorBarWidth, h, true)
+	}
+	sk.Pencil = fr.theme.Pencil.Clone()
+	sk.Pencil.LineWidth = 1
+	sk.Rectangle(x, fr.bottom, colorBarWidth, fr.top-fr.bottom, false)
+
+	ticks, step := svg.NiceTicks(r.Min, r.Max, svg.DefaultAxisTicks)
+	fs := float64(svg.DefaultAxisFontSize)
+	for _, v := range ticks {
+		y := fr.bottom + r.scale(v)*(fr.top-fr.bottom)
+		sk.Pencil = fr.theme.Pencil.Clone()
+		sk.Pencil.LineWidth = 1
+		sk.Edge(x+colorBarWidth, y, x+colorBarWidth+4, y)
+		sk.Pencil = fr.theme.TextPencil.Clone()
+		sk.Pencil.FontSize = svg.DefaultAxisFontSize
+		sk.Text(x+colorBarWidth+7, y-0.35*fs, svg.DefaultTickFormatter(v, step))
+	}
+	if label != "" {
+		sk.Pencil = fr.theme.TextPencil.Clone()
+		sk.Pencil.FontSize = svg.DefaultAxisFontSize
+		sk.Text(x, fr.top+6, label)
+	}
+}
@@ -0,0 +1,559 @@
+package plot
+
+import (
+	"fmt"
+	"math"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Contour lines and filled contours
+// ===========================================================================
+
+// The contours of a grid of values Z[i][j] = f(X[j], Y[i]) are computed with
+// the marching squares algorithm: in each cell of the grid, the contour line of
+// a level crosses the edges whose end values are on both sides of the level
+// (at the linear interpolation of the values). In a saddle cell (the level
+// crosses the four edges), the value at the center of the cell decides which
+// edges are joined. The segments of the cells are joined into continuous
+// polylines through their common edges. The filled contours are the cells
+// clipped to the bands between the levels.
+
+const (
+	DefaultContourLevels = 10  // approximate number of automatic levels
+	DefaultContourGrid   = 100 // number of cells along each axis of the grid of a function
+)
+
+// gridPoint is a point of a contour, on an edge of the grid
+type gridPoint = struct{ X, Y float64 }
+
+// gridEdge identifies an edge of the grid: the edge from the node (i, j) to
+// the node (i, j+1) if horizontal, to the node (i+1, j) otherwise
+type gridEdge struct {
+	i, j     int
+	vertical bool
+}
+
+// SampleGrid returns the values of the function on the regular grid of nx x ny
+// cells covering the ranges: z[i][j] = fn(xs[j], ys[i])
+func SampleGrid(fn func(x, y float64) float64, xr, yr Range, nx, ny int) (xs, ys []float64, z [][]float64) {
+	xs, ys = make([]float64, nx+1), make([]float64, ny+1)
+	for j := range xs {
+		xs[j] = xr.Min + (xr.Max-xr.Min)*float64(j)/float64(nx)
+	}
+	for i := range ys {
+		ys[i] = yr.Min + (yr.Max-yr.Min)*float64(i)/float64(ny)
+	}
+	z = make([][]float64, len(ys))
+	for i, y := range ys {
+		z[i] = make([]float64, len(xs))
+		for j, x := range xs {
+			z[i][j] = fn(x, y)
+		}
+	}
+	return xs, ys, z
+}
+
+// ContourLevels returns about n nice levels covering the range of values, the
+// first level being lower than (or equal to) r.Min and the last one greater
+// than (or equal to) r.Max
+func ContourLevels(r Range, n int) []float64 {
+	if r.Empty() {
+		return []float64{r.Min}
+	}
+	_, step := svg.NiceTicks(r.Min, r.Max, n+1)
+	if step == 0 {
+		// the range is too narrow for its values
+		return []float64{r.Min}
+	}
+	start, end := math.Floor(r.Min/step+1e-9), math.Ceil(r.Max/step-1e-9)
+	var levels []float64
+	for k := start; k <= end; k++ {
+		levels = append(levels, roundTo(k*step, step))
+	}
+	return levels
+}
+
+// roundTo returns the value v, a multiple of step, without the rounding errors
+// of the product
+func roundTo(v, step float64) float64 {
+	decimals := max(0, -int(math.Floor(math.Log10(step))))
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
+
+// gridRange returns the range of the finite values of the grid
+func gridRange(z [][]float64) Range {
+	r := noRange
+	for _, row := range z {
+		r = r.Extend(row...)
+	}
+	return r
+}
+
+// ContourLines returns the contour lines of the level of the grid of values
+// z[i][j] = f(xs[j], ys[i]): the closed lines end with their first point. The
+// cells with a non finite value are ignored.
+func ContourLines(xs, ys []float64, z [][]float64, level float64) [][]struct{ X, Y float64 } {
+	points := map[gridEdge]gridPoint{}
+	var segments [][2]gridEdge
+
+	// crossing returns the edge from the node (i1, j1) to the node (i2, j2)
+	// if the level crosses it, and records its crossing point
+	crossing := func(i1, j1, i2, j2 int) (gridEdge, bool) {
+		z1, z2 := z[i1][j1], z[i2][j2]
+		if (z1 >= level) == (z2 >= level) {
+			return gridEdge{}, false
+		}
+		e := gridEdge{i1, j1, j2 == j1}
+		if _, ok := points[e]; !ok {
+			t := (level - z1) / (z2 - z1)
+			points[e] = gridPoint{
+				X: xs[j1] + t*(xs[j2]-xs[j1]),
+				Y: ys[i1] + t*(ys[i2]-ys[i1]),
+			}
+		}
+		return e, true
+	}
+
+	rows, columns := gridSize(xs, ys, z)
+	for i := range rows - 1 {
+		for j := range columns - 1 {
+			a, b, c, d := z[i][j], z[i][j+1], z[i+1][j+1], z[i+1][j]
+			if !finite(a, b, c, d) {
+				continue
+			}
+			// the edges of the cell: bottom, right, top and left
+			var edges [4]gridEdge
+			var crossed [4]bool
+			edges[0], crossed[0] = crossing(i, j, i, j+1)
+			edges[1], crossed[1] = crossing(i, j+1, i+1, j+1)
+			edges[2], crossed[2] = crossing(i+1, j, i+1, j+1)
+			edges[3], crossed[3] = crossing(i, j, i+1, j)
+			var cut []gridEdge
+			for k := range edges {
+				if crossed[k] {
+					cut = append(cut, edges[k])
+				}
+			}
+			switch len(cut) {
+			case 2:
+				segments = append(segments, [2]gridEdge{cut[0], cut[1]})
+			case 4:
+				// saddle: the corners on the side of the center are joined,
+				// the contour cuts off the two other corners
+				center := 0.25 * (a + b + c + d)
+				if (a >= level) == (center >= level) {
+					segments = append(segments, [2]gridEdge{edges[0], edges[1]}, [2]gridEdge{edges[2], edges[3]})
+				} else {
+					segments = append(segments, [2]gridEdge{edges[3], edges[0]}, [2]gridEdge{edges[1], edges[2]})
+				}
+			}
+		}
+	}
+	return joinSegments(segments, points)
+}
+
+// gridSize returns the number of nodes of the grid along y and x
+func gridSize(xs, ys []float64, z [][]float64) (rows, columns int) {
+	rows = min(len(ys), len(z))
+	columns = len(xs)
+	for _, row := range z[:rows] {
+		columns = min(columns, len(row))
+	}
+	return rows, columns
+}
+
+// joinSegments joins the segments into polylines: an edge of the grid is
+// shared by at most two segments (of the two cells of the edge)
+func joinSegments(segments [][2]gridEdge, points map[gridEdge]gridPoint) [][]struct{ X, Y float64 } {
+	adjacent := map[gridEdge][]int{}
+	for k, s := range segments {
+		adjacent[s[0]] = append(adjacent[s[0]], k)
+		adjacent[s[1]] = append(adjacent[s[1]], k)
+	}
+	visited := make([]bool, len(segments))
+	var lines [][]struct{ X, Y float64 }
+	trace := func(e gridEdge, k int) {
+		line := []struct{ X, Y float64 }{points[e]}
+		for {
+			visited[k] = true
+			s := segments[k]
+			if s[0] == e {
+				e = s[1]
+			} else {
+				e = s[0]
+			}
+			line = append(line, points[e])
+			next := -1
+			for _, n := range adjacent[e] {
+				if !visited[n] {
+					next = n
+				}
+			}
+			if next < 0 {
+				break
+			}
+			k = next
+		}
+		lines = append(lines, line)
+	}
+	// the open lines start at the border of the grid (an edge of a single
+	// segment), then the remaining segments make closed lines
+	for k, s := range segments {
+		for _, e := range s {
+			if !visited[k] && len(adjacent[e]) == 1 {
+				trace(e, k)
+			}
+		}
+	}
+	for k, s := range segments {
+		if !visited[k] {
+			trace(s[0], k)
+		}
+	}
+	return lines
+}
+
+// --------------------------------------------------------------------
+// Contour plots
+
+// Contour draws the contours of a grid of values Z[i][j] = f(X[j], Y[i]): the
+// contour lines of the levels, and/or the bands between the levels filled
+// with the colors of the colormap.
+type Contour struct {
+	X, Y          []float64
+	Z             [][]float64
+	Levels        []float64
+	Filled        bool      // if true, the bands between the levels are filled
+	Lines         bool      // if true, the contour lines are drawn
+	Colormap      *Colormap // colors of the levels (Viridis if nil)
+	LineColor     string    // color of the lines (the colors of the levels if empty)
+	LineWidth     int
+	Labels        bool   // if true, the levels are written on the lines
+	Format        string // format of the labels (the format of the axis ticks if empty)
+	ColorBar      bool   // if true, the color bar of the levels is drawn
+	ColorBarLabel string
+}
+
+// Contour adds to the figure the contour lines of the grid of values z[i][j] =
+// f(xs[j], ys[i]), for the levels (DefaultContourLevels automatic levels if
+// nil), colored by the levels, with the color bar
+func (f *Figure) Contour(xs, ys []float64, z [][]float64, levels []float64) *Contour {
+	if levels == nil {
+		levels = ContourLevels(gridRange(z), DefaultContourLevels)
+	}
+	c := &Contour{
+		X: xs, Y: ys, Z: z, Levels: levels,
+		Lines: true, Colormap: Viridis, LineWidth: 1, ColorBar: true,
+	}
+	f.add(c)
+	return c
+}
+
+// FilledContour adds to the figure the filled contours of the grid of values
+// (see Contour): the bands between the levels are filled, without lines
+func (f *Figure) FilledContour(xs, ys []float64, z [][]float64, levels []float64) *Contour {
+	c := f.Contour(xs, ys, z, levels)
+	c.Filled, c.Lines = true, false
+	return c
+}
+
+// ContourFunc adds to the figure the contour lines of the function z = fn(x, y)
+// on the ranges, sampled on a grid of DefaultContourGrid x DefaultContourGrid
+// cells (see Contour)
+func (f *Figure) ContourFunc(fn func(x, y float64) float64, xr, yr Range, levels []float64) *Contour {
+	xs, ys, z := SampleGrid(fn, xr, yr, DefaultContourGrid, DefaultContourGrid)
+	return f.Contour(xs, ys, z, levels)
+}
+
+func (c *Contour) bounds() (x, y Range) {
+	return noRange.Extend(c.X...), noRange.Extend(c.Y...)
+}
+
+func (c *Contour) tight() (x, y bool) {
+	return true, true
+}
+
+// levelRange returns the range of the levels
+func (c *Contour) levelRange() Range {
+	return noRange.Extend(c.Levels...)
+}
+
+func (c *Contour) colorBar() (cmap *Colormap, r Range, label string, ok bool) {
+	return c.colormap(), c.levelRange(), c.ColorBarLabel, c.ColorBar && len(c.Levels) > 1
+}
+
+func (c *Contour) colormap() *Colormap {
+	if c.Colormap == nil {
+		return Viridis
+	}
+	return c.Colormap
+}
+
+func (c *Contour) draw(sk *svg.Sketcher, fr frame) {
+	if len(c.Z) == 0 {
+		return
+	}
+	if c.Filled {
+		c.drawBands(sk, fr)
+	}
+	if !c.Lines {
+		return
+	}
+	r := c.levelRange()
+	sk.Pencil.LineWidth = max(c.LineWidth, 1)
+	sk.Pencil.Dash = nil
+	var labels []contourLabel
+	for _, level := range c.Levels {
+		color := c.LineColor
+		if color == "" {
+			color = c.colormap().Color(r.scale(level))
+		}
+		sk.Pencil.LineColor = color
+		for _, line := range ContourLines(c.X, c.Y, c.Z, level) {
+			if !c.Labels {
+				drawLine(sk, fr, line)
+				continue
+			}
+			text := c.label(level)
+			parts, x, y, ok := cutLabel(fr, line, textWidth(text, svg.DefaultAxisFontSize)+6)
+			if !ok || overlaps(labels, text, x, y) {
+				// the line is drawn without label
+				parts = [][]struct{ X, Y float64 }{line}
+			} else {
+				labels = append(labels, contourLabel{text, color, x, y})
+			}
+			for _, part := range parts {
+				drawLine(sk, fr, part)
+			}
+		}
+	}
+	fs := float64(svg.DefaultAxisFontSize)
+	sk.Pencil = fr.theme.TextPencil.Clone()
+	sk.Pencil.FontSize = svg.DefaultAxisFontSize
+	for _, l := range labels {
+		if c.Filled {
+			sk.Pencil.FontColor = fr.theme.TextPencil.FontColor
+		} else {
+			sk.Pencil.FontColor = l.color
+		}
+		sk.Text(l.x-0.5*textWidth(l.text, fs), l.y-0.35*fs, l.text)
+	}
+}
+
+// label returns the label of the level
+func (c *Contour) label(level float64) string {
+	if c.Format != "" {
+		return fmt.Sprintf(c.Format, level)
+	}
+	step := 0.
+	if len(c.Levels) > 1 {
+		step = math.Abs(c.Levels[1] - c.Levels[0])
+	}
+	return svg.DefaultTickFormatter(level, step)
+}
+
+// contourLabel is a label of a contour line, centered on the user point (x, y)
+type contourLabel struct {
+	text  string
+	color string
+	x, y  float64
+}
+
+// overlaps returns true if the label text centered on (x, y) overlaps one of
+// the labels
+func overlaps(labels []contourLabel, text string, x, y float64) bool {
+	fs := float64(svg.DefaultAxisFontSize)
+	for _, l := range labels {
+		w := 0.5 * (textWidth(text, fs) + textWidth(l.text, fs))
+		if math.Abs(l.x-x) < w+2 && math.Abs(l.y-y) < fs+2 {
+			return true
+		}
+	}
+	return false
+}
+
+// cutLabel cuts the gap of length gap (pixels) at the middle of the line for
+// a label, if the line is long enough. It returns the parts of the line and the
+// center of the gap (user coordinates), ok being false if the line is too short
+// or if the gap is out of the plot area.
+func cutLabel(fr frame, line []struct{ X, Y float64 }, gap float64) (parts [][]struct{ X, Y float64 }, x, y float64, ok bool) {
+	// cumulated lengths (pixels) of the line
+	lengths := make([]float64, len(line))
+	for k := 1; k < len(line); k++ {
+		x1, y1 := fr.point(line[k-1].X, line[k-1].Y)
+		x2, y2 := fr.point(line[k].X, line[k].Y)
+		lengths[k] = lengths[k-1] + math.Hypot(x2-x1, y2-y1)
+	}
+	total := lengths[len(line)-1]
+	if total < 3*gap {
+		return [][]struct{ X, Y float64 }{line}, 0, 0, false
+	}
+	// at returns the point at the length l of the line, and the index of the
+	// next point
+	at := func(l float64) (struct{ X, Y float64 }, int) {
+		k := 1
+		for k < len(line)-1 && lengths[k] < l {
+			k++
+		}
+		t := 0.
+		if d := lengths[k] - lengths[k-1]; d > 0 {
+			t = (l - lengths[k-1]) / d
+		}
+		a, b := line[k-1], line[k]
+		return struct{ X, Y float64 }{a.X + t*(b.X-a.X), a.Y + t*(b.Y-a.Y)}, k
+	}
+	mid := 0.5 * total
+	center, _ := at(mid)
+	x, y = fr.point(center.X, center.Y)
+	if !fr.inside(x, y) {
+		return [][]struct{ X, Y float64 }{line}, 0, 0, false
+	}
+	start, ks := at(mid - 0.5*gap)
+	end, ke := at(mid + 0.5*gap)
+	before := append(append([]struct{ X, Y float64 }{}, line[:ks]...), start)
+	after := append([]struct{ X, Y float64 }{end}, line[ke:]...)
+	return [][]struct{ X, Y float64 }{before, after}, x, y, true
+}
+
+// drawBands fills the bands between the levels: each cell of the grid is
+// clipped to each band. The consecutive cells of a row whose values are all
+// in the same band are drawn as a single rectangle.
+func (c *Contour) drawBands(sk *svg.Sketcher, fr frame) {
+	bands := len(c.Levels) - 1
+	if bands < 1 {
+		return
+	}
+	colors := make([]string, bands)
+	for k := range colors {
+		colors[k] = c.colormap().Color((float64(k) + 0.5) / float64(bands))
+	}
+	// band returns the band of the value (-1 if out of the levels)
+	band := func(v float64) int {
+		for k := range bands {
+			if v >= c.Levels[k] && v <= c.Levels[k+1] {
+				return k
+			}
+		}
+		return -1
+	}
+	fill := func(k int) {
+		sk.Pencil.FillColor, sk.Pencil.LineColor = colors[k], colors[k]
+	}
+	sk.Pencil.LineWidth = 1
+	sk.Pencil.Dash = nil
+
+	rows, columns := gridSize(c.X, c.Y, c.Z)
+	for i := range rows - 1 {
+		run, runBand := -1, -1 // first cell and band of the current run of cells
+		flush := func(j int) {
+			if run >= 0 {
+				fill(runBand)
+				drawBox(sk, fr, c.X[run], c.Y[i], c.X[j], c.Y[i+1])
+			}
+			run, runBand = -1, -1
+		}
+		for j := range columns - 1 {
+			corners := []gridCorner{
+				{c.X[j], c.Y[i], c.Z[i][j]}, {c.X[j+1], c.Y[i], c.Z[i][j+1]},
+				{c.X[j+1], c.Y[i+1], c.Z[i+1][j+1]}, {c.X[j], c.Y[i+1], c.Z[i+1][j]},
+			}
+			if !finite(corners[0].z, corners[1].z, corners[2].z, corners[3].z) {
+				flush(j)
+				continue
+			}
+			k := band(corners[0].z)
+			if k >= 0 && band(corners[1].z) == k && band(corners[2].z) == k && band(corners[3].z) == k {
+				if k != runBand {
+					flush(j)
+					run, runBand = j, k
+				}
+				continue
+			}
+			flush(j)
+			for k := range bands {
+				polygon := clipBand(corners, c.Levels[k], c.Levels[k+1])
+				if len(polygon) < 3 {
+					continue
+				}
+				points := make([]struct{ X, Y float64 }, len(polygon))
+				for n, p := range polygon {
+					points[n].X, points[n].Y = fr.point(p.x, p.y)
+				}
+				if points = fr.clipPolygon(points); len(points) >= 3 {
+					fill(k)
+					sk.Polygon(points, true)
+				}
+			}
+		}
+		flush(columns - 1)
+	}
+}
+
+// gridCorner is a point of a cell of the grid and its value
+type gridCorner struct {
+	x, y, z float64
+}
+
+// clipBand returns the part of the polygon whose values are in [lo, hi], the
+// values being linearly interpolated along the edges (Sutherland-Hodgman)
+func clipBand(polygon []gridCorner, lo, hi float64) []gridCorner {
+	clip := func(polygon []gridCorner, inside func(z float64) bool, level float64) []gridCorner {
+		var out []gridCorner
+		for n, p := range polygon {
+			q := polygon[(n+1)%len(polygon)]
+			if inside(p.z) {
+				out = append(out, p)
+			}
+			if inside(p.z) != inside(q.z) {
+				t := (level - p.z) / (q.z - p.z)
+				out = append(out, gridCorner{p.x + t*(q.x-p.x), p.y + t*(q.y-p.y), level})
+			}
+		}
+		return out
+	}
+	polygon = clip(polygon, func(z float64) bool { return z >= lo }, lo)
+	return clip(polygon, func(z float64) bool { return z <= hi }, hi)
+}
+
+// clipPolygon returns the part of the polygon (user coordinates) in the plot
+// area (Sutherland-Hodgman)
+func (fr frame) clipPolygon(polygon []struct{ X, Y float64 }) []struct{ X, Y float64 } {
+	// the four sides of the plot area: the coordinate (0 for x, 1 for y),
+	// the limit and the side of the inside (1 above the limit, -1 below)
+	sides := []struct {
+		axis  int
+		limit float64
+		sign  float64
+	}{{0, fr.left, 1}, {0, fr.right, -1}, {1, fr.bottom, 1}, {1, fr.top, -1}}
+	for _, side := range sides {
+		coord := func(p struct{ X, Y float64 }) float64 {
+			if side.axis == 0 {
+				return p.X
+			}
+			return p.Y
+		}
+		inside := func(p struct{ X, Y float64 }) bool {
+			return side.sign*(coord(p)-side.limit) >= 0
+		}
+		var out []struct{ X, Y float64 }
+		for n, p := range polygon {
+			q := polygon[(n+1)%len(polygon)]
+			if inside(p) {
+				out = append(out, p)
+			}
+			if inside(p) != inside(q) {
+				t := (side.limit - coord(p)) / (coord(q) - coord(p))
+				out = append(out, struct{ X, Y float64 }{p.X + t*(q.X-p.X), p.Y + t*(q.Y-p.Y)})
+			}
+		}
+		polygon = out
+	}
+	return polygon
+}
+
+func (c *Contour) legend() []legendEntry {
+	return nil
+}
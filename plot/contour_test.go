@@ -0,0 +1,136 @@
+package plot
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestContourLines(t *testing.T) {
+	// The contour of a paraboloid is a single closed circle
+	paraboloid := func(x, y float64) float64 { return x*x + y*y }
+	xs, ys, z := SampleGrid(paraboloid, Range{-2, 2}, Range{-2, 2}, 40, 40)
+	lines := ContourLines(xs, ys, z, 1)
+	if len(lines) != 1 {
+		t.Fatalf("the contour of the level 1 has %d lines and should be a single circle", len(lines))
+	}
+	circle := lines[0]
+	if first, last := circle[0], circle[len(circle)-1]; first != last {
+		t.Errorf("the circle should be closed: %v != %v", first, last)
+	}
+	for _, p := range circle {
+		if r := math.Hypot(p.X, p.Y); math.Abs(r-1) > 0.01 {
+			t.Errorf("the point %v of the circle is at the distance %g from the center", p, r)
+			break
+		}
+	}
+
+	// The contours of a saddle at its level are the two diagonals, crossing at
+	// the center: the lines are open and end on the border of the grid
+	saddle := func(x, y float64) float64 { return x * y }
+	xs, ys, z = SampleGrid(saddle, Range{-1, 1}, Range{-1, 1}, 11, 11)
+	lines = ContourLines(xs, ys, z, 0.1)
+	if len(lines) != 2 {
+		t.Fatalf("the contour of the saddle has %d lines and should have 2", len(lines))
+	}
+	for _, line := range lines {
+		first, last := line[0], line[len(line)-1]
+		if first == last || math.Max(math.Abs(first.X), math.Abs(first.Y)) < 1-1e-9 {
+			t.Errorf("the hyperbola branch %v should be open and start on the border", line)
+		}
+	}
+}
+
+func TestContourLevels(t *testing.T) {
+	levels := ContourLevels(Range{-0.23, 0.97}, 6)
+	want := []float64{-0.4, -0.2, 0, 0.2, 0.4, 0.6, 0.8, 1}
+	if !equalValues(levels, want) {
+		t.Errorf("the levels are %v and should be %v", levels, want)
+	}
+	if levels := ContourLevels(Range{1, math.Nextafter(1, 2)}, 6); !equalValues(levels, []float64{1}) {
+		t.Errorf("the levels of a range of one float step are %v and should be [1]", levels)
+	}
+}
+
+func TestFigure_Contour(t *testing.T) {
+	// the cardinal sine of the isometry demo, with a maximum at the center
+	sinc := func(x, y float64) float64 {
+		r := 2 * math.Pi / 2.5 * math.Hypot(x, y)
+		if r == 0 {
+			return 4
+		}
+		return 4 * math.Sin(r) / r
+	}
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Cardinal sine (filled contours)"
+	xs, ys, z := SampleGrid(sinc, Range{-5, 5}, Range{-5, 5}, 80, 80)
+	c := f.FilledContour(xs, ys, z, nil)
+	c.ColorBarLabel = "z"
+	lines := f.Contour(xs, ys, z, c.Levels)
+	lines.LineColor = "black"
+	lines.Labels = true
+	lines.ColorBar = false
+	r, fr := render(t, f, "TestFigure_Contour")
+
+	// The levels cover the values, and the labels are written on the lines
+	if zr := gridRange(z); c.Levels[0] > zr.Min || c.Levels[len(c.Levels)-1] < zr.Max {
+		t.Errorf("the levels %v should cover the range %v", c.Levels, zr)
+	}
+	if !slices.Contains(r.texts("element-1"), "0.5") || len(r.find("colorbar", "polygon")) == 0 {
+		t.Errorf("the contour plot should have labeled lines and a color bar")
+	}
+
+	// The bands cover the plot area without overlapping
+	covered := 0.
+	for _, band := range r.find("element-0", "polygon") {
+		covered += area(band.points)
+	}
+	if plot := (fr.right - fr.left) * (fr.top - fr.bottom); !near(covered, plot, 1e-6*plot) {
+		t.Errorf("the bands cover %g pixels and should cover the plot area of %g pixels", covered, plot)
+	}
+
+	// The vertices of the lines, but the ends cut by the labels, are at a
+	// level of the values interpolated on the grid
+	polylines := r.find("element-1", "polyline")
+	if len(polylines) == 0 {
+		t.Fatalf("the contour plot should have lines")
+	}
+	for _, line := range polylines {
+		for _, p := range line.points[1 : len(line.points)-1] {
+			v := interpolate(xs, ys, z, p.X, p.Y, fr)
+			if !slices.ContainsFunc(c.Levels, func(level float64) bool { return near(v, level, 1e-6) }) {
+				t.Fatalf("the point (%g,%g) of a line is at the value %g, not at a level", p.X, p.Y, v)
+			}
+		}
+	}
+
+	f = New(DefaultWidth, DefaultHeight)
+	f.Title = "Rosenbrock function"
+	rosenbrock := func(x, y float64) float64 { return math.Log1p((1-x)*(1-x) + 100*(y-x*x)*(y-x*x)) }
+	f.ContourFunc(rosenbrock, Range{-2, 2}, Range{-1, 3}, nil).Labels = true
+	r, fr = render(t, f, "TestFigure_ContourFunc")
+	for _, line := range r.find("element-0", "polyline") {
+		for _, p := range line.points {
+			if !fr.inside(p.X, p.Y) {
+				t.Fatalf("the point (%g,%g) of a line is out of the plot area", p.X, p.Y)
+			}
+		}
+	}
+	if len(r.texts("element-0")) == 0 {
+		t.Errorf("the lines of the Rosenbrock function should be labeled")
+	}
+}
+
+// interpolate returns the value at the user point (px, py) of the grid of
+// values z[i][j] at (xs[j], ys[i]), bilinearly interpolated in its cell
+func interpolate(xs, ys []float64, z [][]float64, px, py float64, fr frame) float64 {
+	x, y := fr.data(px, py)
+	cell := func(values []float64, v float64) (int, float64) {
+		k := min(max(sort.SearchFloat64s(values, v)-1, 0), len(values)-2)
+		return k, (v - values[k]) / (values[k+1] - values[k])
+	}
+	j, u := cell(xs, x)
+	i, v := cell(ys, y)
+	return (1-v)*((1-u)*z[i][j]+u*z[i][j+1]) + v*((1-u)*z[i+1][j]+u*z[i+1][j+1])
+}
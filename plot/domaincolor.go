@@ -0,0 +1,215 @@
+package plot
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/cmplx"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Domain coloring of complex functions
+// ===========================================================================
+
+const (
+	DefaultDomainLineColor = "#404040" // color of the phase and modulus isolines
+	DefaultDomainLineGrid  = 200       // number of cells along each axis of the grid of the isolines
+)
+
+// DomainColor returns the color of the complex value w: the hue is the
+// argument of w (red for the positive reals, cyan for the negative ones) and
+// the lightness increases with the modulus, from black at the zeros to white
+// at the poles. With the shading, the lightness also steps down each time
+// the modulus doubles, which shows the contours of log2|w|. The color of an
+// undefined value (NaN) is transparent.
+func DomainColor(w complex128, shading bool) color.RGBA {
+	if cmplx.IsNaN(w) {
+		return color.RGBA{}
+	}
+	r := cmplx.Abs(w)
+	if math.IsInf(r, 0) {
+		return color.RGBA{255, 255, 255, 255}
+	}
+	hue := cmplx.Phase(w) / (2 * math.Pi)
+	if hue < 0 {
+		hue++
+	}
+	lightness := 2 / math.Pi * math.Atan(r)
+	if shading && r > 0 {
+		frac := math.Log2(r) - math.Floor(math.Log2(r))
+		lightness *= 0.75 + 0.25*frac
+	}
+	return hslColor(hue, 1, lightness)
+}
+
+// hslColor returns the opaque color of the hue, saturation and lightness,
+// all in [0, 1]
+func hslColor(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	h6 := 6 * h
+	x := c * (1 - math.Abs(math.Mod(h6, 2)-1))
+	var r, g, b float64
+	switch {
+	case h6 < 1:
+		r, g, b = c, x, 0
+	case h6 < 2:
+		r, g, b = x, c, 0
+	case h6 < 3:
+		r, g, b = 0, c, x
+	case h6 < 4:
+		r, g, b = 0, x, c
+	case h6 < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	m := l - 0.5*c
+	channel := func(v float64) uint8 {
+		return uint8(math.Round(255 * math.Max(0, math.Min(1, v+m))))
+	}
+	return color.RGBA{channel(r), channel(g), channel(b), 255}
+}
+
+// DomainColoring draws a complex function on a domain of the complex plane,
+// each point z = x + iy being painted with the DomainColor of f(z). The
+// domain is rendered as an embedded raster image with one sample per pixel
+// of the plot area, or as a grid of colored rectangles if Cells is positive.
+type DomainColoring struct {
+	F            func(z complex128) complex128
+	XR, YR       Range // ranges of the real and imaginary parts of z
+	Cells        int   // if positive, number of rectangles along x (the domain is an image if 0)
+	Shading      bool  // if true, the contours of log2|f| are shaded
+	PhaseLines   int   // number of isolines of the argument of f, at multiples of 2π/PhaseLines
+	ModulusLines bool  // if true, the isolines of |f| at the powers of 2 are drawn
+	LineColor    string
+	LineWidth    int
+}
+
+// DomainColoring adds to the figure the domain coloring of the function on
+// the ranges of the real and imaginary parts, rendered as an image, shaded,
+// without isolines
+func (f *Figure) DomainColoring(fn func(z complex128) complex128, re, im Range) *DomainColoring {
+	d := &DomainColoring{
+		F: fn, XR: re, YR: im, Shading: true,
+		LineColor: DefaultDomainLineColor, LineWidth: 1,
+	}
+	f.add(d)
+	return d
+}
+
+func (d *DomainColoring) bounds() (x, y Range) {
+	return d.XR, d.YR
+}
+
+func (d *DomainColoring) tight() (x, y bool) {
+	return true, true
+}
+
+// color returns the color of the value of the function at the data point
+// (x, y)
+func (d *DomainColoring) color(x, y float64) color.RGBA {
+	return DomainColor(d.F(complex(x, y)), d.Shading)
+}
+
+func (d *DomainColoring) draw(sk *svg.Sketcher, fr frame) {
+	// the rectangle of the domain in the plot area (user coordinates)
+	px1, py1 := fr.point(d.XR.Min, d.YR.Min)
+	px2, py2 := fr.point(d.XR.Max, d.YR.Max)
+	left, right := math.Max(math.Min(px1, px2), fr.left), math.Min(math.Max(px1, px2), fr.right)
+	bottom, top := math.Max(math.Min(py1, py2), fr.bottom), math.Min(math.Max(py1, py2), fr.top)
+	if left >= right || bottom >= top {
+		return
+	}
+	if d.Cells > 0 {
+		d.drawCells(sk, fr, left, bottom, right, top)
+	} else {
+		d.drawImage(sk, fr, left, bottom, right, top)
+	}
+	d.drawLines(sk, fr)
+}
+
+// drawImage draws the domain as an image of one pixel per user unit, which
+// fills the rectangle of the user coordinates
+func (d *DomainColoring) drawImage(sk *svg.Sketcher, fr frame, left, bottom, right, top float64) {
+	w, h := int(math.Ceil(right-left)), int(math.Ceil(top-bottom))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range h {
+		// the rows of the image go down from the top
+		py := top - (float64(i)+0.5)*(top-bottom)/float64(h)
+		for j := range w {
+			px := left + (float64(j)+0.5)*(right-left)/float64(w)
+			img.SetRGBA(j, i, d.color(fr.data(px, py)))
+		}
+	}
+	style := sk.ImageStyle
+	sk.ImageStyle.AspectRatio = svg.ImageAspectRatioNone
+	sk.Image(left, bottom, right-left, top-bottom, img)
+	sk.ImageStyle = style
+}
+
+// drawCells draws the domain as Cells rectangles along x, and as many along
+// y as needed for the rectangles to be square in the plot area
+func (d *DomainColoring) drawCells(sk *svg.Sketcher, fr frame, left, bottom, right, top float64) {
+	nx := d.Cells
+	ny := max(1, int(math.Round(float64(nx)*(top-bottom)/(right-left))))
+	x1, y1 := fr.data(left, bottom)
+	x2, y2 := fr.data(right, top)
+	dx, dy := (x2-x1)/float64(nx), (y2-y1)/float64(ny)
+	for i := range ny {
+		y := y1 + float64(i)*dy
+		for j := range nx {
+			x := x1 + float64(j)*dx
+			c := d.color(x+0.5*dx, y+0.5*dy)
+			if c.A == 0 {
+				continue
+			}
+			setCellPencil(sk, hexColor(c))
+			drawBox(sk, fr, x, y, x+dx, y+dy)
+		}
+	}
+}
+
+// drawLines draws the phase and modulus isolines, the contour lines of
+// log2|f| at the integer levels and the half-lines arg(f) = 2kπ/PhaseLines
+func (d *DomainColoring) drawLines(sk *svg.Sketcher, fr frame) {
+	if d.PhaseLines <= 0 && !d.ModulusLines {
+		return
+	}
+	sk.Pencil.LineColor = d.LineColor
+	sk.Pencil.LineWidth = max(d.LineWidth, 1)
+	sk.Pencil.Dash = nil
+	n := DefaultDomainLineGrid
+	if d.ModulusLines {
+		xs, ys, z := SampleGrid(func(x, y float64) float64 {
+			return math.Log2(cmplx.Abs(d.F(complex(x, y))))
+		}, d.XR, d.YR, n, n)
+		r := gridRange(z)
+		for level := math.Ceil(r.Min); level <= r.Max; level++ {
+			for _, line := range ContourLines(xs, ys, z, level) {
+				drawLine(sk, fr, line)
+			}
+		}
+	}
+	for k := range d.PhaseLines {
+		// arg(f) = theta where Im(f.exp(-i.theta)) = 0 on the side where
+		// Re(f.exp(-i.theta)) > 0, the other side being the half-line
+		// arg(f) = theta + π
+		rotation := cmplx.Rect(1, -2*math.Pi*float64(k)/float64(d.PhaseLines))
+		xs, ys, z := SampleGrid(func(x, y float64) float64 {
+			w := d.F(complex(x, y)) * rotation
+			if real(w) < 0 {
+				return math.NaN()
+			}
+			return imag(w)
+		}, d.XR, d.YR, n, n)
+		for _, line := range ContourLines(xs, ys, z, 0) {
+			drawLine(sk, fr, line)
+		}
+	}
+}
+
+func (d *DomainColoring) legend() []legendEntry {
+	return nil
+}
@@ -0,0 +1,114 @@
+package plot
+
+import (
+	"image/color"
+	"math"
+	"math/cmplx"
+	"slices"
+	"testing"
+)
+
+func TestDomainColor(t *testing.T) {
+	for _, c := range []struct {
+		w    complex128
+		want color.RGBA
+	}{
+		{0, color.RGBA{0, 0, 0, 255}},                    // the zeros are black
+		{cmplx.Inf(), color.RGBA{255, 255, 255, 255}},    // the poles are white
+		{1, color.RGBA{255, 0, 0, 255}},                  // positive reals are red
+		{-1, color.RGBA{0, 255, 255, 255}},               // negative reals are cyan
+		{1i, color.RGBA{128, 255, 0, 255}},               // quarter turn
+		{cmplx.NaN(), color.RGBA{}},                      // undefined: transparent
+		{complex(math.NaN(), 0), color.RGBA{0, 0, 0, 0}}, // same
+	} {
+		if got := DomainColor(c.w, false); got != c.want {
+			t.Errorf("the color of %v is %v and should be %v", c.w, got, c.want)
+		}
+	}
+	// With the shading, the lightness drops when the modulus doubles
+	below, above := DomainColor(1.99, true), DomainColor(2.01, true)
+	if int(above.R)+int(above.G)+int(above.B) >= int(below.R)+int(below.G)+int(below.B) {
+		t.Errorf("the shaded color of 2.01 %v should be darker than the one of 1.99 %v", above, below)
+	}
+}
+
+func TestFigure_DomainColoring(t *testing.T) {
+	// zeros at ±1 and 2+i (double), poles at the square roots of -2-2i
+	fn := func(z complex128) complex128 {
+		return (z*z - 1) * (z - 2 - 1i) * (z - 2 - 1i) / (z*z + 2 + 2i)
+	}
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Domain coloring"
+	f.XLabel, f.YLabel = "Re", "Im"
+	d := f.DomainColoring(fn, Range{-3, 3}, Range{-2, 2})
+	d.PhaseLines = 6
+	d.ModulusLines = true
+	r, fr := render(t, f, "TestFigure_DomainColoring")
+
+	// The domain is a single image filling the plot area, without margin
+	if fr.x != (Range{-3, 3}) || fr.y != (Range{-2, 2}) {
+		t.Errorf("the ranges are %v x %v and should be the domain", fr.x, fr.y)
+	}
+	images := r.find("element-0", "image")
+	if len(images) != 1 {
+		t.Fatalf("the domain has %d images and should be a single image", len(images))
+	}
+	if left, bottom, right, top := box(images[0].points); left != fr.left || bottom != fr.bottom || right != fr.right || top != fr.top {
+		t.Errorf("the image [%g,%g]x[%g,%g] should be the plot area", left, right, bottom, top)
+	}
+
+	// Away from the zeros and the poles, the vertices of the isolines are
+	// where log2|f| is an integer or arg(f) a multiple of 2π/6
+	pole := cmplx.Sqrt(-2 - 2i)
+	singular := []complex128{1, -1, 2 + 1i, pole, -pole}
+	lines := r.find("element-0", "polyline")
+	if len(lines) == 0 || lines[0].pencil.LineColor != DefaultDomainLineColor {
+		t.Fatalf("the figure should have the isolines")
+	}
+	for _, line := range lines {
+		for _, p := range line.points[1 : len(line.points)-1] {
+			z := complex(fr.data(p.X, p.Y))
+			if slices.ContainsFunc(singular, func(s complex128) bool { return cmplx.Abs(z-s) < 0.2 }) {
+				continue
+			}
+			w := fn(z)
+			modulus := math.Log2(cmplx.Abs(w))
+			phase := cmplx.Phase(w) / (math.Pi / 3)
+			if math.Abs(modulus-math.Round(modulus)) > 0.01 && math.Abs(phase-math.Round(phase)) > 0.01 {
+				t.Fatalf("the point %v of an isoline is at f = %v, not on an isoline", z, w)
+			}
+		}
+	}
+
+	// The same domain as rows of 60 square rectangles, from the bottom, of
+	// the color of the function at their centers
+	f = New(DefaultWidth, DefaultHeight)
+	f.Title = "Domain coloring of 1/z (cells)"
+	inverse := func(z complex128) complex128 { return 1 / z }
+	d = f.DomainColoring(inverse, Range{-3, 3}, Range{-2, 2})
+	d.Cells = 60
+	r, fr = render(t, f, "TestFigure_DomainColoring_Cells")
+	if len(r.find("element-0", "image")) != 0 {
+		t.Errorf("the domain should be drawn with rectangles")
+	}
+	cells := r.find("element-0", "polygon")
+	rows := int(math.Round(60 * (fr.top - fr.bottom) / (fr.right - fr.left)))
+	if len(cells) != 60*rows {
+		t.Fatalf("the domain has %d rectangles and should have %d rows of 60", len(cells), rows)
+	}
+	w, h := (fr.right-fr.left)/60, (fr.top-fr.bottom)/float64(rows)
+	if math.Abs(w-h) > 0.05*w {
+		t.Errorf("the cells of %gx%g pixels should be square", w, h)
+	}
+	for n, cell := range cells {
+		left, bottom, right, top := box(cell.points)
+		i, j := n/60, n%60
+		if !near(left, fr.left+float64(j)*w, 1e-6) || !near(bottom, fr.bottom+float64(i)*h, 1e-6) || !near(right-left, w, 1e-6) || !near(top-bottom, h, 1e-6) {
+			t.Fatalf("the cell (%d,%d) is misplaced", i, j)
+		}
+		z := complex(fr.data(0.5*(left+right), 0.5*(bottom+top)))
+		if c, want := cell.pencil.FillColor, hexColor(DomainColor(inverse(z), true)); c != want {
+			t.Fatalf("the cell (%d,%d) is %s and should be %s", i, j, c, want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package plot
+
+import (
+	"math"
+	"slices"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Curves of functions
+// ===========================================================================
+
+// The curves of functions are sampled when the figure is drawn, with the
+// resolution of the plot area (see svg.SampleCurve): the curve is smooth
+// whatever the size of the figure, and broken at the discontinuities.
+
+// boundsSamples is the number of samples used to compute the range of a curve
+const boundsSamples = 512
+
+// curve is the parametric curve (fx(t), fy(t)) for t in [tmin, tmax]
+type curve struct {
+	fx, fy     func(t float64) float64
+	tmin, tmax float64
+}
+
+// bounds returns the ranges of the curve, computed from regular samples. The
+// values around the poles (e.g. of tan) are not taken into account: if the
+// range of the values is much larger than the range of most of the values,
+// the range is the range of the values without the 2% lowest and highest.
+func (c *curve) bounds() (x, y Range) {
+	var xs, ys []float64
+	for i := range boundsSamples + 1 {
+		t := c.tmin + (c.tmax-c.tmin)*float64(i)/boundsSamples
+		if px, py := c.fx(t), c.fy(t); finite(px, py) {
+			xs = append(xs, px)
+			ys = append(ys, py)
+		}
+	}
+	return robustRange(xs), robustRange(ys)
+}
+
+func robustRange(values []float64) Range {
+	if len(values) == 0 {
+		return noRange
+	}
+	slices.Sort(values)
+	n := len(values)
+	full := Range{values[0], values[n-1]}
+	core := Range{values[n/50], values[n-1-n/50]}
+	if full.Max-full.Min > 5*(core.Max-core.Min) {
+		return core
+	}
+	return full
+}
+
+// sample returns the polylines of the curve with the resolution of the frame
+func (c *curve) sample(fr frame) [][]struct{ X, Y float64 } {
+	xscale := (fr.right - fr.left) / (fr.x.Max - fr.x.Min)
+	yscale := (fr.top - fr.bottom) / (fr.y.Max - fr.y.Min)
+	return svg.SampleCurve(c.fx, c.fy, c.tmin, c.tmax, xscale, yscale)
+}
+
+// newCurve adds to the figure a series drawing the curve
+func (f *Figure) newCurve(c *curve) *Series {
+	s := newSeries(f, nil, nil)
+	s.curve = c
+	f.add(s)
+	return s
+}
+
+// PlotFunc adds to the figure the curve of the function y = fn(x) for x in
+// [xmin, xmax]. The curve is broken where the function is not defined or
+// discontinuous (e.g. the poles of tan).
+func (f *Figure) PlotFunc(fn func(x float64) float64, xmin, xmax float64) *Series {
+	identity := func(t float64) float64 { return t }
+	return f.newCurve(&curve{identity, fn, xmin, xmax})
+}
+
+// PlotParametric adds to the figure the parametric curve (fx(t), fy(t)) for
+// t in [tmin, tmax]
+func (f *Figure) PlotParametric(fx, fy func(t float64) float64, tmin, tmax float64) *Series {
+	return f.newCurve(&curve{fx, fy, tmin, tmax})
+}
+
+// PlotPolar adds to the figure the polar curve r = fn(theta) for theta in
+// [thetamin, thetamax] (radians). The default range, if thetamin equals
+// thetamax, is [0, 2π] (as for Sketcher.PlotPolar).
+func (f *Figure) PlotPolar(fn func(theta float64) float64, thetamin, thetamax float64) *Series {
+	if thetamin == thetamax {
+		thetamin, thetamax = 0, 2*math.Pi
+	}
+	fx, fy := svg.PolarFuncs(fn)
+	return f.newCurve(&curve{fx, fy, thetamin, thetamax})
+}
@@ -0,0 +1,109 @@
+package plot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFigure_PlotFunc(t *testing.T) {
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Functions"
+	f.Grid = true
+	tan := f.PlotFunc(math.Tan, -5, 5)
+	tan.Label = "tan(x)"
+	inverse := f.PlotFunc(func(x float64) float64 { return 1 / x }, -5, 5)
+	inverse.Label = "1/x"
+	inverse.LineStyle = LineDashed
+	r, fr := render(t, f, "TestFigure_PlotFunc")
+
+	// The automatic range ignores the values close to the poles
+	if fr.y.Max-fr.y.Min > 100 {
+		t.Errorf("the y range %v should ignore the poles", fr.y)
+	}
+
+	// The curves are broken at the poles (4 for tan, 1 for 1/x) and clipped
+	// to the plot area. The vertices, but the ends cut by the plot area,
+	// are on the curves.
+	for _, c := range []struct {
+		group    string
+		fn       func(x float64) float64
+		branches int
+	}{
+		{"element-0", math.Tan, 5},
+		{"element-1", func(x float64) float64 { return 1 / x }, 2},
+	} {
+		lines := r.find(c.group, "polyline")
+		if len(lines) != c.branches {
+			t.Errorf("the curve %s has %d polylines and should have %d", c.group, len(lines), c.branches)
+		}
+		for _, line := range lines {
+			for i, p := range line.points {
+				if !fr.inside(p.X, p.Y) {
+					t.Fatalf("the point (%g,%g) of the curve %s is out of the plot area", p.X, p.Y, c.group)
+				}
+				x, y := fr.data(p.X, p.Y)
+				if i > 0 && i < len(line.points)-1 && !near(y, c.fn(x), 1e-6*(1+math.Abs(y))) {
+					t.Fatalf("the point (%g,%g) of the curve %s is not on the curve", x, y, c.group)
+				}
+			}
+		}
+	}
+	// The dashed curve is drawn with polylines (the dash pattern is not
+	// restarted at each point)
+	if lines := r.find("element-1", "polyline"); len(lines) == 0 || len(lines[0].pencil.Dash) == 0 {
+		t.Errorf("the curve 1/x should be drawn with dashed polylines")
+	}
+}
+
+func TestFigure_PlotPolar(t *testing.T) {
+	f := New(480, 480)
+	f.Title = "Polar curves"
+	rose := f.PlotPolar(func(theta float64) float64 { return math.Cos(4 * theta) }, 0, 0)
+	rose.Label = "r = cos(4t)"
+	spiral := f.PlotPolar(func(theta float64) float64 { return theta / (4 * math.Pi) }, 0, 4*math.Pi)
+	spiral.Label = "r = t/4pi"
+	lissajous := f.PlotParametric(math.Sin, func(t float64) float64 { return math.Sin(3 * t) }, 0, 2*math.Pi)
+	lissajous.Label = "lissajous"
+	lissajous.LineStyle = LineDotted
+	f.Legend = LegendBottomRight
+	r, fr := render(t, f, "TestFigure_PlotPolar")
+
+	if fr.x.Min > -1 || fr.x.Max < 1 || fr.y.Min > -1 || fr.y.Max < 1 {
+		t.Errorf("the ranges %v x %v should contain the rose", fr.x, fr.y)
+	}
+
+	// The rose is a single closed line (the default range is a full turn),
+	// whose points, but the origin, are at the distance |cos(4t)| of the
+	// origin, t being the angle of the point
+	lines := r.find("element-0", "polyline")
+	if len(lines) != 1 {
+		t.Fatalf("the rose has %d polylines and should have 1", len(lines))
+	}
+	points := lines[0].points
+	if first, last := points[0], points[len(points)-1]; !near(first.X, last.X, 1e-6) || !near(first.Y, last.Y, 1e-6) {
+		t.Errorf("the rose should be closed: %v != %v", first, last)
+	}
+	for _, p := range points {
+		x, y := fr.data(p.X, p.Y)
+		if d := math.Hypot(x, y); d > 1e-3 && !near(d, math.Abs(math.Cos(4*math.Atan2(y, x))), 1e-6) {
+			t.Fatalf("the point (%g,%g) of the rose is not on the curve", x, y)
+		}
+	}
+
+	// The spiral goes away from the origin, up to the distance 1
+	lines = r.find("element-1", "polyline")
+	if len(lines) != 1 {
+		t.Fatalf("the spiral has %d polylines and should have 1", len(lines))
+	}
+	previous := 0.
+	for _, p := range lines[0].points {
+		d := math.Hypot(fr.data(p.X, p.Y))
+		if d < previous-1e-9 {
+			t.Fatalf("the spiral should go away from the origin (%g < %g)", d, previous)
+		}
+		previous = d
+	}
+	if !near(previous, 1, 1e-6) {
+		t.Errorf("the spiral ends at the distance %g and should end at 1", previous)
+	}
+}
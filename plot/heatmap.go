@@ -0,0 +1,193 @@
+package plot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Heatmaps and matrix plots
+// ===========================================================================
+
+// DefaultHeatmapFormat is the format of the values written on the cells of a
+// heatmap (see Heatmap.Annotate)
+const DefaultHeatmapFormat = "%.2g"
+
+// Heatmap draws a matrix of values as a grid of cells colored by a colormap.
+// The cell of the row i and the column j, of value Z[i][j], is the rectangle
+// [XEdges[j], XEdges[j+1]] x [YEdges[i], YEdges[i+1]]. The cells whose value is
+// not finite are drawn with the color NaNColor (not drawn if empty).
+type Heatmap struct {
+	Z              [][]float64
+	XEdges, YEdges []float64
+	Colormap       *Colormap
+	ZRange         Range  // range of the values of the colormap (the range of the finite values if empty)
+	NaNColor       string // color of the cells whose value is not finite (not drawn if empty)
+	Annotate       bool   // if true, the values are written on the cells
+	Format         string // format of the annotations (DefaultHeatmapFormat if empty)
+	ColorBar       bool   // if true, the color bar of the values is drawn
+	ColorBarLabel  string
+
+	rows, columns []string // names of the rows and columns of a matrix plot
+}
+
+// Heatmap adds to the figure the heatmap of the matrix, matrix[i][j] being the
+// value of the cell of the row i and the column j. xedges and yedges are the
+// edges of the columns and the rows (if nil, the column j is [j, j+1] and the
+// row i is [i, i+1]). The colormap is Viridis if nil. The color bar is drawn.
+func (f *Figure) Heatmap(matrix [][]float64, xedges, yedges []float64, colormap *Colormap) *Heatmap {
+	columns := 0
+	for _, row := range matrix {
+		columns = max(columns, len(row))
+	}
+	if xedges == nil {
+		xedges = indexEdges(columns)
+	}
+	if yedges == nil {
+		yedges = indexEdges(len(matrix))
+	}
+	if colormap == nil {
+		colormap = Viridis
+	}
+	h := &Heatmap{Z: matrix, XEdges: xedges, YEdges: yedges, Colormap: colormap, ColorBar: true}
+	f.add(h)
+	return h
+}
+
+// MatrixPlot adds to the figure the matrix plot of the matrix: a heatmap on
+// categorical axes, whose rows go from the top to the bottom as the rows of a
+// matrix, with the values written on the cells. The rows and columns are named
+// with the labels (with their indices if nil).
+func (f *Figure) MatrixPlot(matrix [][]float64, rowLabels, columnLabels []string, colormap *Colormap) *Heatmap {
+	h := f.Heatmap(matrix, nil, nil, colormap)
+	rows, columns := len(h.YEdges)-1, len(h.XEdges)-1
+	h.rows, h.columns = indexLabels(rowLabels, rows), indexLabels(columnLabels, columns)
+	// the row i is at the position rows-1-i of the categorical axis
+	for i := range h.YEdges {
+		h.YEdges[i] = float64(rows-i) - 0.5
+	}
+	for j := range h.XEdges {
+		h.XEdges[j] -= 0.5
+	}
+	h.Annotate = true
+	return h
+}
+
+// indexEdges returns the edges 0, 1, ..., n
+func indexEdges(n int) []float64 {
+	edges := make([]float64, n+1)
+	for i := range edges {
+		edges[i] = float64(i)
+	}
+	return edges
+}
+
+// indexLabels returns the labels of the n rows (or columns), completed with
+// the indices
+func indexLabels(labels []string, n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		if i < len(labels) {
+			names[i] = labels[i]
+		} else {
+			names[i] = strconv.Itoa(i)
+		}
+	}
+	return names
+}
+
+// cells returns the number of rows and columns of the heatmap
+func (h *Heatmap) cells() (rows, columns int) {
+	return min(len(h.Z), len(h.YEdges)-1), len(h.XEdges) - 1
+}
+
+// zRange returns the range of the colormap
+func (h *Heatmap) zRange() Range {
+	if !h.ZRange.Empty() {
+		return h.ZRange
+	}
+	r := noRange
+	for _, row := range h.Z {
+		r = r.Extend(row...)
+	}
+	if r.Min > r.Max {
+		return Range{0, 1}
+	}
+	return r
+}
+
+func (h *Heatmap) bounds() (x, y Range) {
+	if len(h.XEdges) < 2 || len(h.YEdges) < 2 {
+		return noRange, noRange
+	}
+	return noRange.Extend(h.XEdges...), noRange.Extend(h.YEdges...)
+}
+
+func (h *Heatmap) tight() (x, y bool) {
+	return true, true
+}
+
+func (h *Heatmap) categories() (x, y []string) {
+	if h.rows == nil {
+		return nil, nil
+	}
+	reversed := slices.Clone(h.rows)
+	slices.Reverse(reversed)
+	return h.columns, reversed
+}
+
+func (h *Heatmap) colorBar() (cmap *Colormap, r Range, label string, ok bool) {
+	return h.Colormap, h.zRange(), h.ColorBarLabel, h.ColorBar
+}
+
+func (h *Heatmap) draw(sk *svg.Sketcher, fr frame) {
+	r := h.zRange()
+	format := h.Format
+	if format == "" {
+		format = DefaultHeatmapFormat
+	}
+	rows, columns := h.cells()
+	for i := range rows {
+		for j := range min(columns, len(h.Z[i])) {
+			v := h.Z[i][j]
+			color := h.NaNColor
+			if finite(v) {
+				color = h.Colormap.Color(r.scale(v))
+			}
+			if color == "" {
+				continue
+			}
+			setCellPencil(sk, color)
+			drawBox(sk, fr, h.XEdges[j], h.YEdges[i], h.XEdges[j+1], h.YEdges[i+1])
+		}
+	}
+	if !h.Annotate {
+		return
+	}
+
+	sk.Pencil = fr.theme.TextPencil.Clone()
+	sk.Pencil.FontSize = svg.DefaultAxisFontSize
+	fs := float64(svg.DefaultAxisFontSize)
+	for i := range rows {
+		for j := range min(columns, len(h.Z[i])) {
+			v := h.Z[i][j]
+			if !finite(v) {
+				continue
+			}
+			px, py := fr.point(0.5*(h.XEdges[j]+h.XEdges[j+1]), 0.5*(h.YEdges[i]+h.YEdges[i+1]))
+			if !fr.inside(px, py) {
+				continue
+			}
+			text := fmt.Sprintf(format, v)
+			sk.Pencil.FontColor = textColor(h.Colormap.At(r.scale(v)))
+			sk.Text(px-0.5*textWidth(text, fs), py-0.35*fs, text)
+		}
+	}
+}
+
+func (h *Heatmap) legend() []legendEntry {
+	return nil
+}
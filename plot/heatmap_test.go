@@ -0,0 +1,150 @@
+package plot
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+func TestColormap_At(t *testing.T) {
+	if c := Grays.Color(0.5); c != "#808080" {
+		t.Errorf("the middle of the grays colormap is %s and should be #808080", c)
+	}
+	if c := Viridis.Color(math.NaN()); c != "#440154" {
+		t.Errorf("the color of NaN is %s and should be the first color #440154", c)
+	}
+	if c := Viridis.Reversed().Color(2); c != "#440154" {
+		t.Errorf("the last color of the reversed colormap is %s and should be #440154", c)
+	}
+	if _, err := NewColormap("bad", "red", "nocolor"); err == nil {
+		t.Errorf("a colormap with an unknown color should be an error")
+	}
+}
+
+func TestFigure_Heatmap(t *testing.T) {
+	// the cardinal sine on a grid of 40x30 cells, undefined on a disk
+	const nx, ny = 40, 30
+	xedges, yedges := make([]float64, nx+1), make([]float64, ny+1)
+	for j := range xedges {
+		xedges[j] = -10 + 20*float64(j)/nx
+	}
+	for i := range yedges {
+		yedges[i] = -7.5 + 15*float64(i)/ny
+	}
+	z := make([][]float64, ny)
+	for i := range z {
+		z[i] = make([]float64, nx)
+		for j := range z[i] {
+			x, y := 0.5*(xedges[j]+xedges[j+1]), 0.5*(yedges[i]+yedges[i+1])
+			r := math.Hypot(x, y)
+			z[i][j] = math.Sin(r) / r
+			if math.Hypot(x-6, y-3) < 1.5 {
+				z[i][j] = math.NaN()
+			}
+		}
+	}
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Cardinal sine"
+	f.XLabel, f.YLabel = "x", "y"
+	h := f.Heatmap(z, xedges, yedges, Magma)
+	h.ColorBarLabel = "sin(r)/r"
+	r, fr := render(t, f, "TestFigure_Heatmap")
+
+	// The ranges are the edges of the cells, without margin
+	if fr.x != (Range{-10, 10}) || fr.y != (Range{-7.5, 7.5}) {
+		t.Errorf("the ranges are %v x %v and should be the edges of the cells", fr.x, fr.y)
+	}
+
+	// The cells are the rectangles of the edges, colored by their values,
+	// row by row. The undefined cells are not drawn.
+	cells := r.find("element-0", "polygon")
+	zr := h.zRange()
+	n := 0
+	for i, row := range z {
+		for j, v := range row {
+			if math.IsNaN(v) {
+				continue
+			}
+			if n >= len(cells) {
+				t.Fatalf("the heatmap has %d cells and should have more", len(cells))
+			}
+			left, bottom, right, top := box(cells[n].points)
+			x1, y1 := fr.data(left, bottom)
+			x2, y2 := fr.data(right, top)
+			if got, want := []float64{x1, y1, x2, y2}, []float64{xedges[j], yedges[i], xedges[j+1], yedges[i+1]}; !equalValues(got, want) {
+				t.Fatalf("the cell (%d,%d) is %v and should be %v", i, j, got, want)
+			}
+			if c, want := cells[n].pencil.FillColor, Magma.Color(zr.scale(v)); c != want {
+				t.Fatalf("the cell (%d,%d) is %s and should be %s", i, j, c, want)
+			}
+			n++
+		}
+	}
+	if n != len(cells) || n == nx*ny {
+		t.Errorf("the heatmap has %d cells and should have the %d defined cells", len(cells), n)
+	}
+
+	// The color bar is on the right of the plot area, from the bottom to the
+	// top, with stripes of the colormap from the lowest value
+	stripes := r.find("colorbar", "polygon")
+	if len(stripes) != colorBarStripe+1 || !slices.Contains(r.texts("colorbar"), "sin(r)/r") {
+		t.Fatalf("the heatmap should have a labeled color bar of %d stripes", colorBarStripe)
+	}
+	for i, s := range stripes[:colorBarStripe] {
+		left, bottom, _, top := box(s.points)
+		h := (fr.top - fr.bottom) / colorBarStripe
+		if !near(bottom, fr.bottom+float64(i)*h, 1e-6) || !near(top, bottom+h, 1e-6) || left <= fr.right {
+			t.Errorf("the stripe %d of the color bar is misplaced", i)
+		}
+		if c, want := s.pencil.FillColor, Magma.Color((float64(i)+0.5)/colorBarStripe); c != want {
+			t.Errorf("the stripe %d of the color bar is %s and should be %s", i, c, want)
+		}
+	}
+}
+
+func TestFigure_MatrixPlot(t *testing.T) {
+	names := []string{"height", "weight", "age", "income"}
+	correlations := [][]float64{
+		{1, 0.72, 0.05, 0.21},
+		{0.72, 1, 0.18, 0.12},
+		{0.05, 0.18, 1, 0.45},
+		{0.21, 0.12, 0.45, 1},
+	}
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Correlations"
+	h := f.MatrixPlot(correlations, names, names, CoolWarm)
+	h.ZRange = Range{-1, 1}
+	r, fr := render(t, f, "TestFigure_MatrixPlot")
+
+	texts := r.texts("axes")
+	for _, name := range names {
+		if !slices.Contains(texts, name) {
+			t.Errorf("the matrix plot should have the tick label %s", name)
+		}
+	}
+
+	// The first row is at the top, and the values are written at the
+	// centers of the cells
+	cells := r.find("element-0", "polygon")
+	labels := r.find("element-0", "text")
+	if len(cells) != 16 || len(labels) != 16 {
+		t.Fatalf("the matrix plot has %d cells and %d labels, and should have 16", len(cells), len(labels))
+	}
+	fs := float64(svg.DefaultAxisFontSize)
+	for n, label := range labels {
+		i, j := n/4, n%4
+		x, y := fr.data(label.points[0].X+0.5*textWidth(label.text, fs), label.points[0].Y+0.35*fs)
+		if !near(x, float64(j), 1e-6) || !near(y, float64(3-i), 1e-6) {
+			t.Errorf("the label %s of the cell (%d,%d) is at (%g,%g)", label.text, i, j, x, y)
+		}
+		if want := fmt.Sprintf(DefaultHeatmapFormat, correlations[i][j]); label.text != want {
+			t.Errorf("the label of the cell (%d,%d) is %s and should be %s", i, j, label.text, want)
+		}
+		if c, want := cells[n].pencil.FillColor, CoolWarm.Color(0.5*(correlations[i][j]+1)); c != want {
+			t.Errorf("the cell (%d,%d) is %s and should be %s", i, j, c, want)
+		}
+	}
+}
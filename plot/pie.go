@@ -0,0 +1,414 @@
+package plot
+
+import (
+	"cmp"
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Pie, donut and sunburst charts
+// ===========================================================================
+
+// The pie charts are drawn in the plot area of the figure, whose axes are
+// hidden: the disk is centered in the plot area, and its radius does not
+// depend on the ranges of the figure (the disk remains round whatever the
+// aspect ratio of the plot area). The slices go clockwise from the angle
+// StartAngle, with the sectors of the sketcher (see svg.Sketcher.Sector).
+
+const (
+	DefaultPieStartAngle = 90 // angle of the first slice (degrees, the top of the disk)
+	pieFontSize          = 12
+	pieLeaderLength      = 12 // length of the leader lines of the outside labels (pixels)
+)
+
+// PieLabels defines where the labels of the slices are drawn
+type PieLabels int
+
+const (
+	PieLabelsInside  PieLabels = iota // the labels are drawn on the slices
+	PieLabelsOutside                  // the labels are drawn around the disk, with leader lines
+	PieLabelsLegend                   // the labels are the entries of the legend
+	PieLabelsNone                     // the labels are not drawn
+)
+
+// Pie is a pie chart: the slice i is the fraction Values[i]/sum(Values) of
+// the disk. The negative and non finite values are ignored.
+type Pie struct {
+	Values      []float64
+	Labels      []string
+	Colors      []string  // colors of the slices (the theme palette if nil or empty)
+	Explode     []float64 // offsets of the slices from the center (fraction of the radius)
+	Hole        float64   // radius of the hole of a donut chart (fraction of the radius)
+	StartAngle  float64   // angle of the start of the first slice (degrees)
+	LabelMode   PieLabels
+	ShowPercent bool // if true, the percentages are added to the labels
+}
+
+// Pie adds to the figure a pie chart of the values, whose slices are labeled
+// with the labels. The axes of the figure are hidden.
+func (f *Figure) Pie(values []float64, labels []string) *Pie {
+	p := &Pie{Values: values, Labels: labels, StartAngle: DefaultPieStartAngle}
+	f.HideAxes = true
+	f.add(p)
+	return p
+}
+
+// Donut adds to the figure a donut chart, i.e. a pie chart with a hole of
+// radius hole (fraction of the radius of the disk)
+func (f *Figure) Donut(values []float64, labels []string, hole float64) *Pie {
+	p := f.Pie(values, labels)
+	p.Hole = hole
+	return p
+}
+
+func (p *Pie) bounds() (x, y Range) {
+	return noRange, noRange
+}
+
+// pieValue returns the value v if it can be the value of a slice, 0 otherwise
+func pieValue(v float64) float64 {
+	if v > 0 && !math.IsInf(v, 1) {
+		return v
+	}
+	return 0
+}
+
+// sliceColor returns the color of the slice i
+func sliceColor(colors []string, i int, theme *svg.Theme) string {
+	if i < len(colors) && colors[i] != "" {
+		return colors[i]
+	}
+	return theme.PaletteColor(i)
+}
+
+// pieDisk returns the center and the radius (pixels) of the disk of a pie
+// chart in the plot area, with room for the offsets and the outside labels
+func pieDisk(fr frame, offset float64, outside bool) (cx, cy, r float64) {
+	cx, cy = 0.5*(fr.left+fr.right), 0.5*(fr.bottom+fr.top)
+	r = 0.5 * math.Min(fr.right-fr.left, fr.top-fr.bottom)
+	if outside {
+		r -= pieLeaderLength + 2*pieFontSize
+		r = math.Min(r, 0.5*(fr.right-fr.left)-6*pieFontSize)
+	}
+	return cx, cy, math.Max(r, 1) / (1 + offset)
+}
+
+func (p *Pie) draw(sk *svg.Sketcher, fr frame) {
+	total := 0.
+	for _, v := range p.Values {
+		total += pieValue(v)
+	}
+	if total == 0 {
+		return
+	}
+	offset := 0.
+	for _, e := range p.Explode {
+		offset = math.Max(offset, e)
+	}
+	cx, cy, r := pieDisk(fr, offset, p.LabelMode == PieLabelsOutside)
+
+	var labels []pieLabel
+	angle := p.StartAngle
+	for i, v := range p.Values {
+		v = pieValue(v)
+		if v == 0 {
+			continue
+		}
+		sweep := 360 * v / total
+		mid := angle - 0.5*sweep
+		// the center of the slice is moved along its bisector
+		sx, sy := cx, cy
+		if i < len(p.Explode) && p.Explode[i] > 0 {
+			sx += p.Explode[i] * r * math.Cos(mid*math.Pi/180)
+			sy += p.Explode[i] * r * math.Sin(mid*math.Pi/180)
+		}
+		setBarPencil(sk, fr.theme, sliceColor(p.Colors, i, fr.theme))
+		sk.AnnularSector(sx, sy, p.Hole*r, r, angle-sweep, angle, true)
+
+		if text := p.label(i, v/total); text != "" {
+			labels = append(labels, pieLabel{text, sx, sy, mid})
+		}
+		angle -= sweep
+	}
+
+	switch p.LabelMode {
+	case PieLabelsInside:
+		// the labels are centered between the hole and the border
+		rl := 0.5 * (math.Max(p.Hole, 0.25) + 1) * r
+		sk.Pencil = fr.theme.TextPencil.Clone()
+		sk.Pencil.FontSize = pieFontSize
+		sk.Pencil.FontColor = "white"
+		for _, l := range labels {
+			x := l.cx + rl*math.Cos(l.angle*math.Pi/180)
+			y := l.cy + rl*math.Sin(l.angle*math.Pi/180)
+			sk.Text(x-0.5*textWidth(l.text, pieFontSize), y-0.35*pieFontSize, l.text)
+		}
+	case PieLabelsOutside:
+		drawOutsideLabels(sk, fr, labels, r)
+	}
+}
+
+// label returns the label of the slice i, whose fraction of the disk is
+// fraction ("" if the slice is not labeled on the chart)
+func (p *Pie) label(i int, fraction float64) string {
+	if p.LabelMode == PieLabelsNone || p.LabelMode == PieLabelsLegend {
+		return ""
+	}
+	text := ""
+	if i < len(p.Labels) {
+		text = p.Labels[i]
+	}
+	if p.ShowPercent {
+		percent := fmt.Sprintf("%.1f%%", 100*fraction)
+		if text == "" {
+			return percent
+		}
+		return text + " (" + percent + ")"
+	}
+	return text
+}
+
+func (p *Pie) legend() []legendEntry {
+	if p.LabelMode != PieLabelsLegend {
+		return nil
+	}
+	var entries []legendEntry
+	for i, v := range p.Values {
+		if pieValue(v) == 0 || i >= len(p.Labels) || p.Labels[i] == "" {
+			continue
+		}
+		color := ""
+		if i < len(p.Colors) {
+			color = p.Colors[i]
+		}
+		entries = append(entries, boxEntry(p.Labels[i], color, i))
+	}
+	return entries
+}
+
+// pieLabel is the label of a slice, whose center is (cx, cy) and whose
+// bisector is at the angle angle (degrees)
+type pieLabel struct {
+	text   string
+	cx, cy float64
+	angle  float64
+}
+
+// drawOutsideLabels draws the labels of the slices of radius r around the
+// disk. Each label is at the end of a leader line starting from the middle of
+// the arc of its slice. The labels of each side of the disk are moved
+// vertically so that they do not overlap.
+func drawOutsideLabels(sk *svg.Sketcher, fr frame, labels []pieLabel, r float64) {
+	type placed struct {
+		pieLabel
+		ax, ay float64 // anchor of the leader line on the arc
+		y      float64 // baseline of the label
+	}
+	var left, right []*placed
+	for _, l := range labels {
+		c, s := math.Cos(l.angle*math.Pi/180), math.Sin(l.angle*math.Pi/180)
+		p := &placed{pieLabel: l, ax: l.cx + r*c, ay: l.cy + r*s}
+		p.y = l.cy + (r+pieLeaderLength)*s
+		if c >= 0 {
+			right = append(right, p)
+		} else {
+			left = append(left, p)
+		}
+	}
+
+	const spacing = 1.4 * pieFontSize
+	pencil := sk.Pencil
+	for side, group := range [][]*placed{left, right} {
+		// from top to bottom, each label is at least spacing below the
+		// previous one, and the labels are moved up if they go below the
+		// plot area
+		slices.SortFunc(group, func(a, b *placed) int {
+			return cmp.Compare(b.y, a.y)
+		})
+		for i := 1; i < len(group); i++ {
+			group[i].y = math.Min(group[i].y, group[i-1].y-spacing)
+		}
+		for i := len(group) - 1; i >= 0; i-- {
+			floor := fr.bottom + 0.5*pieFontSize
+			if i < len(group)-1 {
+				floor = group[i+1].y + spacing
+			}
+			group[i].y = math.Max(group[i].y, floor)
+		}
+
+		sign := 2*float64(side) - 1 // -1 on the left, 1 on the right
+		for _, p := range group {
+			// the leader line goes radially then horizontally to the label
+			ex := p.ax + pieLeaderLength*math.Cos(p.angle*math.Pi/180)
+			ex = sign * math.Max(sign*ex, sign*(p.cx+sign*(r+0.5*pieLeaderLength)))
+			sk.Pencil = fr.theme.GridPencil.Clone()
+			sk.Pencil.LineWidth = 1
+			sk.Curve([]struct{ X, Y float64 }{{p.ax, p.ay}, {ex, p.y}, {ex + sign*pieLeaderLength/2, p.y}})
+			sk.Pencil = fr.theme.TextPencil.Clone()
+			sk.Pencil.FontSize = pieFontSize
+			x := ex + sign*(pieLeaderLength/2+4)
+			if sign < 0 {
+				x -= textWidth(p.text, pieFontSize)
+			}
+			sk.Text(x, p.y-0.35*pieFontSize, p.text)
+		}
+	}
+	sk.Pencil = pencil
+}
+
+// --------------------------------------------------------------------
+// Sunburst charts
+
+// Node is a node of a hierarchy of values drawn as a sunburst chart. The
+// value of a node with children is the sum of the values of its children if
+// its own value is lower.
+type Node struct {
+	Label    string
+	Value    float64
+	Color    string // color of the node (the color of its parent, lighter, if empty)
+	Children []*Node
+}
+
+// total returns the value of the node
+func (n *Node) total() float64 {
+	sum := 0.
+	for _, c := range n.Children {
+		sum += c.total()
+	}
+	return math.Max(pieValue(n.Value), sum)
+}
+
+// depth returns the number of levels of the hierarchy below the node
+func (n *Node) depth() int {
+	d := 0
+	for _, c := range n.Children {
+		d = max(d, 1+c.depth())
+	}
+	return d
+}
+
+// Sunburst is a sunburst chart: a ring for each level of the hierarchy, the
+// children of a node dividing the sector of the node in the next ring. The
+// root node is drawn as a disk at the center if it has a label.
+type Sunburst struct {
+	Root       *Node
+	Hole       float64 // radius of the central disk (fraction of the radius)
+	StartAngle float64 // angle of the start of the first node (degrees)
+	MinLabel   float64 // minimal angle of the sector of a labeled node (degrees)
+}
+
+// Sunburst adds to the figure the sunburst chart of the hierarchy whose root
+// is root. The first level nodes get the colors of the theme palette, and
+// their descendants lighter shades of these colors. The axes of the figure
+// are hidden.
+func (f *Figure) Sunburst(root *Node) *Sunburst {
+	s := &Sunburst{Root: root, Hole: 0.2, StartAngle: DefaultPieStartAngle, MinLabel: 12}
+	f.HideAxes = true
+	f.add(s)
+	return s
+}
+
+func (s *Sunburst) bounds() (x, y Range) {
+	return noRange, noRange
+}
+
+// sunburstRings is the state of the drawing of a sunburst chart: the disk of
+// center (cx, cy) has a hole of radius hole and rings of width ring (pixels)
+type sunburstRings struct {
+	sk         *svg.Sketcher
+	theme      *svg.Theme
+	cx, cy     float64
+	hole, ring float64
+	minLabel   float64
+	textPencil *svg.Pencil
+}
+
+func (s *Sunburst) draw(sk *svg.Sketcher, fr frame) {
+	if s.Root == nil || s.Root.total() == 0 {
+		return
+	}
+	cx, cy, r := pieDisk(fr, 0, false)
+	rings := &sunburstRings{
+		sk: sk, theme: fr.theme, cx: cx, cy: cy,
+		hole: s.Hole * r, minLabel: s.MinLabel,
+		textPencil: fr.theme.TextPencil.Clone(),
+	}
+	rings.ring = (r - rings.hole) / float64(max(1, s.Root.depth()))
+	rings.textPencil.FontSize = pieFontSize
+	if s.Root.Label != "" && rings.hole > 0 {
+		sk.Pencil = rings.textPencil.Clone()
+		sk.Text(cx-0.5*textWidth(s.Root.Label, pieFontSize), cy-0.35*pieFontSize, s.Root.Label)
+	}
+	rings.drawChildren(s.Root, 0, s.StartAngle, 360, "")
+}
+
+// drawChildren draws the children of the node n in the ring level, dividing
+// the sector [start-sweep, start] of the node. The children of the root get
+// the palette colors, the other nodes a lighter shade of the parent color.
+func (rs *sunburstRings) drawChildren(n *Node, level int, start, sweep float64, color string) {
+	total := n.total()
+	if total == 0 {
+		return
+	}
+	i := 0
+	for _, c := range n.Children {
+		value := c.total()
+		if value == 0 {
+			continue
+		}
+		csweep := sweep * value / total
+		ccolor := c.Color
+		if ccolor == "" {
+			if level == 0 {
+				ccolor = rs.theme.PaletteColor(i)
+			} else {
+				ccolor = lighter(color, 0.3)
+			}
+		}
+		rs.drawNode(c, level, start, csweep, ccolor)
+		start -= csweep
+		i++
+	}
+}
+
+// drawNode draws the sector of the node n in the ring level, then its children
+func (rs *sunburstRings) drawNode(n *Node, level int, start, sweep float64, color string) {
+	r1 := rs.hole + float64(level)*rs.ring
+	setBarPencil(rs.sk, rs.theme, color)
+	rs.sk.AnnularSector(rs.cx, rs.cy, r1, r1+rs.ring, start-sweep, start, true)
+
+	// the label is drawn if it fits in the sector (along the arc or along
+	// the radius)
+	rl := r1 + 0.5*rs.ring
+	room := math.Max(rs.ring, sweep*math.Pi/180*rl)
+	if n.Label != "" && sweep >= rs.minLabel && textWidth(n.Label, pieFontSize) < room {
+		mid := (start - 0.5*sweep) * math.Pi / 180
+		x, y := rs.cx+rl*math.Cos(mid), rs.cy+rl*math.Sin(mid)
+		rs.sk.Pencil = rs.textPencil.Clone()
+		rs.sk.Pencil.FontColor = "white"
+		rs.sk.Text(x-0.5*textWidth(n.Label, pieFontSize), y-0.35*pieFontSize, n.Label)
+	}
+	rs.drawChildren(n, level+1, start, sweep, color)
+}
+
+func (s *Sunburst) legend() []legendEntry {
+	return nil
+}
+
+// lighter returns the color mixed with white (fraction of white), as an
+// hexadecimal color
+func lighter(colorname string, fraction float64) string {
+	c, err := svg.ParseColor(colorname)
+	if err != nil {
+		return colorname
+	}
+	mix := func(v uint8) uint8 {
+		return uint8(math.Round(float64(v) + fraction*(255-float64(v))))
+	}
+	return hexColor(color.RGBA{mix(c.R), mix(c.G), mix(c.B), c.A})
+}
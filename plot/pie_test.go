@@ -0,0 +1,134 @@
+package plot
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// radii returns the smallest and largest distances of the points to the
+// center (cx, cy)
+func radii(points []struct{ X, Y float64 }, cx, cy float64) (rmin, rmax float64) {
+	rmin, rmax = math.Inf(1), 0
+	for _, p := range points {
+		d := math.Hypot(p.X-cx, p.Y-cy)
+		rmin, rmax = math.Min(rmin, d), math.Max(rmax, d)
+	}
+	return rmin, rmax
+}
+
+func TestFigure_Pie(t *testing.T) {
+	languages := []string{"Go", "Python", "Rust", "C", "Java", "Other"}
+	shares := []float64{35, 25, 15, 10, 8, 7}
+
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Languages (pie)"
+	p := f.Pie(shares, languages)
+	p.LabelMode = PieLabelsOutside
+	p.ShowPercent = true
+	p.Explode = []float64{0.1}
+	r, fr := render(t, f, "TestFigure_Pie")
+
+	// The axes are hidden, and the labels show the percentages
+	if len(r.find("axes", "line")) > 0 {
+		t.Errorf("the axes of a pie chart should be hidden")
+	}
+	texts := r.texts("element-0")
+	for _, label := range []string{"Go (35.0%)", "Other (7.0%)"} {
+		if !slices.Contains(texts, label) {
+			t.Errorf("the pie chart should contain the label %s", label)
+		}
+	}
+
+	// The areas of the slices are proportional to the shares, and the first
+	// slice is moved away from the center by 10% of the radius
+	sectors := r.find("element-0", "polygon")
+	if len(sectors) != len(shares) {
+		t.Fatalf("the pie has %d slices and should have %d", len(sectors), len(shares))
+	}
+	cx, cy, radius := pieDisk(fr, 0.1, true)
+	for i, s := range sectors {
+		if a := area(s.points) / (math.Pi * radius * radius); !near(a, shares[i]/100, 0.005) {
+			t.Errorf("the slice %s is %.3f of the disk and should be %g", languages[i], a, shares[i]/100)
+		}
+		offset := 0.
+		if i == 0 {
+			offset = 0.1 * radius
+		}
+		if rmin, rmax := radii(s.points, cx, cy); !near(rmin, offset, 1e-6) || rmax > radius+offset+1e-6 {
+			t.Errorf("the slice %s is at the distances [%g,%g] of the center", languages[i], rmin, rmax)
+		}
+	}
+
+	f = New(DefaultWidth, DefaultHeight)
+	f.Title = "Languages (donut)"
+	d := f.Donut(shares, languages, 0.5)
+	d.LabelMode = PieLabelsLegend
+	r, fr = render(t, f, "TestFigure_Donut")
+	if texts := r.texts("legend"); !slices.Equal(texts, languages) {
+		t.Errorf("the labels of the donut are %v and should be in the legend", texts)
+	}
+	// The slices are in the ring between half the radius and the radius
+	cx, cy, radius = pieDisk(fr, 0, false)
+	for i, s := range r.find("element-0", "polygon") {
+		if a := area(s.points) / (0.75 * math.Pi * radius * radius); !near(a, shares[i]/100, 0.005) {
+			t.Errorf("the slice %s is %.3f of the ring and should be %g", languages[i], a, shares[i]/100)
+		}
+		if rmin, rmax := radii(s.points, cx, cy); !near(rmin, 0.5*radius, 1e-6) || !near(rmax, radius, 1e-6) {
+			t.Errorf("the slice %s is at the distances [%g,%g] of the center", languages[i], rmin, rmax)
+		}
+	}
+}
+
+func TestFigure_Sunburst(t *testing.T) {
+	root := &Node{Label: "World", Children: []*Node{
+		{Label: "Europe", Children: []*Node{
+			{Label: "France", Value: 68},
+			{Label: "Germany", Value: 84},
+			{Label: "Italy", Value: 59},
+		}},
+		{Label: "Asia", Children: []*Node{
+			{Label: "Japan", Value: 125},
+			{Label: "Korea", Value: 52},
+		}},
+		{Label: "Americas", Value: 40, Children: []*Node{
+			{Label: "Canada", Value: 39},
+		}},
+	}}
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Population (millions)"
+	f.Sunburst(root)
+	r, fr := render(t, f, "TestFigure_Sunburst")
+
+	// The value of a node is the sum of its children, or its own value if
+	// greater
+	if v := root.total(); v != 68+84+59+125+52+40 {
+		t.Errorf("the total of the root is %g and should be 428", v)
+	}
+
+	// A sector for each node but the root, drawn before its children: the
+	// continents in the inner ring, the countries in the outer ring
+	sectors := r.find("element-0", "polygon")
+	if len(sectors) != 9 {
+		t.Fatalf("the sunburst has %d sectors and should have 9", len(sectors))
+	}
+	cx, cy, radius := pieDisk(fr, 0, false)
+	ring := math.Pi * (0.6*0.6 - 0.2*0.2) * radius * radius
+	for _, c := range []struct {
+		index int
+		value float64
+	}{{0, 211}, {4, 177}, {7, 40}} {
+		s := sectors[c.index]
+		if a := area(s.points) / ring; !near(a, c.value/428, 0.005) {
+			t.Errorf("the sector %d is %.3f of its ring and should be %.3f", c.index, a, c.value/428)
+		}
+		if rmin, rmax := radii(s.points, cx, cy); !near(rmin, 0.2*radius, 1e-6) || !near(rmax, 0.6*radius, 1e-6) {
+			t.Errorf("the sector %d is at the distances [%g,%g] of the center", c.index, rmin, rmax)
+		}
+	}
+	for _, i := range []int{1, 2, 3, 5, 6, 8} {
+		if rmin, rmax := radii(sectors[i].points, cx, cy); !near(rmin, 0.6*radius, 1e-6) || !near(rmax, radius, 1e-6) {
+			t.Errorf("the sector %d is at the distances [%g,%g] of the center", i, rmin, rmax)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+// Package plot draws data plots (line and scatter plots, charts) with the
+// sketcher of the package svg. A plot is a Figure, to which are added the
+// elements to draw (e.g. the series of data created with Figure.Plot and
+// Figure.Scatter). The figure computes the ranges of the data, and draws the
+// elements, the axes and the legend on a sketcher.
+package plot
+
+import (
+	"math"
+	"strconv"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Figure
+// ===========================================================================
+
+const (
+	DefaultWidth  = 640
+	DefaultHeight = 480
+	DefaultMargin = 0.05 // relative margin added to the automatic data ranges
+
+	titleFontSize  = 16
+	legendFontSize = 12
+)
+
+// Range is a range of values [Min, Max]. A range whose Min is not lower than
+// Max is empty: the ranges of a figure are computed from the data if empty.
+type Range struct {
+	Min, Max float64
+}
+
+// Empty returns true if the range is empty
+func (r Range) Empty() bool {
+	return !(r.Min < r.Max)
+}
+
+// Extend returns the smallest range containing the range and the values (the
+// non finite values are ignored)
+func (r Range) Extend(values ...float64) Range {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		if r.Min > r.Max {
+			r.Min, r.Max = v, v
+			continue
+		}
+		r.Min = math.Min(r.Min, v)
+		r.Max = math.Max(r.Max, v)
+	}
+	return r
+}
+
+// WithMargin returns the range extended by the fraction margin of its length
+// on each side. A range reduced to a value v, or too narrow for its values
+// (e.g. [1, 1+1e-15]), is extended to [v-1, v+1] (or [v - |v|/10, v + |v|/10]
+// for large values).
+func (r Range) WithMargin(margin float64) Range {
+	if r.Min > r.Max {
+		return Range{0, 1}
+	}
+	d := (r.Max - r.Min) * margin
+	if r.Max-r.Min <= math.Max(math.Abs(r.Min), math.Abs(r.Max))*1e-12 {
+		d = math.Max(1, math.Abs(r.Min)/10)
+	}
+	return Range{r.Min - d, r.Max + d}
+}
+
+// noRange is the range of the elements without data (the neutral element of
+// Extend)
+var noRange = Range{math.Inf(1), math.Inf(-1)}
+
+// DataRange returns the range of the values with the relative margin on each
+// side (see Range.WithMargin). This is the automatic range of the figures.
+func DataRange(values []float64, margin float64) Range {
+	return noRange.Extend(values...).WithMargin(margin)
+}
+
+// LegendPosition is the corner of the plot area where the legend is drawn
+type LegendPosition int
+
+const (
+	LegendTopRight LegendPosition = iota
+	LegendTopLeft
+	LegendBottomRight
+	LegendBottomLeft
+	LegendNone // the legend is not drawn
+)
+
+// element is an element drawn in the plot area of a figure
+type element interface {
+	// bounds returns the ranges of the data of the element (noRange if
+	// the element does not define a range)
+	bounds() (x, y Range)
+	// draw draws the element in the frame of the figure
+	draw(sk *svg.Sketcher, fr frame)
+	// legend returns the entries of the element in the legend
+	legend() []legendEntry
+}
+
+// zeroBased is implemented by the elements drawn from the value zero (e.g.
+// the bars): the automatic range does not go beyond zero if the data are all
+// positive (or all negative)
+type zeroBased interface {
+	zeroBased() (x, y bool)
+}
+
+// categorical is implemented by the elements drawn on a categorical axis: the
+// categories of the x or y axis (nil if the axis is not categorical)
+type categorical interface {
+	categories() (x, y []string)
+}
+
+// tight is implemented by the elements filling their bounds (e.g. the cells
+// of a heatmap): the automatic ranges have no margin along the x or y axis
+type tight interface {
+	tight() (x, y bool)
+}
+
+// legendEntry is an entry of the legend: the label, and a sample of the
+// element drawn at the center (x, y) of a 30x12 pixels box
+type legendEntry struct {
+	label  string
+	sample func(sk *svg.Sketcher, x, y float64)
+}
+
+// Figure is a plot: a title, x and y axis, the elements drawn in the plot area
+// and a legend
+type Figure struct {
+	Width, Height  int // size of the figure (pixels)
+	Title          string
+	XLabel, YLabel string
+	XRange, YRange Range   // ranges of the axis (computed from the data if empty)
+	Margin         float64 // relative margin of the automatic ranges
+	Grid           bool    // if true, the grid lines of the major ticks are drawn
+	Legend         LegendPosition
+	Theme          *svg.Theme
+	HideAxes       bool // if true, the axes are not drawn (e.g. for a pie chart)
+	BarMode        BarMode
+	BarWidth       float64 // fraction of the category interval filled by the bars (DefaultBarWidth if zero)
+	HorizontalBars bool    // if true, the bars are horizontal and the categories are on the y axis
+
+	elements []element
+	bars     *barChart // element of the bar series (see Bars)
+	colors   int       // number of palette colors already used by the elements
+}
+
+// New creates an empty figure of width x height pixels, with the light theme
+func New(width, height int) *Figure {
+	return &Figure{
+		Width: width, Height: height,
+		Margin: DefaultMargin,
+		Theme:  svg.LightTheme,
+	}
+}
+
+// add adds the element to the figure
+func (f *Figure) add(e element) {
+	f.elements = append(f.elements, e)
+}
+
+// nextColor returns the index of the next palette color
+func (f *Figure) nextColor() int {
+	f.colors++
+	return f.colors - 1
+}
+
+// Ranges returns the ranges of the axis: the ranges of the figure, or the
+// ranges of the data with the margins if empty
+func (f *Figure) Ranges() (x, y Range) {
+	x, y = f.XRange, f.YRange
+	if x.Empty() || y.Empty() {
+		dx, dy := noRange, noRange
+		xzero, yzero := false, false
+		xtight, ytight := false, false // the categorical and tight ranges have no margin
+		for _, e := range f.elements {
+			ex, ey := e.bounds()
+			dx = dx.Extend(ex.Min, ex.Max)
+			dy = dy.Extend(ey.Min, ey.Max)
+			if z, ok := e.(zeroBased); ok {
+				zx, zy := z.zeroBased()
+				xzero, yzero = xzero || zx, yzero || zy
+			}
+			if c, ok := e.(categorical); ok {
+				cx, cy := c.categories()
+				xtight, ytight = xtight || cx != nil, ytight || cy != nil
+			}
+			if t, ok := e.(tight); ok {
+				tx, ty := t.tight()
+				xtight, ytight = xtight || tx, ytight || ty
+			}
+		}
+		if x.Empty() {
+			x = dx
+			if !xtight {
+				x = zeroMargin(dx, dx.WithMargin(f.Margin), xzero)
+			}
+		}
+		if y.Empty() {
+			y = dy
+			if !ytight {
+				y = zeroMargin(dy, dy.WithMargin(f.Margin), yzero)
+			}
+		}
+	}
+	return x, y
+}
+
+// zeroMargin returns the range r with margins, without the margin beyond zero
+// if zero is true and the data range d starts (or ends) at zero
+func zeroMargin(d, r Range, zero bool) Range {
+	if zero && d.Min == 0 {
+		r.Min = 0
+	}
+	if zero && d.Max == 0 {
+		r.Max = 0
+	}
+	return r
+}
+
+// frame maps the data coordinates to the user coordinates of the sketcher of
+// the figure (pixels from the bottom left corner of the figure, y up). The
+// plot area is the rectangle [left, right] x [bottom, top].
+type frame struct {
+	x, y                     Range
+	left, bottom, right, top float64
+	theme                    *svg.Theme
+}
+
+// point returns the user coordinates of the data point (x, y)
+func (fr frame) point(x, y float64) (float64, float64) {
+	px := fr.left + (x-fr.x.Min)*(fr.right-fr.left)/(fr.x.Max-fr.x.Min)
+	py := fr.bottom + (y-fr.y.Min)*(fr.top-fr.bottom)/(fr.y.Max-fr.y.Min)
+	return px, py
+}
+
+// data returns the data coordinates of the user point (px, py), the inverse of
+// point
+func (fr frame) data(px, py float64) (float64, float64) {
+	x := fr.x.Min + (px-fr.left)*(fr.x.Max-fr.x.Min)/(fr.right-fr.left)
+	y := fr.y.Min + (py-fr.bottom)*(fr.y.Max-fr.y.Min)/(fr.top-fr.bottom)
+	return x, y
+}
+
+// inside returns true if the user point (px, py) is in the plot area
+func (fr frame) inside(px, py float64) bool {
+	const eps = 1e-6
+	return px >= fr.left-eps && px <= fr.right+eps && py >= fr.bottom-eps && py <= fr.top+eps
+}
+
+// clip returns the part of the segment (user coordinates) in the plot area
+// (Liang-Barsky algorithm), ok being false if the segment is out of the area
+func (fr frame) clip(x1, y1, x2, y2 float64) (cx1, cy1, cx2, cy2 float64, ok bool) {
+	t0, t1 := 0., 1.
+	dx, dy := x2-x1, y2-y1
+	for _, c := range [4][2]float64{
+		{-dx, x1 - fr.left}, {dx, fr.right - x1},
+		{-dy, y1 - fr.bottom}, {dy, fr.top - y1},
+	} {
+		p, q := c[0], c[1]
+		if p == 0 {
+			if q < 0 {
+				return 0, 0, 0, 0, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+	}
+	if t0 > t1 {
+		return 0, 0, 0, 0, false
+	}
+	return x1 + t0*dx, y1 + t0*dy, x1 + t1*dx, y1 + t1*dy, true
+}
+
+// layout returns the frame of the figure, with room around the plot area
+// for the title, the tick labels and the axis titles
+func (f *Figure) layout() frame {
+	x, y := f.Ranges()
+	left, bottom, right, top := 70., 50., 20., 20.
+	if f.Title != "" {
+		top += 1.5 * titleFontSize
+	}
+	if f.YLabel != "" {
+		top += 1.5 * svg.DefaultAxisFontSize
+	}
+	if f.XLabel != "" {
+		bottom += 1.5 * svg.DefaultAxisFontSize
+	}
+	if f.HideAxes {
+		left, bottom = 20, 20
+	}
+	if _, _, _, ok := f.colorBar(); ok {
+		right += colorBarRoom
+	}
+	w, h := float64(f.Width), float64(f.Height)
+	return frame{x: x, y: y, left: left, bottom: bottom, right: w - right, top: h - top, theme: f.Theme}
+}
+
+// Draw draws the figure on a new sketcher, whose user coordinates are the
+// pixels from the bottom left corner of the figure
+func (f *Figure) Draw() *svg.Sketcher {
+	cs := svg.NewCoordSysBottomLeft(f.Width, f.Height, float64(f.Width))
+	sk := svg.NewSketcher().WithCoordinateSystem(cs).WithTheme(f.Theme)
+	fr := f.layout()
+	// the theme of the sketcher, whose missing pencils are the default ones
+	fr.theme = sk.Theme()
+
+	if !f.HideAxes {
+		axes := svg.NewAxes()
+		axes.X = svg.Axis{
+			Min: fr.x.Min, Max: fr.x.Max, Start: fr.left, End: fr.right,
+			Title: f.XLabel, MinorTicks: true, Grid: f.Grid,
+		}
+		axes.Y = svg.Axis{
+			Min: fr.y.Min, Max: fr.y.Max, Start: fr.bottom, End: fr.top,
+			Title: f.YLabel, MinorTicks: true, Grid: f.Grid,
+		}
+		for _, e := range f.elements {
+			if c, ok := e.(categorical); ok {
+				xc, yc := c.categories()
+				if xc != nil {
+					axes.X.Categories = xc
+				}
+				if yc != nil {
+					axes.Y.Categories = yc
+				}
+			}
+		}
+		sk.DrawAxes(axes)
+	}
+
+	for i, e := range f.elements {
+		pencil := sk.Pencil.Clone()
+		sk.BeginGroup(groupName("element", i))
+		e.draw(sk, fr)
+		sk.EndGroup()
+		sk.Pencil = pencil
+	}
+
+	if cmap, r, label, ok := f.colorBar(); ok {
+		f.drawColorBar(sk, fr, cmap, r, label)
+	}
+	if f.Legend != LegendNone {
+		f.drawLegend(sk, fr)
+	}
+	if f.Title != "" {
+		sk.Pencil.FontSize = titleFontSize
+		sk.Pencil.FontWeight = "bold"
+		tw := textWidth(f.Title, titleFontSize)
+		sk.Text(0.5*(fr.left+fr.right-tw), float64(f.Height)-10-0.75*titleFontSize, f.Title)
+	}
+	return sk
+}
+
+// Save draws the figure and saves it as an SVG file
+func (f *Figure) Save(svgpath string) error {
+	return f.Draw().Save(svgpath)
+}
+
+// drawLegend draws the legend entries of the elements in a box at the corner
+// of the plot area
+func (f *Figure) drawLegend(sk *svg.Sketcher, fr frame) {
+	var entries []legendEntry
+	for _, e := range f.elements {
+		entries = append(entries, e.legend()...)
+	}
+	if len(entries) == 0 {
+		return
+	}
+	const pad, sample, line = 8., 30., 1.5 * legendFontSize
+	labelw := 0.
+	for _, e := range entries {
+		labelw = math.Max(labelw, textWidth(e.label, legendFontSize))
+	}
+	w := pad + sample + pad + labelw + pad
+	h := pad + line*float64(len(entries)) + pad - (line - legendFontSize)
+	x, y := fr.right-10-w, fr.top-10-h // bottom left corner of the box
+	switch f.Legend {
+	case LegendTopLeft:
+		x = fr.left + 10
+	case LegendBottomRight:
+		y = fr.bottom + 10
+	case LegendBottomLeft:
+		x, y = fr.left+10, fr.bottom+10
+	}
+
+	sk.BeginGroup("legend")
+	defer sk.EndGroup()
+	pencil := sk.Pencil
+	defer func() { sk.Pencil = pencil }()
+	sk.Pencil = fr.theme.GridPencil.Clone()
+	sk.Pencil.FillColor = fr.theme.BackgroundColor
+	if fr.theme.BackgroundColor == svg.Transparent {
+		sk.Pencil.FillColor = "white"
+	}
+	sk.Rectangle(x, y, w, h, true)
+	for i, e := range entries {
+		// center of the sample, and baseline of the label
+		cy := y + h - pad - line*float64(i) - 0.5*legendFontSize
+		sk.Pencil = fr.theme.Pencil.Clone()
+		e.sample(sk, x+pad+0.5*sample, cy)
+		sk.Pencil = fr.theme.TextPencil.Clone()
+		sk.Pencil.FontSize = legendFontSize
+		sk.Text(x+pad+sample+pad, cy-0.35*legendFontSize, e.label)
+	}
+}
+
+// textWidth returns the approximate width (pixels) of the text written with
+// the font size fontsize
+func textWidth(text string, fontsize float64) float64 {
+	return 0.6 * fontsize * float64(len([]rune(text)))
+}
+
+// groupName returns the name of the group of the i-th element
+func groupName(prefix string, i int) string {
+	return prefix + "-" + strconv.Itoa(i)
+}
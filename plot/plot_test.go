@@ -0,0 +1,272 @@
+package plot
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+func TestRange_WithMargin(t *testing.T) {
+	r := DataRange([]float64{2, math.NaN(), 0, 1, math.Inf(1)}, 0.1)
+	if r != (Range{-0.2, 2.2}) {
+		t.Errorf("the range is %v and should be [-0.2, 2.2]", r)
+	}
+	if r := DataRange([]float64{3, 3}, 0.1); r != (Range{2, 4}) {
+		t.Errorf("the range of a single value is %v and should be [2, 4]", r)
+	}
+	if r := DataRange([]float64{1, math.Nextafter(1, 2)}, 0.1); r.Max-r.Min < 1 {
+		t.Errorf("the range of one float step is %v and should be widened as a single value", r)
+	}
+	f := New(400, 300)
+	f.Plot([]float64{0, 1}, []float64{1, math.Nextafter(1, 2)})
+	if _, y := f.Ranges(); y.Max-y.Min < 1 || len(f.Draw().ToSVG()) == 0 {
+		t.Errorf("the figure of a range of one float step should be drawn on the range %v", y)
+	}
+	if r := DataRange(nil, 0.1); r != (Range{0, 1}) {
+		t.Errorf("the range without values is %v and should be [0, 1]", r)
+	}
+}
+
+func TestFigure_Plot(t *testing.T) {
+	var xs, ys, cs []float64
+	for i := range 41 {
+		x := float64(i) / 4
+		xs = append(xs, x)
+		ys = append(ys, math.Sin(x))
+		cs = append(cs, math.Cos(x))
+	}
+	ys[20] = math.NaN() // the line is broken at the NaN point
+
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Trigonometric functions"
+	f.XLabel, f.YLabel = "x", "y"
+	f.Grid = true
+	sine := f.Plot(xs, ys)
+	sine.Label = "sin(x)"
+	cosine := f.Plot(xs, cs)
+	cosine.Label = "cos(x)"
+	cosine.LineStyle = LineDashed
+	cosine.Marker = MarkerSquare
+	cosine.MarkerSize = 5
+
+	measures := f.Scatter([]float64{1, 3, 5, 7, 9}, []float64{0.9, 0.1, -0.9, 0.6, 0.4})
+	measures.Label = "measures"
+	measures.Marker = MarkerDiamond
+	measures.YErr = []float64{0.1, 0.2, 0.1, 0.3, 0.1}
+	r, fr := render(t, f, "TestFigure_Plot")
+
+	// The automatic ranges contain the data and the error bars
+	if fr.x.Min > 0 || fr.x.Max < 10 || fr.y.Min > -1.0 || fr.y.Max < 1 {
+		t.Errorf("the ranges %v x %v should contain the data", fr.x, fr.y)
+	}
+
+	// The sine is broken at the NaN point, and its vertices are the data
+	// points
+	lines := r.find("element-0", "polyline")
+	if len(lines) != 2 || len(lines[0].points) != 20 || len(lines[1].points) != 20 {
+		t.Fatalf("the sine should be two polylines of 20 points")
+	}
+	for k, line := range lines {
+		for i, p := range line.points {
+			x, y := fr.point(xs[21*k+i], ys[21*k+i])
+			if !near(p.X, x, 1e-6) || !near(p.Y, y, 1e-6) {
+				t.Fatalf("the point %d of the sine is (%g,%g) and should be (%g,%g)", 21*k+i, p.X, p.Y, x, y)
+			}
+		}
+	}
+
+	// A square marker centered on each point of the cosine
+	markers := r.find("element-1", "polygon")
+	if len(markers) != len(xs) {
+		t.Fatalf("the cosine has %d markers and should have %d", len(markers), len(xs))
+	}
+	for i, m := range markers {
+		left, bottom, right, top := box(m.points)
+		x, y := fr.point(xs[i], cs[i])
+		if !near(0.5*(left+right), x, 1e-6) || !near(0.5*(bottom+top), y, 1e-6) || !near(right-left, 0.85*5, 1e-6) {
+			t.Fatalf("the marker %d of the cosine is [%g,%g]x[%g,%g], not centered on (%g,%g)", i, left, right, bottom, top, x, y)
+		}
+	}
+
+	// The error bars are the vertical segments, as long as twice the errors
+	yscale := (fr.top - fr.bottom) / (fr.y.Max - fr.y.Min)
+	var bars []float64
+	for _, l := range r.find("element-2", "line") {
+		if p, q := l.points[0], l.points[1]; near(p.X, q.X, 1e-6) {
+			bars = append(bars, math.Abs(q.Y-p.Y)/yscale)
+		}
+	}
+	if !equalValues(bars, []float64{0.2, 0.4, 0.2, 0.6, 0.2}) {
+		t.Errorf("the error bars have the lengths %v and should be twice the errors", bars)
+	}
+	if n := len(r.find("element-2", "polygon")); n != 5 {
+		t.Errorf("the measures have %d markers and should have 5", n)
+	}
+
+	if texts := r.texts("legend"); !slices.Equal(texts, []string{"sin(x)", "cos(x)", "measures"}) {
+		t.Errorf("the legend has the labels %v", texts)
+	}
+	if texts := r.texts(""); !slices.Contains(texts, "Trigonometric functions") {
+		t.Errorf("the figure should have the title")
+	}
+	for _, sh := range r.shapes {
+		for _, p := range sh.points {
+			if !finite(p.X, p.Y) {
+				t.Fatalf("the %s %v of the group %s is not finite", sh.kind, sh.points, sh.group)
+			}
+		}
+	}
+}
+
+func TestFigure_PartialTheme(t *testing.T) {
+	// The pencils missing in the theme are the default ones
+	f := New(DefaultWidth, DefaultHeight)
+	f.Theme = &svg.Theme{BackgroundColor: "ivory", Palette: []string{"purple"}}
+	f.Plot([]float64{0, 1}, []float64{0, 1}).Label = "line"
+	r, _ := render(t, f, "TestFigure_PartialTheme")
+	if lines := r.find("element-0", "polyline"); len(lines) != 1 || lines[0].pencil.LineColor != "purple" {
+		t.Errorf("the line should be drawn with the palette of the theme")
+	}
+	if texts := r.texts("legend"); !slices.Equal(texts, []string{"line"}) {
+		t.Errorf("the legend has the labels %v and should have the label of the line", texts)
+	}
+	if len(r.find("axes", "text")) == 0 {
+		t.Errorf("the axes should have tick labels with the default text pencil")
+	}
+}
+
+func TestFrame_Clip(t *testing.T) {
+	fr := frame{left: 0, bottom: 0, right: 10, top: 10}
+	x1, y1, x2, y2, ok := fr.clip(-5, 5, 15, 5)
+	if !ok || x1 != 0 || y1 != 5 || x2 != 10 || y2 != 5 {
+		t.Errorf("the clipped segment is (%g,%g)-(%g,%g) and should be (0,5)-(10,5)", x1, y1, x2, y2)
+	}
+	if _, _, _, _, ok := fr.clip(-5, -5, -1, 20); ok {
+		t.Errorf("the segment out of the area should be rejected")
+	}
+}
+
+// recorder is a renderer recording the shapes of a figure, in the user
+// coordinates of the figure (pixels from the bottom left corner)
+type recorder struct {
+	height float64
+	groups []string
+	x, y   float64 // current point
+	shapes []recordedShape
+}
+
+// recordedShape is a shape of a figure: a segment ("line"), a "polygon", a
+// "polyline", a "circle" (center and radius), a "text" (position) or an
+// "image" (bottom left and top right corners)
+type recordedShape struct {
+	group  string // innermost group of the shape
+	kind   string
+	points []struct{ X, Y float64 }
+	radius float64
+	fill   bool
+	text   string
+	pencil svg.Pencil
+}
+
+func (r *recorder) add(kind string, p svg.Pencil, points ...struct{ X, Y float64 }) *recordedShape {
+	group := ""
+	if n := len(r.groups); n > 0 {
+		group = r.groups[n-1]
+	}
+	for i := range points {
+		points[i].Y = r.height - points[i].Y
+	}
+	r.shapes = append(r.shapes, recordedShape{group: group, kind: kind, points: points, pencil: p})
+	return &r.shapes[len(r.shapes)-1]
+}
+
+func (r *recorder) Begin(width, height int, background string) { r.height = float64(height) }
+func (r *recorder) End()                                       {}
+func (r *recorder) MoveTo(x, y float64)                        { r.x, r.y = x, y }
+func (r *recorder) LineTo(x, y float64, p svg.Pencil) {
+	r.add("line", p, struct{ X, Y float64 }{r.x, r.y}, struct{ X, Y float64 }{x, y})
+	r.x, r.y = x, y
+}
+func (r *recorder) Path(points []struct{ X, Y float64 }, closed, fill bool, p svg.Pencil) {
+	kind := "polyline"
+	if closed {
+		kind = "polygon"
+	}
+	r.add(kind, p, slices.Clone(points)...).fill = fill
+}
+func (r *recorder) Circle(cx, cy, radius float64, fill bool, p svg.Pencil) {
+	sh := r.add("circle", p, struct{ X, Y float64 }{cx, cy})
+	sh.radius, sh.fill = radius, fill
+}
+func (r *recorder) Arc(cx, cy, radius, startAngle, sweepAngle float64, p svg.Pencil) {
+	r.add("arc", p, struct{ X, Y float64 }{cx, cy}).radius = radius
+}
+func (r *recorder) Text(x, y float64, text string, p svg.Pencil) {
+	r.add("text", p, struct{ X, Y float64 }{x, y}).text = text
+}
+func (r *recorder) Image(x, y, width, height float64, href string, style svg.ImageStyle) {
+	r.add("image", svg.Pencil{}, struct{ X, Y float64 }{x, y + height}, struct{ X, Y float64 }{x + width, y})
+}
+func (r *recorder) BeginGroup(name string) { r.groups = append(r.groups, name) }
+func (r *recorder) EndGroup()              { r.groups = r.groups[:len(r.groups)-1] }
+
+// find returns the shapes of the kind drawn in the group
+func (r *recorder) find(group, kind string) []recordedShape {
+	var shapes []recordedShape
+	for _, sh := range r.shapes {
+		if sh.group == group && sh.kind == kind {
+			shapes = append(shapes, sh)
+		}
+	}
+	return shapes
+}
+
+// texts returns the texts written in the group
+func (r *recorder) texts(group string) []string {
+	var texts []string
+	for _, sh := range r.find(group, "text") {
+		texts = append(texts, sh.text)
+	}
+	return texts
+}
+
+// render draws the figure once, saves the drawing as the SVG file
+// output.<name>.svg and returns the shapes of the drawing and the frame of
+// the plot area
+func render(t *testing.T, f *Figure, name string) (*recorder, frame) {
+	t.Helper()
+	sk := f.Draw()
+	if err := sk.Save("output." + name + ".svg"); err != nil {
+		t.Fatal(err)
+	}
+	r := &recorder{}
+	sk.Render(r)
+	return r, f.layout()
+}
+
+// box returns the bounding box of the points
+func box(points []struct{ X, Y float64 }) (left, bottom, right, top float64) {
+	left, bottom, right, top = math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		left, right = math.Min(left, p.X), math.Max(right, p.X)
+		bottom, top = math.Min(bottom, p.Y), math.Max(top, p.Y)
+	}
+	return left, bottom, right, top
+}
+
+// area returns the area of the polygon (shoelace formula)
+func area(points []struct{ X, Y float64 }) float64 {
+	a := 0.
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return math.Abs(0.5 * a)
+}
+
+// near returns true if the values are equal, up to the tolerance
+func near(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
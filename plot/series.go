@@ -0,0 +1,291 @@
+package plot
+
+import (
+	"math"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Series of data (line and scatter plots)
+// ===========================================================================
+
+const (
+	DefaultLineWidth  = 2
+	DefaultMarkerSize = 8 // size of the markers (pixels)
+)
+
+// Marker is the shape drawn at the data points of a series
+type Marker int
+
+const (
+	NoMarker Marker = iota
+	MarkerCircle
+	MarkerSquare
+	MarkerTriangle
+	MarkerDiamond
+	MarkerCross
+	MarkerPlus
+)
+
+// LineStyle is the style of the line joining the data points of a series
+type LineStyle int
+
+const (
+	LineSolid LineStyle = iota
+	LineDashed
+	LineDotted
+	LineDashDot
+	NoLine
+)
+
+// dash returns the dash pattern of the line style for the line width
+func (l LineStyle) dash(width int) []float64 {
+	w := float64(max(width, 1))
+	switch l {
+	case LineDashed:
+		return []float64{6 * w, 3 * w}
+	case LineDotted:
+		return []float64{w, 2 * w}
+	case LineDashDot:
+		return []float64{6 * w, 2 * w, w, 2 * w}
+	}
+	return nil
+}
+
+// Series is a series of data points (X[i], Y[i]), drawn as a line and/or
+// markers. The points whose coordinates are NaN break the line.
+type Series struct {
+	X, Y       []float64
+	XErr, YErr []float64 // half lengths of the error bars (no error bar if nil)
+	Label      string    // label of the series in the legend (not in the legend if empty)
+	Color      string    // color of the series (the next color of the theme palette if empty)
+	LineWidth  int
+	LineStyle  LineStyle
+	Marker     Marker
+	MarkerSize float64 // size of the markers (pixels)
+
+	colorIndex int
+	curve      *curve // function drawn instead of the data points (see PlotFunc)
+}
+
+// Plot adds to the figure the series of the points (xs[i], ys[i]) drawn as a
+// solid line without marker. If xs is nil, the x values are the indices of
+// the y values.
+func (f *Figure) Plot(xs, ys []float64) *Series {
+	s := newSeries(f, xs, ys)
+	f.add(s)
+	return s
+}
+
+// Scatter adds to the figure the series of the points (xs[i], ys[i]) drawn as
+// circle markers, without line
+func (f *Figure) Scatter(xs, ys []float64) *Series {
+	s := newSeries(f, xs, ys)
+	s.LineStyle = NoLine
+	s.Marker = MarkerCircle
+	f.add(s)
+	return s
+}
+
+func newSeries(f *Figure, xs, ys []float64) *Series {
+	if xs == nil {
+		xs = make([]float64, len(ys))
+		for i := range xs {
+			xs[i] = float64(i)
+		}
+	}
+	return &Series{
+		X: xs, Y: ys,
+		LineWidth:  DefaultLineWidth,
+		MarkerSize: DefaultMarkerSize,
+		colorIndex: f.nextColor(),
+	}
+}
+
+// len returns the number of points of the series
+func (s *Series) len() int {
+	return min(len(s.X), len(s.Y))
+}
+
+// errorBar returns the half length of the error bar i (0 if none)
+func errorBar(errs []float64, i int) float64 {
+	if i < len(errs) {
+		return math.Abs(errs[i])
+	}
+	return 0
+}
+
+func (s *Series) bounds() (x, y Range) {
+	if s.curve != nil {
+		return s.curve.bounds()
+	}
+	x, y = noRange, noRange
+	for i := range s.len() {
+		ex, ey := errorBar(s.XErr, i), errorBar(s.YErr, i)
+		x = x.Extend(s.X[i]-ex, s.X[i]+ex)
+		y = y.Extend(s.Y[i]-ey, s.Y[i]+ey)
+	}
+	return x, y
+}
+
+// color returns the color of the series
+func (s *Series) color(theme *svg.Theme) string {
+	if s.Color != "" {
+		return s.Color
+	}
+	return theme.PaletteColor(s.colorIndex)
+}
+
+// setPencil sets the pencil of the sketcher to draw the lines of the series
+func (s *Series) setPencil(sk *svg.Sketcher, theme *svg.Theme) {
+	sk.Pencil.LineColor = s.color(theme)
+	sk.Pencil.FillColor = s.color(theme)
+	sk.Pencil.LineWidth = s.LineWidth
+	sk.Pencil.Dash = s.LineStyle.dash(s.LineWidth)
+}
+
+func (s *Series) draw(sk *svg.Sketcher, fr frame) {
+	s.setPencil(sk, fr.theme)
+	if s.LineStyle != NoLine {
+		if s.curve != nil {
+			for _, line := range s.curve.sample(fr) {
+				drawLine(sk, fr, line)
+			}
+		} else {
+			line := make([]struct{ X, Y float64 }, s.len())
+			for i := range line {
+				line[i].X, line[i].Y = s.X[i], s.Y[i]
+			}
+			drawLine(sk, fr, line)
+		}
+	}
+	if s.curve != nil {
+		return
+	}
+
+	sk.Pencil.Dash = nil
+	sk.Pencil.LineWidth = max(1, s.LineWidth/2)
+	for i := range s.len() {
+		x, y := s.X[i], s.Y[i]
+		if ex := errorBar(s.XErr, i); ex > 0 {
+			s.errorBar(sk, fr, x-ex, y, x+ex, y)
+		}
+		if ey := errorBar(s.YErr, i); ey > 0 {
+			s.errorBar(sk, fr, x, y-ey, x, y+ey)
+		}
+	}
+	if s.Marker != NoMarker {
+		for i := range s.len() {
+			px, py := fr.point(s.X[i], s.Y[i])
+			if finite(px, py) && fr.inside(px, py) {
+				drawMarker(sk, s.Marker, px, py, s.MarkerSize)
+			}
+		}
+	}
+}
+
+// drawLine draws the polyline of data points clipped to the plot area. The
+// line is broken at the points whose coordinates are not finite.
+func drawLine(sk *svg.Sketcher, fr frame, points []struct{ X, Y float64 }) {
+	var line []struct{ X, Y float64 }
+	flush := func() {
+		if len(line) > 1 {
+			sk.Curve(line)
+		}
+		line = nil
+	}
+	for i := 1; i < len(points); i++ {
+		x1, y1 := fr.point(points[i-1].X, points[i-1].Y)
+		x2, y2 := fr.point(points[i].X, points[i].Y)
+		if !finite(x1, y1, x2, y2) {
+			flush()
+			continue
+		}
+		cx1, cy1, cx2, cy2, ok := fr.clip(x1, y1, x2, y2)
+		if !ok {
+			flush()
+			continue
+		}
+		if n := len(line); n == 0 || line[n-1].X != cx1 || line[n-1].Y != cy1 {
+			flush()
+			line = append(line, struct{ X, Y float64 }{cx1, cy1})
+		}
+		line = append(line, struct{ X, Y float64 }{cx2, cy2})
+	}
+	flush()
+}
+
+// errorBar draws the error bar from the data point (x1, y1) to (x2, y2), with
+// a cap at each end
+func (s *Series) errorBar(sk *svg.Sketcher, fr frame, x1, y1, x2, y2 float64) {
+	px1, py1 := fr.point(x1, y1)
+	px2, py2 := fr.point(x2, y2)
+	cx1, cy1, cx2, cy2, ok := fr.clip(px1, py1, px2, py2)
+	if !finite(px1, py1, px2, py2) || !ok {
+		return
+	}
+	sk.Edge(cx1, cy1, cx2, cy2)
+	// the caps are perpendicular to the bar
+	c := 0.5 * s.MarkerSize * 0.75
+	for _, p := range [][2]float64{{px1, py1}, {px2, py2}} {
+		if !fr.inside(p[0], p[1]) {
+			continue
+		}
+		if py1 == py2 {
+			sk.Edge(p[0], p[1]-c, p[0], p[1]+c)
+		} else {
+			sk.Edge(p[0]-c, p[1], p[0]+c, p[1])
+		}
+	}
+}
+
+// drawMarker draws the marker of size size (pixels) centered on the user
+// point (x, y), with the line and fill colors of the pencil
+func drawMarker(sk *svg.Sketcher, m Marker, x, y, size float64) {
+	r := 0.5 * size
+	switch m {
+	case MarkerCircle:
+		sk.Circle(x, y, r, true)
+	case MarkerSquare:
+		r *= 0.85
+		sk.Rectangle(x-r, y-r, 2*r, 2*r, true)
+	case MarkerTriangle:
+		sk.Triangle(x, y+r, x-0.866*r, y-0.5*r, x+0.866*r, y-0.5*r, true)
+	case MarkerDiamond:
+		sk.Quadrangle(x, y+r, x-r, y, x, y-r, x+r, y, true)
+	case MarkerCross:
+		r *= 0.75
+		sk.Edge(x-r, y-r, x+r, y+r)
+		sk.Edge(x-r, y+r, x+r, y-r)
+	case MarkerPlus:
+		sk.Edge(x-r, y, x+r, y)
+		sk.Edge(x, y-r, x, y+r)
+	}
+}
+
+func (s *Series) legend() []legendEntry {
+	if s.Label == "" {
+		return nil
+	}
+	return []legendEntry{{s.Label, func(sk *svg.Sketcher, x, y float64) {
+		s.setPencil(sk, sk.Theme())
+		if s.LineStyle != NoLine {
+			sk.Edge(x-15, y, x+15, y)
+		}
+		sk.Pencil.Dash = nil
+		if s.Marker != NoMarker {
+			drawMarker(sk, s.Marker, x, y, s.MarkerSize)
+		}
+	}}}
+}
+
+// finite returns true if all the values are finite
+func finite(values ...float64) bool {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
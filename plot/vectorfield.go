@@ -0,0 +1,487 @@
+package plot
+
+import (
+	"math"
+
+	svg "github.com/gboulant/dingo-svg"
+)
+
+// ===========================================================================
+// Vector fields: quiver and streamline plots
+// ===========================================================================
+
+// VectorField is a 2D vector field: the vector (u, v) at the point (x, y)
+type VectorField func(x, y float64) (u, v float64)
+
+const (
+	DefaultQuiverGrid           = 20 // number of arrows along each axis of a quiver plot
+	DefaultStreamlineSeparation = 20 // distance between the streamlines (pixels)
+	arrowHeadLength             = 6  // length of the head of the arrows (pixels)
+)
+
+// magnitude returns the norm of the vector of the field at (x, y), NaN if
+// the field is not defined at this point
+func (vf VectorField) magnitude(x, y float64) float64 {
+	u, v := vf(x, y)
+	if !finite(u, v) {
+		return math.NaN()
+	}
+	return math.Hypot(u, v)
+}
+
+// drawArrow draws the arrow from the user point (x1, y1) to (x2, y2), whose
+// head is a triangle filled with the line color of the pencil
+func drawArrow(sk *svg.Sketcher, x1, y1, x2, y2, head float64) {
+	dx, dy := x2-x1, y2-y1
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return
+	}
+	head = math.Min(head, 0.5*l)
+	ux, uy := dx/l, dy/l
+	// the base of the head
+	bx, by := x2-head*ux, y2-head*uy
+	sk.Edge(x1, y1, bx, by)
+	w := 0.4 * head
+	fill := sk.Pencil.FillColor
+	sk.Pencil.FillColor = sk.Pencil.LineColor
+	sk.Triangle(x2, y2, bx-w*uy, by+w*ux, bx+w*uy, by-w*ux, true)
+	sk.Pencil.FillColor = fill
+}
+
+// --------------------------------------------------------------------
+// Quiver plots
+
+// Quiver draws the vectors of a field as arrows at the nodes of a regular
+// grid. The length of the arrows is proportional to the magnitude of the
+// vectors, the longest arrow being as long as the grid step.
+type Quiver struct {
+	Field      VectorField
+	XR, YR     Range // ranges of the grid
+	NX, NY     int   // number of arrows along x and y
+	Color      string
+	Colormap   *Colormap // if not nil, the arrows are colored by their magnitude
+	ColorBar   bool      // if true, the color bar of the magnitudes is drawn (with a colormap)
+	LineWidth  int
+	colorIndex int
+}
+
+// Quiver adds to the figure the quiver plot of the field: n x n arrows on the
+// ranges (DefaultQuiverGrid x DefaultQuiverGrid if n is not positive)
+func (f *Figure) Quiver(field VectorField, xr, yr Range, n int) *Quiver {
+	if n <= 0 {
+		n = DefaultQuiverGrid
+	}
+	q := &Quiver{
+		Field: field, XR: xr, YR: yr, NX: n, NY: n,
+		LineWidth: 1, ColorBar: true, colorIndex: f.nextColor(),
+	}
+	f.add(q)
+	return q
+}
+
+// nodes calls the function for each node of the grid of the arrows (at the
+// centers of the cells of the ranges)
+func (q *Quiver) nodes(fn func(x, y float64)) {
+	for i := range q.NY {
+		y := q.YR.Min + (float64(i)+0.5)*(q.YR.Max-q.YR.Min)/float64(q.NY)
+		for j := range q.NX {
+			fn(q.XR.Min+(float64(j)+0.5)*(q.XR.Max-q.XR.Min)/float64(q.NX), y)
+		}
+	}
+}
+
+// magnitudes returns the range of the magnitudes of the arrows
+func (q *Quiver) magnitudes() Range {
+	r := noRange
+	q.nodes(func(x, y float64) {
+		r = r.Extend(q.Field.magnitude(x, y))
+	})
+	return r
+}
+
+func (q *Quiver) bounds() (x, y Range) {
+	return q.XR, q.YR
+}
+
+func (q *Quiver) tight() (x, y bool) {
+	return true, true
+}
+
+func (q *Quiver) colorBar() (cmap *Colormap, r Range, label string, ok bool) {
+	r = q.magnitudes()
+	return q.Colormap, r, "|v|", q.Colormap != nil && q.ColorBar && !r.Empty()
+}
+
+func (q *Quiver) draw(sk *svg.Sketcher, fr frame) {
+	if q.NX < 1 || q.NY < 1 {
+		return
+	}
+	r := q.magnitudes()
+	if r.Max <= 0 || r.Min > r.Max {
+		return
+	}
+	// the longest arrow is 90% of the smallest step of the grid (pixels)
+	stepx := (fr.right - fr.left) * (q.XR.Max - q.XR.Min) / (fr.x.Max - fr.x.Min) / float64(q.NX)
+	stepy := (fr.top - fr.bottom) * (q.YR.Max - q.YR.Min) / (fr.y.Max - fr.y.Min) / float64(q.NY)
+	scale := 0.9 * math.Min(math.Abs(stepx), math.Abs(stepy)) / r.Max
+
+	sk.Pencil.LineWidth = max(q.LineWidth, 1)
+	sk.Pencil.Dash = nil
+	sk.Pencil.LineColor = barColor(q.Color, q.colorIndex, fr.theme)
+	q.nodes(func(x, y float64) {
+		u, v := q.Field(x, y)
+		if !finite(u, v) {
+			return
+		}
+		px, py := fr.point(x, y)
+		if !fr.inside(px, py) {
+			return
+		}
+		// the direction on the canvas follows the scales of the axes
+		dx, dy := fr.point(x+u, y+v)
+		dx, dy = dx-px, dy-py
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			return
+		}
+		length := scale * math.Hypot(u, v)
+		dx, dy = dx*length/l, dy*length/l
+		if q.Colormap != nil {
+			sk.Pencil.LineColor = q.Colormap.Color(r.scale(math.Hypot(u, v)))
+		}
+		// the arrow is centered on the node
+		drawArrow(sk, px-0.5*dx, py-0.5*dy, px+0.5*dx, py+0.5*dy, arrowHeadLength)
+	})
+}
+
+func (q *Quiver) legend() []legendEntry {
+	return nil
+}
+
+// --------------------------------------------------------------------
+// Streamlines
+
+// The streamlines are integrated with the Runge-Kutta method of order 4 on the
+// canvas (the direction of the field is followed at a constant speed of one
+// pixel per unit of time, whatever its magnitude and the scales of the axes).
+// Without seed points, the streamlines are evenly spaced (Jobard and Lefer):
+// the new seeds are taken at the separation distance beside the existing
+// streamlines, and a streamline stops when it comes closer than half the
+// separation distance to another one.
+
+const (
+	streamlineStep     = 2    // integration step (pixels)
+	streamlineMaxSteps = 4000 // maximal number of steps in each direction
+	streamlineChunk    = 8    // number of steps of the parts of a streamline colored by magnitude
+)
+
+// Streamlines draws the streamlines of a vector field, i.e. the curves
+// tangent to the vectors of the field
+type Streamlines struct {
+	Field      VectorField
+	XR, YR     Range
+	Seeds      []struct{ X, Y float64 } // starting points (evenly spaced streamlines if nil)
+	Separation float64                  // distance between the evenly spaced streamlines (pixels)
+	Color      string
+	Colormap   *Colormap // if not nil, the streamlines are colored by the magnitude of the field
+	ColorBar   bool      // if true, the color bar of the magnitudes is drawn (with a colormap)
+	LineWidth  int
+	Arrows     bool // if true, an arrow shows the direction of each streamline
+	colorIndex int
+}
+
+// Streamlines adds to the figure the streamlines of the field on the ranges,
+// starting from the seeds (evenly spaced streamlines if nil)
+func (f *Figure) Streamlines(field VectorField, xr, yr Range, seeds []struct{ X, Y float64 }) *Streamlines {
+	s := &Streamlines{
+		Field: field, XR: xr, YR: yr, Seeds: seeds,
+		Separation: DefaultStreamlineSeparation, LineWidth: 1,
+		ColorBar: true, Arrows: true, colorIndex: f.nextColor(),
+	}
+	f.add(s)
+	return s
+}
+
+func (s *Streamlines) bounds() (x, y Range) {
+	return s.XR, s.YR
+}
+
+func (s *Streamlines) tight() (x, y bool) {
+	return true, true
+}
+
+// magnitudes returns the range of the magnitudes of the field, sampled on a
+// grid of the ranges
+func (s *Streamlines) magnitudes() Range {
+	r := noRange
+	const n = 32
+	for i := range n + 1 {
+		for j := range n + 1 {
+			x := s.XR.Min + (s.XR.Max-s.XR.Min)*float64(j)/n
+			y := s.YR.Min + (s.YR.Max-s.YR.Min)*float64(i)/n
+			r = r.Extend(s.Field.magnitude(x, y))
+		}
+	}
+	return r
+}
+
+func (s *Streamlines) colorBar() (cmap *Colormap, r Range, label string, ok bool) {
+	r = s.magnitudes()
+	return s.Colormap, r, "|v|", s.Colormap != nil && s.ColorBar && !r.Empty()
+}
+
+// streamPoint is a point of a streamline (user coordinates)
+type streamPoint struct {
+	x, y float64
+	line int // index of the streamline
+	step int // index of the point in the streamline
+}
+
+// streamGrid is the grid of the points of the streamlines, whose cells are
+// as large as the separation distance
+type streamGrid struct {
+	cell  float64
+	cells map[[2]int][]streamPoint
+}
+
+func (g *streamGrid) key(x, y float64) [2]int {
+	return [2]int{int(math.Floor(x / g.cell)), int(math.Floor(y / g.cell))}
+}
+
+func (g *streamGrid) add(p streamPoint) {
+	k := g.key(p.x, p.y)
+	g.cells[k] = append(g.cells[k], p)
+}
+
+// near returns true if a point of the grid is closer than d to (x, y), the
+// points of the streamline line (if line >= 0) being ignored if close to the
+// step step along the line
+func (g *streamGrid) near(x, y, d float64, line, step int) bool {
+	k := g.key(x, y)
+	for i := k[0] - 1; i <= k[0]+1; i++ {
+		for j := k[1] - 1; j <= k[1]+1; j++ {
+			for _, p := range g.cells[[2]int{i, j}] {
+				if p.line == line && abs(p.step-step)*streamlineStep < int(3*g.cell) {
+					continue
+				}
+				if math.Hypot(p.x-x, p.y-y) < d {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// remove removes the points of the streamline line
+func (g *streamGrid) remove(line int) {
+	for k, points := range g.cells {
+		kept := points[:0]
+		for _, p := range points {
+			if p.line != line {
+				kept = append(kept, p)
+			}
+		}
+		g.cells[k] = kept
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// direction returns the unit direction (user coordinates) of the field at the
+// user point (px, py), ok being false if the field is not defined or zero
+func (s *Streamlines) direction(fr frame, px, py float64) (dx, dy float64, ok bool) {
+	x, y := fr.data(px, py)
+	u, v := s.Field(x, y)
+	if !finite(u, v) {
+		return 0, 0, false
+	}
+	qx, qy := fr.point(x+u, y+v)
+	dx, dy = qx-px, qy-py
+	l := math.Hypot(dx, dy)
+	if l == 0 || math.Hypot(u, v) < 1e-12 {
+		return 0, 0, false
+	}
+	return dx / l, dy / l, true
+}
+
+// integrate returns the points of the streamline from the user point (px, py)
+// in the direction of the field (sign 1) or in the opposite direction (sign
+// -1). The streamline stops out of the area of the ranges, where the field is
+// not defined, or close to the points of the grid if evenly spaced.
+func (s *Streamlines) integrate(fr frame, g *streamGrid, px, py, sign float64, line int) []streamPoint {
+	left, bottom := fr.point(s.XR.Min, s.YR.Min)
+	right, top := fr.point(s.XR.Max, s.YR.Max)
+	inside := func(x, y float64) bool {
+		return x >= math.Min(left, right) && x <= math.Max(left, right) &&
+			y >= math.Min(bottom, top) && y <= math.Max(bottom, top) && fr.inside(x, y)
+	}
+	f := func(x, y float64) (float64, float64, bool) {
+		dx, dy, ok := s.direction(fr, x, y)
+		return sign * dx, sign * dy, ok
+	}
+	dtest := 0.5 * g.cell
+	if s.Seeds != nil {
+		dtest = 0.5 * streamlineStep
+	}
+	var points []streamPoint
+	h := float64(streamlineStep)
+	for step := 1; step <= streamlineMaxSteps; step++ {
+		k1x, k1y, ok1 := f(px, py)
+		k2x, k2y, ok2 := f(px+0.5*h*k1x, py+0.5*h*k1y)
+		k3x, k3y, ok3 := f(px+0.5*h*k2x, py+0.5*h*k2y)
+		k4x, k4y, ok4 := f(px+h*k3x, py+h*k3y)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			break
+		}
+		nx := px + h/6*(k1x+2*k2x+2*k3x+k4x)
+		ny := py + h/6*(k1y+2*k2y+2*k3y+k4y)
+		if !inside(nx, ny) {
+			break
+		}
+		// the streamline stops close to another streamline (if evenly
+		// spaced), or close to itself (closed orbit)
+		if g.near(nx, ny, dtest, line, int(sign)*step) {
+			break
+		}
+		px, py = nx, ny
+		points = append(points, streamPoint{px, py, line, int(sign) * step})
+		g.add(points[len(points)-1])
+	}
+	return points
+}
+
+// streamline returns the streamline through the user point (px, py), nil if
+// shorter than the separation distance
+func (s *Streamlines) streamline(fr frame, g *streamGrid, px, py float64, line int) []streamPoint {
+	g.add(streamPoint{px, py, line, 0})
+	backward := s.integrate(fr, g, px, py, -1, line)
+	forward := s.integrate(fr, g, px, py, 1, line)
+	points := make([]streamPoint, 0, len(backward)+1+len(forward))
+	for i := len(backward) - 1; i >= 0; i-- {
+		points = append(points, backward[i])
+	}
+	points = append(points, streamPoint{px, py, line, 0})
+	points = append(points, forward...)
+	if float64(len(points)-1)*streamlineStep < g.cell {
+		g.remove(line)
+		return nil
+	}
+	return points
+}
+
+// lines returns the streamlines (user coordinates)
+func (s *Streamlines) lines(fr frame) [][]streamPoint {
+	sep := s.Separation
+	if sep <= 0 {
+		sep = DefaultStreamlineSeparation
+	}
+	g := &streamGrid{cell: sep, cells: map[[2]int][]streamPoint{}}
+	var lines [][]streamPoint
+	addLine := func(points []streamPoint) {
+		if points != nil {
+			lines = append(lines, points)
+		}
+	}
+
+	if s.Seeds != nil {
+		for _, seed := range s.Seeds {
+			px, py := fr.point(seed.X, seed.Y)
+			if fr.inside(px, py) {
+				addLine(s.streamline(fr, g, px, py, len(lines)))
+			}
+		}
+		return lines
+	}
+
+	// candidate starts a new streamline at (px, py) if far enough from the
+	// existing streamlines
+	left, bottom := fr.point(s.XR.Min, s.YR.Min)
+	right, top := fr.point(s.XR.Max, s.YR.Max)
+	candidate := func(px, py float64) {
+		if px < math.Min(left, right) || px > math.Max(left, right) || py < math.Min(bottom, top) || py > math.Max(bottom, top) {
+			return
+		}
+		if !fr.inside(px, py) || g.near(px, py, sep, -1, 0) {
+			return
+		}
+		addLine(s.streamline(fr, g, px, py, len(lines)))
+	}
+	// the seeds beside the points of the streamlines, at the separation
+	// distance on both sides (the new streamlines are expanded in turn)
+	expanded := 0
+	expand := func() {
+		for ; expanded < len(lines); expanded++ {
+			points := lines[expanded]
+			for k := 1; k < len(points); k += 2 {
+				a, b := points[k-1], points[k]
+				dx, dy := b.x-a.x, b.y-a.y
+				if l := math.Hypot(dx, dy); l > 0 {
+					candidate(b.x-sep*dy/l, b.y+sep*dx/l)
+					candidate(b.x+sep*dy/l, b.y-sep*dx/l)
+				}
+			}
+		}
+	}
+	// the first streamline starts at the center, then the seeds of a coarse
+	// grid cover the areas not reached by the expansion
+	candidate(fr.point(0.5*(s.XR.Min+s.XR.Max), 0.5*(s.YR.Min+s.YR.Max)))
+	expand()
+	for y := math.Min(bottom, top) + 0.5*sep; y < math.Max(bottom, top); y += 2 * sep {
+		for x := math.Min(left, right) + 0.5*sep; x < math.Max(left, right); x += 2 * sep {
+			candidate(x, y)
+			expand()
+		}
+	}
+	return lines
+}
+
+func (s *Streamlines) draw(sk *svg.Sketcher, fr frame) {
+	lines := s.lines(fr)
+	r := s.magnitudes()
+	sk.Pencil.LineWidth = max(s.LineWidth, 1)
+	sk.Pencil.Dash = nil
+	sk.Pencil.LineColor = barColor(s.Color, s.colorIndex, fr.theme)
+	for _, points := range lines {
+		// the streamline is drawn in parts of the color of the magnitude at
+		// the middle of the part
+		chunk := len(points)
+		if s.Colormap != nil {
+			chunk = streamlineChunk
+		}
+		for start := 0; start < len(points)-1; start += chunk {
+			end := min(start+chunk, len(points)-1)
+			part := make([]struct{ X, Y float64 }, 0, end-start+1)
+			for _, p := range points[start : end+1] {
+				part = append(part, struct{ X, Y float64 }{p.x, p.y})
+			}
+			if s.Colormap != nil {
+				m := points[(start+end)/2]
+				x, y := fr.data(m.x, m.y)
+				sk.Pencil.LineColor = s.Colormap.Color(r.scale(s.Field.magnitude(x, y)))
+			}
+			sk.Curve(part)
+		}
+		if s.Arrows && len(points) > 2*arrowHeadLength/streamlineStep {
+			// the arrow at the middle of the streamline, in the direction
+			// of the field
+			k := len(points) / 2
+			a, b := points[k-arrowHeadLength/streamlineStep], points[k]
+			if s.Colormap != nil {
+				x, y := fr.data(b.x, b.y)
+				sk.Pencil.LineColor = s.Colormap.Color(r.scale(s.Field.magnitude(x, y)))
+			}
+			drawArrow(sk, a.x, a.y, b.x, b.y, arrowHeadLength)
+		}
+	}
+}
+
+func (s *Streamlines) legend() []legendEntry {
+	return nil
+}
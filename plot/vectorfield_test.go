@@ -0,0 +1,121 @@
+package plot
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestFigure_Quiver(t *testing.T) {
+	// the field of the holomorph function z^2 (the conjugate of its values,
+	// i.e. the Polya vector field)
+	square := func(x, y float64) (u, v float64) {
+		w := cmplx.Conj(complex(x, y) * complex(x, y))
+		return real(w), imag(w)
+	}
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Polya field of z^2"
+	q := f.Quiver(square, Range{-2, 2}, Range{-1.5, 1.5}, 16)
+	q.Colormap = Viridis
+	r, fr := render(t, f, "TestFigure_Quiver")
+
+	// An arrow (line and head) for each node of the grid, centered on the
+	// node, along the vector and at most 90% of the grid step long
+	shafts, heads := r.find("element-0", "line"), r.find("element-0", "polygon")
+	if len(shafts) != 16*16 || len(heads) != 16*16 {
+		t.Fatalf("the quiver plot has %d lines and %d heads and should have %d arrows", len(shafts), len(heads), 16*16)
+	}
+	step := (fr.right - fr.left) * 4 / (fr.x.Max - fr.x.Min) / 16
+	n := 0
+	q.nodes(func(x, y float64) {
+		tail, tip := shafts[n].points[0], heads[n].points[0]
+		n++
+		px, py := fr.point(x, y)
+		if !near(0.5*(tail.X+tip.X), px, 1e-6) || !near(0.5*(tail.Y+tip.Y), py, 1e-6) {
+			t.Errorf("the arrow of the node (%g,%g) is not centered on the node", x, y)
+		}
+		dx, dy := tip.X-tail.X, tip.Y-tail.Y
+		u, v := square(x, y)
+		ux, uy := fr.point(x+u, y+v)
+		if cross := dx*(uy-py) - dy*(ux-px); !near(cross, 0, 1e-6*math.Hypot(dx, dy)*math.Hypot(ux-px, uy-py)) || dx*(ux-px)+dy*(uy-py) < 0 {
+			t.Errorf("the arrow of the node (%g,%g) is not along the vector (%g,%g)", x, y, u, v)
+		}
+		if l := math.Hypot(dx, dy); l > 0.9*step+1e-6 {
+			t.Errorf("the arrow of the node (%g,%g) is %g pixels long and should be shorter than %g", x, y, l, 0.9*step)
+		}
+	})
+	if len(r.find("colorbar", "polygon")) != colorBarStripe+1 {
+		t.Errorf("the quiver plot should have the color bar of the magnitudes")
+	}
+}
+
+func TestFigure_Streamlines(t *testing.T) {
+	// the closed orbits of a rotation field do not loop forever
+	rotation := func(x, y float64) (u, v float64) { return -y, x }
+	f := New(DefaultWidth, DefaultHeight)
+	f.Title = "Rotation"
+	s := f.Streamlines(rotation, Range{-2, 2}, Range{-2, 2}, []struct{ X, Y float64 }{{1, 0}})
+	if err := f.Save("output.TestFigure_Streamlines.rotation.svg"); err != nil {
+		t.Fatal(err)
+	}
+	lines := s.lines(f.layout())
+	if len(lines) != 1 {
+		t.Fatalf("the rotation has %d streamlines from a seed and should have 1", len(lines))
+	}
+	fr := f.layout()
+	for _, p := range lines[0] {
+		x, y := fr.data(p.x, p.y)
+		if math.Abs(math.Hypot(x, y)-1) > 0.02 {
+			t.Errorf("the streamline should be the unit circle: the point (%g,%g) is off", x, y)
+			break
+		}
+	}
+	// the loop is closed after a single turn (at the separation of a step)
+	if n, max := len(lines[0]), int(2*math.Pi*(fr.right-fr.left)/4/streamlineStep)+10; n > max {
+		t.Errorf("the streamline of the rotation has %d points and should have less than %d", n, max)
+	}
+
+	// the evenly spaced streamlines of a dipole, colored by the magnitude
+	dipole := func(x, y float64) (u, v float64) {
+		field := func(cx, q float64) (float64, float64) {
+			dx, dy := x-cx, y
+			r3 := math.Pow(dx*dx+dy*dy, 1.5)
+			return q * dx / r3, q * dy / r3
+		}
+		u1, v1 := field(-1, 1)
+		u2, v2 := field(1, -1)
+		return u1 + u2, v1 + v2
+	}
+	magnitude := func(x, y float64) (u, v float64) {
+		u, v = dipole(x, y)
+		m := math.Hypot(u, v)
+		return u / m * math.Log1p(m), v / m * math.Log1p(m)
+	}
+	f = New(DefaultWidth, DefaultHeight)
+	f.Title = "Dipole"
+	s = f.Streamlines(magnitude, Range{-3, 3}, Range{-2, 2}, nil)
+	s.Colormap = Magma.Reversed()
+	if err := f.Save("output.TestFigure_Streamlines.dipole.svg"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the streamlines are not closer than half the separation distance
+	fr = f.layout()
+	lines = s.lines(fr)
+	if len(lines) < 10 {
+		t.Errorf("the dipole has %d streamlines and should have more", len(lines))
+	}
+	g := &streamGrid{cell: s.Separation, cells: map[[2]int][]streamPoint{}}
+	for _, line := range lines {
+		for _, p := range line {
+			g.add(p)
+		}
+	}
+	for _, line := range lines {
+		for _, p := range line {
+			if g.near(p.x, p.y, 0.5*s.Separation-1e-6, p.line, p.step) {
+				t.Fatalf("the point (%g,%g) of the streamline %d is too close to another streamline", p.x, p.y, p.line)
+			}
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ===========================================================================
+// Pen plotter path optimization
+// ===========================================================================
+
+// The strokes of a plotter pass are drawn in the order of the drawing calls,
+// which can produce a lot of pen up moves (e.g. a grid drawn line by line).
+// The optimization of a pass merges the contiguous strokes into longer
+// polylines, then reorders the strokes (and reverses some of them) to reduce
+// the pen up travel, with a nearest neighbour heuristic improved by 2-opt
+// moves. The order of the passes (the pens) is preserved.
+
+const (
+	// plotMergeTolerance is the maximal distance (mm) between the ends of two
+	// strokes that are merged
+	plotMergeTolerance = 0.01
+	// plot2OptMaxStrokes is the maximal number of strokes of a pass for the
+	// 2-opt improvement (whose cost is quadratic)
+	plot2OptMaxStrokes = 2000
+	// plot2OptMaxSweeps is the maximal number of sweeps of the 2-opt
+	// improvement
+	plot2OptMaxSweeps = 50
+	// plot2OptMinGain is the minimal relative reduction of the pen up travel
+	// by a sweep of the 2-opt improvement for another sweep to be done
+	plot2OptMinGain = 1e-3
+)
+
+// PlotterReport gives the number of strokes and the pen up travel distance of
+// a plot, before and after the optimization of the strokes
+type PlotterReport struct {
+	Strokes, OptimizedStrokes               int
+	TravelDistance, OptimizedTravelDistance float64 // mm
+}
+
+func (r PlotterReport) String() string {
+	gain := 0.
+	if r.TravelDistance > 0 {
+		gain = 100 * (1 - r.OptimizedTravelDistance/r.TravelDistance)
+	}
+	return fmt.Sprintf("strokes: %d -> %d, pen up travel: %.1f mm -> %.1f mm (-%.1f%%)",
+		r.Strokes, r.OptimizedStrokes, r.TravelDistance, r.OptimizedTravelDistance, gain)
+}
+
+// PlotterReport returns the report of the optimization of the plot of the
+// sketch with the plotter options opts (whatever the value of opts.Optimize)
+func (s Sketcher) PlotterReport(opts PlotterOptions) PlotterReport {
+	opts.Optimize = false
+	passes := s.plotterPasses(opts)
+	report := PlotterReport{TravelDistance: plotTravel(passes)}
+	for _, pass := range passes {
+		report.Strokes += len(pass.strokes)
+	}
+	optimizePasses(passes)
+	report.OptimizedTravelDistance = plotTravel(passes)
+	for _, pass := range passes {
+		report.OptimizedStrokes += len(pass.strokes)
+	}
+	return report
+}
+
+func pointDistance(p, q point) float64 {
+	return math.Hypot(q.X-p.X, q.Y-p.Y)
+}
+
+// plotTravel returns the pen up travel distance to plot the passes, starting
+// from the origin
+func plotTravel(passes []*plotPass) float64 {
+	var travel float64
+	var position point
+	for _, pass := range passes {
+		for _, stroke := range pass.strokes {
+			travel += pointDistance(position, stroke[0])
+			position = stroke[len(stroke)-1]
+		}
+	}
+	return travel
+}
+
+// optimizePasses optimizes the strokes of each pass, the start point of a pass
+// being the end point of the previous pass
+func optimizePasses(passes []*plotPass) {
+	var position point
+	for _, pass := range passes {
+		if len(pass.strokes) == 0 {
+			continue
+		}
+		pass.strokes = orderStrokes(mergeStrokes(pass.strokes), position)
+		last := pass.strokes[len(pass.strokes)-1]
+		position = last[len(last)-1]
+	}
+}
+
+func reversed(stroke []point) []point {
+	r := make([]point, len(stroke))
+	for i, p := range stroke {
+		r[len(stroke)-1-i] = p
+	}
+	return r
+}
+
+// mergeStrokes merges the strokes whose ends coincide into polylines (the
+// strokes are reversed if needed)
+func mergeStrokes(strokes [][]point) [][]point {
+	type key struct{ x, y int64 }
+	keyOf := func(p point) key {
+		return key{int64(math.Round(p.X / plotMergeTolerance)), int64(math.Round(p.Y / plotMergeTolerance))}
+	}
+	// index of the strokes by the keys of their ends
+	ends := map[key][]int{}
+	for i, s := range strokes {
+		ends[keyOf(s[0])] = append(ends[keyOf(s[0])], i)
+		ends[keyOf(s[len(s)-1])] = append(ends[keyOf(s[len(s)-1])], i)
+	}
+	used := make([]bool, len(strokes))
+	// next returns a stroke, not used yet, with an end at the point p, oriented
+	// to start at p
+	next := func(p point) ([]point, bool) {
+		for _, i := range ends[keyOf(p)] {
+			if used[i] {
+				continue
+			}
+			used[i] = true
+			s := strokes[i]
+			if keyOf(s[0]) == keyOf(p) {
+				return s, true
+			}
+			return reversed(s), true
+		}
+		return nil, false
+	}
+
+	var merged [][]point
+	for i, s := range strokes {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		polyline := append([]point(nil), s...)
+		// extension at the end, then at the beginning of the polyline
+		for {
+			n, ok := next(polyline[len(polyline)-1])
+			if !ok {
+				break
+			}
+			polyline = append(polyline, n[1:]...)
+		}
+		for {
+			n, ok := next(polyline[0])
+			if !ok {
+				break
+			}
+			polyline = append(reversed(n), polyline[1:]...)
+		}
+		merged = append(merged, polyline)
+	}
+	return merged
+}
+
+// tourStroke is a stroke of an ordered pass: the index of the stroke, drawn
+// in the reverse direction if reverse is true (the points are not copied
+// while the order is optimized)
+type tourStroke struct {
+	index   int
+	reverse bool
+}
+
+// ends returns the first and the last points of the stroke of the tour, in
+// the drawing direction
+func (t tourStroke) ends(strokes [][]point) (first, last point) {
+	s := strokes[t.index]
+	if t.reverse {
+		return s[len(s)-1], s[0]
+	}
+	return s[0], s[len(s)-1]
+}
+
+// orderStrokes reorders the strokes to reduce the pen up travel from the
+// point start: nearest neighbour ordering (a stroke can be drawn in the
+// reverse direction), then 2-opt improvement
+func orderStrokes(strokes [][]point, start point) [][]point {
+	used := make([]bool, len(strokes))
+	tour := make([]tourStroke, 0, len(strokes))
+	position := start
+	for range strokes {
+		best, reverse, dmin := -1, false, math.Inf(1)
+		for i, s := range strokes {
+			if used[i] {
+				continue
+			}
+			if d := pointDistance(position, s[0]); d < dmin {
+				best, reverse, dmin = i, false, d
+			}
+			if d := pointDistance(position, s[len(s)-1]); d < dmin {
+				best, reverse, dmin = i, true, d
+			}
+		}
+		used[best] = true
+		t := tourStroke{best, reverse}
+		tour = append(tour, t)
+		_, position = t.ends(strokes)
+	}
+	if len(tour) <= plot2OptMaxStrokes {
+		improve2Opt(strokes, tour, start)
+	}
+	ordered := make([][]point, len(tour))
+	for i, t := range tour {
+		ordered[i] = strokes[t.index]
+		if t.reverse {
+			ordered[i] = reversed(ordered[i])
+		}
+	}
+	return ordered
+}
+
+// tourTravel returns the pen up travel of the tour from the point start
+func tourTravel(strokes [][]point, tour []tourStroke, start point) float64 {
+	var travel float64
+	position := start
+	for _, t := range tour {
+		first, last := t.ends(strokes)
+		travel += pointDistance(position, first)
+		position = last
+	}
+	return travel
+}
+
+// improve2Opt improves the order of the tour of the strokes with 2-opt moves:
+// a sequence of strokes is drawn in the reverse order, each stroke being
+// reversed, when it reduces the pen up travel. The travels inside the sequence
+// are unchanged, only the travels at the ends of the sequence are modified.
+// The sweeps stop when the last one did not reduce the travel by more than
+// plot2OptMinGain.
+func improve2Opt(strokes [][]point, tour []tourStroke, start point) {
+	n := len(tour)
+	travel := tourTravel(strokes, tour, start)
+	for range plot2OptMaxSweeps {
+		gain := 0.
+		for i := 0; i < n; i++ {
+			before := start
+			if i > 0 {
+				_, before = tour[i-1].ends(strokes)
+			}
+			for k := i; k < n; k++ {
+				// travel before and after the reversal of the sequence i..k
+				// (the end of the plot is free)
+				first, _ := tour[i].ends(strokes)
+				_, last := tour[k].ends(strokes)
+				d0 := pointDistance(before, first)
+				d1 := pointDistance(before, last)
+				if k+1 < n {
+					next, _ := tour[k+1].ends(strokes)
+					d0 += pointDistance(last, next)
+					d1 += pointDistance(first, next)
+				}
+				if d1 < d0-1e-9 {
+					slices.Reverse(tour[i : k+1])
+					for j := i; j <= k; j++ {
+						tour[j].reverse = !tour[j].reverse
+					}
+					gain += d0 - d1
+				}
+			}
+		}
+		if gain <= plot2OptMinGain*travel {
+			return
+		}
+		travel -= gain
+	}
+}
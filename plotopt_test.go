@@ -0,0 +1,101 @@
+package svg
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// strokesLength returns the total length of the strokes (pen down)
+func strokesLength(strokes [][]point) float64 {
+	var l float64
+	for _, s := range strokes {
+		for i := 0; i+1 < len(s); i++ {
+			l += pointDistance(s[i], s[i+1])
+		}
+	}
+	return l
+}
+
+func TestPlotOpt_MergeStrokes(t *testing.T) {
+	// a polyline drawn as segments, some of them reversed, and an isolated
+	// segment
+	strokes := [][]point{
+		{{1, 0}, {2, 0}},
+		{{3, 1}, {2, 0}},
+		{{0, 0}, {1, 0}},
+		{{5, 5}, {6, 6}},
+		{{3, 1}, {3, 2}},
+	}
+	merged := mergeStrokes(strokes)
+	if len(merged) != 2 {
+		t.Fatalf("the number of merged strokes is %d and should be %d: %v", len(merged), 2, merged)
+	}
+	if n := len(merged[0]); n != 5 {
+		t.Errorf("the merged polyline has %d points and should have %d: %v", n, 5, merged[0])
+	}
+	if l, ref := strokesLength(merged), strokesLength(strokes); math.Abs(l-ref) > 1e-9 {
+		t.Errorf("the length of the merged strokes is %.2f and should be %.2f", l, ref)
+	}
+}
+
+func TestPlotOpt_OrderStrokes(t *testing.T) {
+	// vertical lines of a grid, all drawn from the bottom to the top, in a
+	// random order. The optimal order draws the lines from left to right,
+	// alternately from the bottom and from the top.
+	var strokes [][]point
+	for _, i := range []int{7, 2, 9, 0, 4, 1, 8, 3, 6, 5} {
+		x := float64(i)
+		strokes = append(strokes, []point{{x, 0}, {x, 10}})
+	}
+	ordered := orderStrokes(strokes, point{0, 0})
+	passes := []*plotPass{{strokes: ordered}}
+	// 9 moves of 1 mm between the lines
+	if travel := plotTravel(passes); math.Abs(travel-9) > 1e-9 {
+		t.Errorf("the pen up travel is %.2f and should be %.2f", travel, 9.)
+	}
+	if l, ref := strokesLength(ordered), strokesLength(strokes); math.Abs(l-ref) > 1e-9 {
+		t.Errorf("the length of the ordered strokes is %.2f and should be %.2f", l, ref)
+	}
+
+	// the strokes are reordered without modifying the original ones
+	for _, stroke := range strokes {
+		if stroke[0].Y != 0 {
+			t.Fatalf("the original strokes should not be modified: %v", strokes)
+		}
+	}
+
+	// The largest pass improved with 2-opt moves: random segments
+	rng := rand.New(rand.NewPCG(1, 2))
+	strokes = nil
+	for range plot2OptMaxStrokes {
+		x, y := 100*rng.Float64(), 100*rng.Float64()
+		strokes = append(strokes, []point{{x, y}, {x + rng.Float64(), y + rng.Float64()}})
+	}
+	before := plotTravel([]*plotPass{{strokes: strokes}})
+	ordered = orderStrokes(strokes, point{0, 0})
+	after := plotTravel([]*plotPass{{strokes: ordered}})
+	if len(ordered) != len(strokes) || after > before/10 {
+		t.Errorf("the pen up travel of the random segments is %.0f -> %.0f and should be divided by 10", before, after)
+	}
+}
+
+func TestPlotOpt_PlotterReport(t *testing.T) {
+	// A grid drawn line by line (each line drawn from the bottom to the top),
+	// each line being drawn as two segments
+	s := NewSketcher()
+	for i := 0; i <= 10; i++ {
+		x := float64(i) / 10
+		s.Edge(x, 0, x, 0.5)
+		s.Edge(x, 0.5, x, 1)
+	}
+	report := s.PlotterReport(DefaultPlotterOptions)
+	t.Log(report)
+	if report.Strokes != 22 || report.OptimizedStrokes != 11 {
+		t.Errorf("the number of strokes is %d -> %d and should be %d -> %d",
+			report.Strokes, report.OptimizedStrokes, 22, 11)
+	}
+	if report.OptimizedTravelDistance >= report.TravelDistance/2 {
+		t.Errorf("the optimization should at least halve the travel: %s", report)
+	}
+}
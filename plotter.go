@@ -0,0 +1,245 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ===========================================================================
+// Pen plotter backends (common part)
+// ===========================================================================
+
+// The pen plotter backends (HPGL and G-code) convert the recorded shapes into
+// strokes, i.e. polylines drawn with the pen down, in millimetres. The plotter
+// coordinates system has its origin at the bottom left corner of the canvas and
+// a y axis oriented bottom up. The filled shapes are converted into hatch
+// lines, the texts are drawn with the stroke font, and the raster images are
+// ignored. The strokes are gathered in passes, one pass per pen color, in the
+// order of the first use of the colors.
+
+const (
+	DefaultPlotterScale        = 25.4 / 96 // size of a canvas pixel (1/96 inch) in mm
+	DefaultPlotterHatchSpacing = 1.        // mm
+	DefaultPlotterHatchAngle   = 45.       // degrees
+	DefaultPlotterDrawSpeed    = 1500.     // mm/min
+)
+
+// PlotterOptions defines how the sketch is converted into pen moves
+type PlotterOptions struct {
+	Scale        float64 // size of a canvas pixel in millimetres
+	HatchSpacing float64 // distance between the hatch lines of the filled shapes (mm)
+	HatchAngle   float64 // angle of the hatch lines with the x axis (degrees)
+	DrawSpeed    float64 // feed rate of the pen down moves (mm/min, DefaultPlotterDrawSpeed if not positive)
+	Optimize     bool    // if true, the strokes are merged and reordered (see plotopt.go)
+
+	// G-code commands to lift the pen, to lower the pen, and to change the pen
+	// before each pass (e.g. "M0" to pause the program). The commands are
+	// written verbatim, and the pen change is skipped if empty.
+	PenUp, PenDown, PenChange string
+}
+
+var DefaultPlotterOptions = PlotterOptions{
+	Scale:        DefaultPlotterScale,
+	HatchSpacing: DefaultPlotterHatchSpacing,
+	HatchAngle:   DefaultPlotterHatchAngle,
+	DrawSpeed:    DefaultPlotterDrawSpeed,
+	Optimize:     true,
+	PenUp:        "G0 Z2",
+	PenDown:      "G1 Z0",
+	PenChange:    "M0",
+}
+
+// drawSpeed returns the feed rate of the pen down moves (mm/min), the default
+// one if the options do not set a positive one
+func (opts PlotterOptions) drawSpeed() float64 {
+	if opts.DrawSpeed <= 0 {
+		return DefaultPlotterDrawSpeed
+	}
+	return opts.DrawSpeed
+}
+
+// plotPass is the list of the strokes drawn with the pen of a given color
+// (the strokes are polylines in plotter coordinates, in mm)
+type plotPass struct {
+	color   string // color of the pen (as specified in the pencil)
+	strokes [][]point
+}
+
+// plotter converts the shapes into the strokes of the passes
+type plotter struct {
+	opts    PlotterOptions
+	height  float64 // height of the canvas (pixels)
+	passes  []*plotPass
+	pens    map[string]*plotPass // pass of a pen, indexed by the color key
+	current point                // current point (canvas coordinates)
+}
+
+// pass returns the pass of the pen of color colorname, and false if nothing
+// should be drawn with this color
+func (pl *plotter) pass(colorname string) (*plotPass, bool) {
+	c, err := ParseColor(colorname)
+	if err != nil || c.A == 0 {
+		return nil, false
+	}
+	key := fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+	pass, ok := pl.pens[key]
+	if !ok {
+		pass = &plotPass{color: colorname}
+		pl.pens[key] = pass
+		pl.passes = append(pl.passes, pass)
+	}
+	return pass, true
+}
+
+// add adds the polyline (canvas coordinates) to the strokes of the pen of
+// color colorname
+func (pl *plotter) add(colorname string, points []point) {
+	if len(points) < 2 {
+		return
+	}
+	if !finitePoints(points...) {
+		return
+	}
+	pass, ok := pl.pass(colorname)
+	if !ok {
+		return
+	}
+	stroke := make([]point, len(points))
+	for i, p := range points {
+		stroke[i] = point{p.X * pl.opts.Scale, (pl.height - p.Y) * pl.opts.Scale}
+	}
+	pass.strokes = append(pass.strokes, stroke)
+}
+
+// outline adds the strokes of the polyline drawn with the pencil p
+func (pl *plotter) outline(points []point, p Pencil, closed bool) {
+	if p.LineWidth <= 0 {
+		return
+	}
+	for _, d := range dashPolyline(points, p.Dash, closed) {
+		pl.add(p.LineColor, d)
+	}
+}
+
+// hatch adds the hatch lines that fill the polygon
+func (pl *plotter) hatch(points []point, colorname string) {
+	if pl.opts.HatchSpacing <= 0 {
+		return
+	}
+	// The spacing is given in mm, the polygon in pixels. The hatch angle is
+	// given in the plotter coordinates system, whose y axis is reversed.
+	spacing := pl.opts.HatchSpacing / pl.opts.Scale
+	for _, line := range hatchLines(points, spacing, -pl.opts.HatchAngle) {
+		pl.add(colorname, line)
+	}
+}
+
+// fillAndOutline adds the hatch lines (if fill is true) and the outline of the
+// polygon
+func (pl *plotter) fillAndOutline(points []point, fill bool, p Pencil) {
+	if fill {
+		pl.hatch(points, p.FillColor)
+	}
+	pl.outline(points, p, true)
+}
+
+func (pl *plotter) Begin(width, height int, background string) {
+	pl.height = float64(height)
+}
+
+func (pl *plotter) End() {}
+
+func (pl *plotter) MoveTo(x, y float64) {
+	pl.current = point{x, y}
+}
+
+func (pl *plotter) LineTo(x, y float64, p Pencil) {
+	pl.outline([]point{pl.current, {x, y}}, p, false)
+	pl.current = point{x, y}
+}
+
+func (pl *plotter) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	if closed {
+		pl.fillAndOutline(points, fill, p)
+	} else {
+		pl.outline(points, p, false)
+	}
+}
+
+func (pl *plotter) Circle(cx, cy, r float64, fill bool, p Pencil) {
+	pl.fillAndOutline(circlePoints(point{cx, cy}, r), fill, p)
+}
+
+func (pl *plotter) Arc(cx, cy, r, startAngle, sweepAngle float64, p Pencil) {
+	pl.outline(arcPoints(cx, cy, r, startAngle, sweepAngle), p, false)
+}
+
+func (pl *plotter) Text(x, y float64, text string, p Pencil) {
+	for _, stroke := range textStrokes(text, x, y, float64(p.FontSize)) {
+		pl.add(p.FontColor, stroke)
+	}
+}
+
+// Image is ignored (a pen plotter can not draw raster images)
+func (pl *plotter) Image(x, y, width, height float64, href string, style ImageStyle) {}
+
+func (pl *plotter) BeginGroup(name string) {}
+func (pl *plotter) EndGroup()              {}
+
+// plotterPasses returns the passes (one per pen color) to plot the sketch
+func (s Sketcher) plotterPasses(opts PlotterOptions) []*plotPass {
+	if opts.Scale <= 0 {
+		opts.Scale = DefaultPlotterScale
+	}
+	pl := &plotter{opts: opts, pens: map[string]*plotPass{}}
+	s.Render(pl)
+	if opts.Optimize {
+		optimizePasses(pl.passes)
+	}
+	return pl.passes
+}
+
+// hatchLines returns the segments of the parallel lines, spaced by spacing and
+// making the angle angle (degrees) with the x axis, that fill the polygon (even
+// odd rule). The lines are returned in a zigzag order to limit the moves
+// between two lines.
+func hatchLines(polygon []point, spacing, angle float64) [][]point {
+	if len(polygon) < 3 || spacing <= 0 {
+		return nil
+	}
+	// The polygon is rotated so that the hatch lines are horizontal
+	a := angle * math.Pi / 180
+	cos, sin := math.Cos(a), math.Sin(a)
+	rotated := make([]point, len(polygon))
+	ymin, ymax := math.Inf(1), math.Inf(-1)
+	for i, p := range polygon {
+		q := point{p.X*cos + p.Y*sin, -p.X*sin + p.Y*cos}
+		rotated[i] = q
+		ymin, ymax = math.Min(ymin, q.Y), math.Max(ymax, q.Y)
+	}
+	var lines [][]point
+	reverse := false
+	for y := ymin + spacing/2; y < ymax; y += spacing {
+		var xs []float64
+		for i, p := range rotated {
+			q := rotated[(i+1)%len(rotated)]
+			if (p.Y <= y) != (q.Y <= y) {
+				xs = append(xs, p.X+(y-p.Y)*(q.X-p.X)/(q.Y-p.Y))
+			}
+		}
+		sort.Float64s(xs)
+		if reverse {
+			sort.Sort(sort.Reverse(sort.Float64Slice(xs)))
+		}
+		for i := 0; i+1 < len(xs); i += 2 {
+			line := make([]point, 2)
+			for j, x := range xs[i : i+2] {
+				line[j] = point{x*cos - y*sin, x*sin + y*cos}
+			}
+			lines = append(lines, line)
+		}
+		reverse = !reverse
+	}
+	return lines
+}
@@ -0,0 +1,130 @@
+package svg
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPlotter_HatchLines(t *testing.T) {
+	square := []point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	lines := hatchLines(square, 1, 0)
+	if len(lines) != 10 {
+		t.Fatalf("the number of hatch lines is %d and should be %d", len(lines), 10)
+	}
+	// The lines are drawn in zigzag
+	if lines[0][0].X != 0 || lines[0][1].X != 10 || lines[1][0].X != 10 || lines[1][1].X != 0 {
+		t.Errorf("the hatch lines should be in zigzag order: %v", lines[:2])
+	}
+	if lines[0][0].Y != 0.5 {
+		t.Errorf("the first hatch line is at y=%.2f and should be at y=%.2f", lines[0][0].Y, 0.5)
+	}
+
+	// With an angle of 45 degrees, the hatch lines of the square are parallel
+	// to a diagonal
+	for _, l := range hatchLines(square, 1, 45) {
+		dx, dy := l[1].X-l[0].X, l[1].Y-l[0].Y
+		if math.Abs(math.Abs(dx)-math.Abs(dy)) > 1e-9 || dx*dy < 0 {
+			t.Errorf("the hatch line %v should make an angle of 45 degrees", l)
+		}
+	}
+
+	// A ring (even odd rule): the hole is not hatched
+	ring := []point{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}, {3, 3}, {3, 7}, {7, 7}, {7, 3}, {3, 3}}
+	for _, l := range hatchLines(ring, 1, 0) {
+		for _, p := range l {
+			if p.X > 3 && p.X < 7 && p.Y > 3 && p.Y < 7 {
+				t.Errorf("the hatch line %v should not enter the hole", l)
+			}
+		}
+	}
+}
+
+func plottersketch() *Sketcher {
+	cs := NewCoordSysBottomLeft(400, 400, 100)
+	s := NewSketcher().WithCoordinateSystem(cs)
+	s.Edge(0, 0, 100, 100)
+	s.Pencil.LineColor = "red"
+	s.Pencil.FillColor = "blue"
+	s.Rectangle(10, 10, 20, 20, true)
+	s.Pencil.LineColor = "#f00"
+	s.Edge(50, 0, 50, 10)
+	return s
+}
+
+func TestPlotter_Passes(t *testing.T) {
+	s := plottersketch()
+	passes := s.plotterPasses(DefaultPlotterOptions)
+	// the fill of the rectangle is drawn before its outline
+	colors := []string{"black", "blue", "red"}
+	if len(passes) != len(colors) {
+		t.Fatalf("the number of passes is %d and should be %d", len(passes), len(colors))
+	}
+	for i, pass := range passes {
+		if pass.color != colors[i] {
+			t.Errorf("the color of the pass %d is %s and should be %s", i, pass.color, colors[i])
+		}
+	}
+	// the red and #f00 strokes are drawn with the same pen
+	if n := len(passes[2].strokes); n != 2 {
+		t.Errorf("the number of red strokes is %d and should be %d", n, 2)
+	}
+	// the first stroke is the diagonal from the bottom left corner of the
+	// canvas (400 pixels = 105.83 mm)
+	stroke := passes[0].strokes[0]
+	if stroke[0].X != 0 || stroke[0].Y != 0 || math.Abs(stroke[1].Y-105.833) > 1e-3 {
+		t.Errorf("the diagonal stroke is %v", stroke)
+	}
+}
+
+func TestPlotter_ToHPGL(t *testing.T) {
+	s := plottersketch()
+	s.SaveHPGL("output.TestPlotter_ToHPGL.hpgl", DefaultPlotterOptions)
+	res := s.ToHPGL(DefaultPlotterOptions)
+	refs := []string{
+		"IN;\nVS2.5;\nSP1;\nPU0,0;\nPD4233,4233;\nPU;\nSP2;\n",
+		"SP3;\nPU423,423;\nPD1270,423,1270,1270,423,1270,423,423;\n",
+		"PU;\nSP0;\n",
+	}
+	for _, ref := range refs {
+		if !strings.Contains(res, ref) {
+			t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+		}
+	}
+}
+
+func TestPlotter_ToGCode(t *testing.T) {
+	s := plottersketch()
+	opts := DefaultPlotterOptions
+	opts.PenUp = "M5"
+	opts.PenDown = "M3 S90"
+	s.SaveGCode("output.TestPlotter_ToGCode.gcode", opts)
+	res := s.ToGCode(opts)
+	refs := []string{
+		"G21 ; millimetres\nG90 ; absolute coordinates\nF1500 ; feed rate (mm/min)\nM5\n",
+		"; pen 1: black\nM0\nG0 X0 Y0\nM3 S90\nG1 X105.833 Y105.833\nM5\n",
+		"; pen 2: blue\nM0\n",
+		"; pen 3: red\nM0\n",
+		"G0 X0 Y0\nM2\n",
+	}
+	for _, ref := range refs {
+		if !strings.Contains(res, ref) {
+			t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+		}
+	}
+	// The feed rate is set before the first pen down move, never on the
+	// rapid moves
+	for _, line := range strings.Split(res, "\n") {
+		if strings.HasPrefix(line, "G0") && strings.Contains(line, " F") {
+			t.Errorf("the rapid move %q should not set the feed rate", line)
+		}
+	}
+	if f, down := strings.Index(res, "F1500"), strings.Index(res, "M3 S90"); f < 0 || f > down {
+		t.Errorf("the feed rate should be set before the first pen down move")
+	}
+	// The feed rate is always written, the linear moves requiring one
+	opts.DrawSpeed = 0
+	if res := s.ToGCode(opts); !strings.Contains(res, "F1500 ; feed rate (mm/min)\n") {
+		t.Errorf("result is:\n%s\nShould contain the default feed rate", res)
+	}
+}
@@ -0,0 +1,499 @@
+package svg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"  // register the GIF decoder for the embedded images
+	_ "image/jpeg" // register the JPEG decoder for the embedded images
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ===========================================================================
+// Raster backend (PNG)
+// ===========================================================================
+
+// The raster backend renders the recorded shapes into an RGBA image, with
+// anti-aliasing. The shapes are converted into polygons (the strokes are
+// converted into the polygons of their outline), and each polygon is
+// rasterized with an exact area coverage algorithm (signed area accumulation,
+// as in the font-rs rasterizer by R. Levien).
+
+const (
+	minCoverage    = 1. / 512 // below this coverage, the pixel is not painted
+	circleStepSize = 2.       // approximative length of the circle segments (pixels)
+)
+
+type rasterizer struct {
+	w, h   int
+	stride int       // length of an accumulation row (w + 2)
+	acc    []float32 // accumulation buffer
+	// bounding box of the accumulation cells modified since the last fill
+	minx, miny, maxx, maxy int
+}
+
+func newRasterizer(w, h int) *rasterizer {
+	r := &rasterizer{w: w, h: h, stride: w + 2}
+	r.acc = make([]float32, r.stride*h)
+	r.resetBounds()
+	return r
+}
+
+func (r *rasterizer) resetBounds() {
+	r.minx, r.miny = r.stride, r.h
+	r.maxx, r.maxy = -1, -1
+}
+
+func isFinitePoint(p point) bool {
+	return !math.IsNaN(p.X) && !math.IsNaN(p.Y) && !math.IsInf(p.X, 0) && !math.IsInf(p.Y, 0)
+}
+
+// polygon adds the closed polygon defined by the points to the accumulation
+// buffer
+func (r *rasterizer) polygon(points []point) {
+	n := len(points)
+	for i := range n {
+		r.edge(points[i], points[(i+1)%n])
+	}
+}
+
+// edge adds the edge p0-p1 to the accumulation buffer. The parts of the edge
+// that are at the left (right) of the canvas are projected on the left (right)
+// boundary, which preserves the coverage of the visible pixels.
+func (r *rasterizer) edge(p0, p1 point) {
+	if !isFinitePoint(p0) || !isFinitePoint(p1) {
+		return
+	}
+	w := float64(r.w)
+	for _, bx := range []float64{0, w} {
+		if (p0.X < bx && p1.X > bx) || (p0.X > bx && p1.X < bx) {
+			t := (bx - p0.X) / (p1.X - p0.X)
+			m := point{bx, p0.Y + t*(p1.Y-p0.Y)}
+			r.edge(p0, m)
+			r.edge(m, p1)
+			return
+		}
+	}
+	p0.X = min(max(p0.X, 0), w)
+	p1.X = min(max(p1.X, 0), w)
+	r.line(p0, p1)
+}
+
+// line accumulates the signed area covered by the line p0-p1 (whose x
+// coordinates are inside the canvas) in the cells of the accumulation buffer
+func (r *rasterizer) line(p0, p1 point) {
+	if p0.Y == p1.Y {
+		return
+	}
+	dir := float32(1)
+	if p0.Y > p1.Y {
+		dir = -1
+		p0, p1 = p1, p0
+	}
+	dxdy := (p1.X - p0.X) / (p1.Y - p0.Y)
+	x := p0.X
+	if p0.Y < 0 {
+		x -= p0.Y * dxdy
+	}
+	w := float64(r.w)
+	ystart := int(math.Max(0, math.Floor(p0.Y)))
+	yend := int(math.Min(float64(r.h), math.Ceil(p1.Y)))
+	for y := ystart; y < yend; y++ {
+		fy := float64(y)
+		dy := math.Min(fy+1, p1.Y) - math.Max(fy, p0.Y)
+		xnext := x + dxdy*dy
+		d := float32(dy) * dir
+		x0, x1 := x, xnext
+		if x0 > x1 {
+			x0, x1 = x1, x0
+		}
+		x0 = min(max(x0, 0), w)
+		x1 = min(max(x1, 0), w)
+		x0floor := math.Floor(x0)
+		x0i := int(x0floor)
+		x1ceil := math.Ceil(x1)
+		x1i := int(x1ceil)
+		row := y * r.stride
+		if x1i <= x0i+1 {
+			xmf := float32(0.5*(x+xnext) - x0floor)
+			xmf = min(max(xmf, 0), 1)
+			r.acc[row+x0i] += d - d*xmf
+			r.acc[row+x0i+1] += d * xmf
+			x1i = x0i + 1
+		} else {
+			s := float32(1 / (x1 - x0))
+			x0f := float32(x0 - x0floor)
+			a0 := 0.5 * s * (1 - x0f) * (1 - x0f)
+			x1f := float32(x1 - x1ceil + 1)
+			am := 0.5 * s * x1f * x1f
+			r.acc[row+x0i] += d * a0
+			if x1i == x0i+2 {
+				r.acc[row+x0i+1] += d * (1 - a0 - am)
+			} else {
+				a1 := s * (1.5 - x0f)
+				r.acc[row+x0i+1] += d * (a1 - a0)
+				for xi := x0i + 2; xi < x1i-1; xi++ {
+					r.acc[row+xi] += d * s
+				}
+				a2 := a1 + float32(x1i-x0i-3)*s
+				r.acc[row+x1i-1] += d * (1 - a2 - am)
+			}
+			r.acc[row+x1i] += d * am
+		}
+		r.minx = min(r.minx, x0i)
+		r.maxx = max(r.maxx, x1i)
+		x = xnext
+	}
+	if ystart < yend {
+		r.miny = min(r.miny, ystart)
+		r.maxy = max(r.maxy, yend-1)
+	}
+}
+
+// fill paints the pixels of the image covered by the accumulated polygons with
+// the color c, and then clears the accumulation buffer. The coverage of a
+// pixel is the absolute value of the accumulated area, clamped to 1, which
+// corresponds to the nonzero fill rule when the polygons have the same
+// orientation.
+func (r *rasterizer) fill(img *image.RGBA, c color.RGBA) {
+	for y := r.miny; y <= r.maxy; y++ {
+		var acc float32
+		row := y * r.stride
+		xend := min(r.maxx+1, r.stride-1)
+		for x := r.minx; x <= xend; x++ {
+			acc += r.acc[row+x]
+			r.acc[row+x] = 0
+			coverage := acc
+			if coverage < 0 {
+				coverage = -coverage
+			}
+			if coverage > 1 {
+				coverage = 1
+			}
+			if coverage > minCoverage && x < r.w && c.A > 0 {
+				blend(img, x, y, c, coverage)
+			}
+		}
+	}
+	r.resetBounds()
+}
+
+// blend paints the color c (alpha premultiplied) over the pixel (x, y) of the
+// image with the given coverage
+func blend(img *image.RGBA, x, y int, c color.RGBA, coverage float32) {
+	i := img.PixOffset(x, y)
+	pix := img.Pix[i : i+4 : i+4]
+	sa := float32(c.A) * coverage
+	k := 1 - sa/255
+	pix[0] = uint8(float32(c.R)*coverage + float32(pix[0])*k + 0.5)
+	pix[1] = uint8(float32(c.G)*coverage + float32(pix[1])*k + 0.5)
+	pix[2] = uint8(float32(c.B)*coverage + float32(pix[2])*k + 0.5)
+	pix[3] = uint8(sa + float32(pix[3])*k + 0.5)
+}
+
+// --------------------------------------------------------------------
+// Conversion of the shapes into polygons
+
+// signedArea returns the signed area of the polygon (positive if the polygon
+// is clockwise in the canvas coordinates system)
+func signedArea(points []point) float64 {
+	var a float64
+	n := len(points)
+	for i := range n {
+		p, q := points[i], points[(i+1)%n]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return 0.5 * a
+}
+
+// oriented returns the points of the polygon in the clockwise order (canvas
+// coordinates system) if cw is true, in the counter clockwise order otherwise
+func oriented(points []point, cw bool) []point {
+	if (signedArea(points) >= 0) == cw {
+		return points
+	}
+	reversed := make([]point, len(points))
+	for i, p := range points {
+		reversed[len(points)-1-i] = p
+	}
+	return reversed
+}
+
+// circlePoints returns the polygon approximating the circle of center c and
+// radius radius
+func circlePoints(c point, radius float64) []point {
+	n := int(2 * math.Pi * radius / circleStepSize)
+	n = min(max(n, 12), 720)
+	points := make([]point, n)
+	for i := range n {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		points[i] = point{c.X + radius*math.Cos(a), c.Y + radius*math.Sin(a)}
+	}
+	return points
+}
+
+// segmentOutline returns the outline (a rectangle) of the segment p-q drawn
+// with a line of width width
+func segmentOutline(p, q point, width float64) []point {
+	dx, dy := q.X-p.X, q.Y-p.Y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return nil
+	}
+	nx, ny := -dy/l*width/2, dx/l*width/2
+	return []point{
+		{p.X + nx, p.Y + ny}, {q.X + nx, q.Y + ny},
+		{q.X - nx, q.Y - ny}, {p.X - nx, p.Y - ny},
+	}
+}
+
+// strokePolyline adds the outline of the polyline drawn with a line of width
+// width to the rasterizer. The joins between the segments are rounded.
+func (r *rasterizer) strokePolyline(points []point, width float64, closed bool) {
+	n := len(points)
+	if closed && n > 2 {
+		points = append(points[:n:n], points[0])
+	}
+	for i := 0; i+1 < len(points); i++ {
+		if outline := segmentOutline(points[i], points[i+1], width); outline != nil {
+			r.polygon(oriented(outline, true))
+		}
+		if (i > 0 || closed) && width > 1 {
+			r.polygon(oriented(circlePoints(points[i], width/2), true))
+		}
+	}
+	if n == 1 || (n == 2 && points[0] == points[1]) {
+		r.polygon(oriented(circlePoints(points[0], width/2), true))
+	}
+}
+
+// --------------------------------------------------------------------
+// Rendering of the shapes
+
+// rasterColor returns the color used to paint with the color name, and false
+// if nothing should be painted (transparent or unknown color)
+func rasterColor(colorname string) (color.RGBA, bool) {
+	c, err := ParseColor(colorname)
+	if err != nil || c.A == 0 {
+		return c, false
+	}
+	return c, true
+}
+
+// textLineWidth returns the width of the strokes used to draw the texts with
+// the stroke font
+func textLineWidth(p Pencil) float64 {
+	width := math.Max(1, float64(p.FontSize)/12)
+	if p.FontWeight == "bold" || p.FontWeight == "bolder" {
+		width *= 1.6
+	}
+	return width
+}
+
+type rasterRenderer struct {
+	r        *rasterizer
+	img      *image.RGBA
+	scale    float64
+	minWidth float64 // minimal width of the lines (pixels of the image)
+	current  point   // current point (canvas coordinates)
+}
+
+// lineWidth returns the width in the image of a line of width width in the
+// canvas (0 means no line)
+func (rr *rasterRenderer) lineWidth(width float64) float64 {
+	if width <= 0 {
+		return 0
+	}
+	return math.Max(width*rr.scale, rr.minWidth)
+}
+
+func (rr *rasterRenderer) scaled(points []point) []point {
+	spoints := make([]point, len(points))
+	for i, p := range points {
+		spoints[i] = point{p.X * rr.scale, p.Y * rr.scale}
+	}
+	return spoints
+}
+
+func (rr *rasterRenderer) fillPolygon(points []point, colorname string) {
+	if c, ok := rasterColor(colorname); ok && len(points) > 2 {
+		rr.r.polygon(points)
+		rr.r.fill(rr.img, c)
+	}
+}
+
+func (rr *rasterRenderer) strokePolyline(points []point, p Pencil, closed bool) {
+	width := rr.lineWidth(float64(p.LineWidth))
+	c, ok := rasterColor(p.LineColor)
+	if !ok || width <= 0 || len(points) == 0 {
+		return
+	}
+	if len(p.Dash) == 0 {
+		rr.r.strokePolyline(points, width, closed)
+	} else {
+		dash := make([]float64, len(p.Dash))
+		for i, l := range p.Dash {
+			dash[i] = l * rr.scale
+		}
+		for _, d := range dashPolyline(points, dash, closed) {
+			rr.r.strokePolyline(d, width, false)
+		}
+	}
+	rr.r.fill(rr.img, c)
+}
+
+func (rr *rasterRenderer) Begin(width, height int, background string) {
+	w := int(math.Round(float64(width) * rr.scale))
+	h := int(math.Round(float64(height) * rr.scale))
+	rr.img = image.NewRGBA(image.Rect(0, 0, w, h))
+	rr.r = newRasterizer(w, h)
+	if c, ok := rasterColor(background); ok {
+		for i := 0; i < len(rr.img.Pix); i += 4 {
+			rr.img.Pix[i], rr.img.Pix[i+1], rr.img.Pix[i+2], rr.img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+	}
+}
+
+func (rr *rasterRenderer) End() {}
+
+func (rr *rasterRenderer) MoveTo(x, y float64) {
+	rr.current = point{x, y}
+}
+
+func (rr *rasterRenderer) LineTo(x, y float64, p Pencil) {
+	rr.strokePolyline(rr.scaled([]point{rr.current, {x, y}}), p, false)
+	rr.current = point{x, y}
+}
+
+func (rr *rasterRenderer) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	spoints := rr.scaled(points)
+	if closed && fill {
+		rr.fillPolygon(spoints, p.FillColor)
+	}
+	rr.strokePolyline(spoints, p, closed)
+}
+
+func (rr *rasterRenderer) Circle(cx, cy, r float64, fill bool, p Pencil) {
+	c := point{cx * rr.scale, cy * rr.scale}
+	radius := r * rr.scale
+	width := rr.lineWidth(float64(p.LineWidth))
+	if fill {
+		rr.fillPolygon(circlePoints(c, radius), p.FillColor)
+	}
+	if len(p.Dash) > 0 {
+		rr.strokePolyline(circlePoints(c, radius), p, true)
+	} else if col, ok := rasterColor(p.LineColor); ok && width > 0 {
+		// the stroke is a ring: outer circle and inner circle with the
+		// opposite orientation
+		rr.r.polygon(oriented(circlePoints(c, radius+width/2), true))
+		if inner := radius - width/2; inner > 0 {
+			rr.r.polygon(oriented(circlePoints(c, inner), false))
+		}
+		rr.r.fill(rr.img, col)
+	}
+}
+
+func (rr *rasterRenderer) Arc(cx, cy, r, startAngle, sweepAngle float64, p Pencil) {
+	rr.strokePolyline(rr.scaled(arcPoints(cx, cy, r, startAngle, sweepAngle)), p, false)
+}
+
+func (rr *rasterRenderer) Text(x, y float64, text string, p Pencil) {
+	col, ok := rasterColor(p.FontColor)
+	if !ok {
+		return
+	}
+	fontsize := float64(p.FontSize) * rr.scale
+	width := rr.lineWidth(textLineWidth(p))
+	for _, stroke := range textStrokes(text, x*rr.scale, y*rr.scale, fontsize) {
+		rr.r.strokePolyline(stroke, width, false)
+	}
+	rr.r.fill(rr.img, col)
+}
+
+func (rr *rasterRenderer) BeginGroup(name string) {}
+func (rr *rasterRenderer) EndGroup()              {}
+
+// loadImage returns the decoded image referenced by the href (data URI or
+// path of an image file)
+func loadImage(href string) (image.Image, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(href, "data:") {
+		data, _, err = decodeDataURI(href)
+	} else {
+		data, err = os.ReadFile(href)
+	}
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Image draws the image in the raster image (nearest neighbor sampling)
+func (rr *rasterRenderer) Image(x, y, width, height float64, href string, style ImageStyle) {
+	src, err := loadImage(href)
+	if err != nil {
+		return
+	}
+	b := src.Bounds()
+	x0, y0, w, h := imageRectangle(x, y, width, height, style, b.Dx(), b.Dy())
+	x0, y0, w, h = x0*rr.scale, y0*rr.scale, w*rr.scale, h*rr.scale
+	sx, sy := w/float64(b.Dx()), h/float64(b.Dy())
+	opacity := float32(min(max(style.Opacity, 0), 1))
+	bounds := rr.img.Bounds()
+	ystart, yend := max(int(math.Floor(y0)), bounds.Min.Y), min(int(math.Ceil(y0+h)), bounds.Max.Y)
+	xstart, xend := max(int(math.Floor(x0)), bounds.Min.X), min(int(math.Ceil(x0+w)), bounds.Max.X)
+	for y := ystart; y < yend; y++ {
+		j := b.Min.Y + int((float64(y)+0.5-y0)/sy)
+		if j < b.Min.Y || j >= b.Max.Y {
+			continue
+		}
+		for x := xstart; x < xend; x++ {
+			i := b.Min.X + int((float64(x)+0.5-x0)/sx)
+			if i < b.Min.X || i >= b.Max.X {
+				continue
+			}
+			c := color.RGBAModel.Convert(src.At(i, j)).(color.RGBA)
+			blend(rr.img, x, y, c, opacity)
+		}
+	}
+}
+
+// --------------------------------------------------------------------
+// Sketch export functions
+
+// ToImage renders the sketch as a raster image. The size of the image in
+// pixels is the size of the canvas multiplied by the scale factor.
+func (s Sketcher) ToImage(scale float64) *image.RGBA {
+	return s.rasterize(scale, 0)
+}
+
+// rasterize draws the sketch in an image whose size is the size of the canvas
+// multiplied by the scale factor. The lines are drawn with a width of at least
+// minWidth pixels (e.g. to keep the lines visible in a small preview).
+func (s Sketcher) rasterize(scale, minWidth float64) *image.RGBA {
+	rr := &rasterRenderer{scale: scale, minWidth: minWidth}
+	s.Render(rr)
+	return rr.img
+}
+
+// WritePNG writes the PNG image of the sketch (see ToImage) to the writer w
+func (s Sketcher) WritePNG(w io.Writer, scale float64) error {
+	return png.Encode(w, s.ToImage(scale))
+}
+
+// SavePNG saves the sketch as a PNG image file. The size of the image in pixels
+// is the size of the canvas multiplied by the scale factor.
+func (s Sketcher) SavePNG(pngpath string, scale float64) error {
+	file, err := os.OpenFile(pngpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.WritePNG(file, scale)
+}
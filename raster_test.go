@@ -0,0 +1,92 @@
+package svg
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestRaster_Polygon(t *testing.T) {
+	s := NewSketcher().WithBackgroundColor("white")
+	s.Pencil.FillColor = "red"
+	s.Pencil.LineColor = "blue"
+	s.Pencil.LineWidth = 4
+	s.Rectangle(0.25, 0.25, 0.5, 0.5, true)
+	img := s.ToImage(1)
+
+	if b := img.Bounds(); b.Dx() != DefaultCanvasWidth || b.Dy() != DefaultCanvasHeight {
+		t.Fatalf("image size is %dx%d (should be %dx%d)", b.Dx(), b.Dy(), DefaultCanvasWidth, DefaultCanvasHeight)
+	}
+	checks := []struct {
+		x, y int
+		c    color.RGBA
+	}{
+		{300, 300, color.RGBA{255, 0, 0, 255}}, // inside the rectangle
+		{10, 10, color.RGBA{255, 255, 255, 255}},
+		{150, 300, color.RGBA{0, 0, 255, 255}}, // on the left edge
+		{300, 449, color.RGBA{0, 0, 255, 255}}, // on the bottom edge
+	}
+	for _, c := range checks {
+		if res := img.RGBAAt(c.x, c.y); res != c.c {
+			t.Errorf("color at (%d,%d) is %v (should be %v)", c.x, c.y, res, c.c)
+		}
+	}
+}
+
+func TestRaster_Circle(t *testing.T) {
+	s := NewSketcher()
+	s.Pencil.LineWidth = 2
+	s.Circle(0.5, 0.5, 0.25, false)
+	img := s.ToImage(2)
+
+	// The circle is not filled: the center is transparent and the circle
+	// line (radius = 150px x 2) is black
+	if res := img.RGBAAt(600, 600); res.A != 0 {
+		t.Errorf("the center of the circle should be transparent, not %v", res)
+	}
+	if res := img.RGBAAt(900, 600); res.A < 200 || res.R > 50 {
+		t.Errorf("the circle line should be black, not %v", res)
+	}
+}
+
+func TestRaster_Text(t *testing.T) {
+	s := NewSketcher().WithBackgroundColor("white")
+	s.Pencil.FontSize = 40
+	s.Text(0.1, 0.5, "Hello, éèç!")
+	img := s.ToImage(1)
+
+	var inked int
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			if img.RGBAAt(x, y).R < 128 {
+				inked++
+			}
+		}
+	}
+	if inked == 0 {
+		t.Errorf("the text should be drawn")
+	}
+	s.SavePNG("output.TestRaster_Text.png", 1)
+}
+
+func TestRaster_SavePNG(t *testing.T) {
+	s := NewSketcher().WithTheme(LightTheme)
+	x0, y0 := 0.1, 0.2
+	w, h := 0.4, 0.5
+	s.Triangle(x0, y0, x0+w*0.5, y0+h, x0+w, y0, false)
+	s.Text(x0, y0-0.05, "Triangle Vide")
+	s.Pencil.LineColor = "red"
+	s.Pencil.FillColor = "rgba(0,0,255,0.5)"
+	s.Quadrangle(0.5, 0.1, 0.74, 0.2, 0.78, 0.5, 0.5, 0.6, true)
+	s.Pencil.LineColor = "green"
+	s.Polyline(testpoints(), true)
+	s.Image(0.6, 0.7, 0.3, 0.2, testimage(30, 20))
+	s.Pencil.FillColor = "orange"
+	for _, p := range testpoints() {
+		s.Point(p.X, p.Y)
+	}
+	if err := s.SavePNG("output.TestRaster_SavePNG.png", 2); err != nil {
+		t.Error(err)
+	}
+	s.Save("output.TestRaster_SavePNG.svg")
+}
@@ -0,0 +1,80 @@
+package svg
+
+// ===========================================================================
+// Renderers (drawing backends)
+// ===========================================================================
+
+// Renderer is the interface of the drawing backends (SVG, PNG, PDF, etc.). The
+// display list of a sketch is replayed into a renderer with Sketcher.Render.
+// The coordinates are resolved in the canvas coordinates system (pixels, y
+// axis oriented top down), and the style of each shape is given by a copy of
+// the pencil used to draw the shape.
+type Renderer interface {
+	// Begin starts the drawing of a canvas of size width x height pixels,
+	// whose background color is background (Transparent for no background)
+	Begin(width, height int, background string)
+	// End ends the drawing
+	End()
+
+	// MoveTo moves the current point to (x, y)
+	MoveTo(x, y float64)
+	// LineTo draws a straight line from the current point to (x, y), that
+	// becomes the current point
+	LineTo(x, y float64, p Pencil)
+	// Path draws the polyline defined by the points. If closed is true, the
+	// polyline is a polygon, filled with the fill color if fill is true.
+	Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil)
+	// Circle draws the circle of center (cx, cy) and radius r
+	Circle(cx, cy, r float64, fill bool, p Pencil)
+	// Arc draws the arc of the circle of center (cx, cy) and radius r, from
+	// the angle startAngle over the angle sweepAngle (degrees). The angles are
+	// measured from the x axis towards the y axis of the canvas, i.e.
+	// clockwise on the screen (a negative sweep angle goes counterclockwise).
+	Arc(cx, cy, r, startAngle, sweepAngle float64, p Pencil)
+	// Text draws the text whose baseline starts at (x, y)
+	Text(x, y float64, text string, p Pencil)
+	// Image draws the image referenced by href (data URI or path of an image
+	// file) in the rectangle whose top left corner is (x, y)
+	Image(x, y, width, height float64, href string, style ImageStyle)
+
+	// BeginGroup opens a group of shapes named name, and EndGroup closes the
+	// last opened group
+	BeginGroup(name string)
+	EndGroup()
+}
+
+// Render replays the display list of the sketch into the renderer r. The
+// groups that are still open at the end of the sketch are closed.
+func (s Sketcher) Render(r Renderer) {
+	r.Begin(s.cs.cnvxsize, s.cs.cnvysize, s.backgroundColor)
+	opened := 0
+	for _, sh := range s.shapes {
+		switch sh.kind {
+		case shapeLine:
+			r.MoveTo(sh.points[0].X, sh.points[0].Y)
+			r.LineTo(sh.points[1].X, sh.points[1].Y, sh.pencil)
+		case shapePolygon:
+			r.Path(sh.points, true, sh.fill, sh.pencil)
+		case shapePolyline:
+			r.Path(sh.points, false, false, sh.pencil)
+		case shapeCircle:
+			r.Circle(sh.points[0].X, sh.points[0].Y, sh.radius, sh.fill, sh.pencil)
+		case shapeArc:
+			r.Arc(sh.points[0].X, sh.points[0].Y, sh.radius, sh.startAngle, sh.sweepAngle, sh.pencil)
+		case shapeText:
+			r.Text(sh.points[0].X, sh.points[0].Y, sh.text, sh.pencil)
+		case shapeImage:
+			r.Image(sh.points[0].X, sh.points[0].Y, sh.width, sh.height, sh.href, sh.imagestyle)
+		case shapeGroupBegin:
+			r.BeginGroup(sh.text)
+			opened++
+		case shapeGroupEnd:
+			r.EndGroup()
+			opened--
+		}
+	}
+	for ; opened > 0; opened-- {
+		r.EndGroup()
+	}
+	r.End()
+}
@@ -0,0 +1,83 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// traceRenderer records the calls of the renderer methods
+type traceRenderer struct {
+	calls []string
+}
+
+func (r *traceRenderer) trace(format string, args ...any) {
+	r.calls = append(r.calls, fmt.Sprintf(format, args...))
+}
+
+func (r *traceRenderer) Begin(width, height int, background string) {
+	r.trace("Begin(%d,%d,%s)", width, height, background)
+}
+func (r *traceRenderer) End()                { r.trace("End") }
+func (r *traceRenderer) MoveTo(x, y float64) { r.trace("MoveTo(%.0f,%.0f)", x, y) }
+func (r *traceRenderer) LineTo(x, y float64, p Pencil) {
+	r.trace("LineTo(%.0f,%.0f,%s)", x, y, p.LineColor)
+}
+func (r *traceRenderer) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	r.trace("Path(%d,%t,%t,%s)", len(points), closed, fill, p.FillColor)
+}
+func (r *traceRenderer) Circle(cx, cy, radius float64, fill bool, p Pencil) {
+	r.trace("Circle(%.0f,%.0f,%.0f,%t)", cx, cy, radius, fill)
+}
+func (r *traceRenderer) Arc(cx, cy, radius, startAngle, sweepAngle float64, p Pencil) {
+	// (adding 0 turns a negative zero into a positive zero)
+	r.trace("Arc(%.0f,%.0f,%.0f,%.0f,%.0f)", cx, cy, radius, startAngle+0, sweepAngle)
+}
+func (r *traceRenderer) Text(x, y float64, text string, p Pencil) {
+	r.trace("Text(%.0f,%.0f,%s,%d)", x, y, text, p.FontSize)
+}
+func (r *traceRenderer) Image(x, y, width, height float64, href string, style ImageStyle) {
+	r.trace("Image(%.0f,%.0f,%.0f,%.0f)", x, y, width, height)
+}
+func (r *traceRenderer) BeginGroup(name string) { r.trace("BeginGroup(%s)", name) }
+func (r *traceRenderer) EndGroup()              { r.trace("EndGroup") }
+
+func TestRenderer_Render(t *testing.T) {
+	s := NewSketcher().WithBackgroundColor("white")
+	s.BeginGroup("frame")
+	s.Pencil.LineColor = "red"
+	s.Edge(0.2, 0.2, 0.8, 0.8)
+	s.Pencil.FillColor = "blue"
+	s.Polygon(testpoints(), true)
+	s.BeginGroup("marks")
+	s.Circle(0.5, 0.5, 0.1, false)
+	s.Arc(0.5, 0.5, 0.2, 0, 90)
+	s.EndGroup()
+	s.Pencil.FontSize = 14
+	s.Text(0.1, 0.9, "label")
+	s.Image(0.1, 0.3, 0.2, 0.2, testimage(4, 4))
+
+	// The pencil is recorded with each shape, and the group left open is
+	// closed before the end
+	r := &traceRenderer{}
+	s.Render(r)
+	res := strings.Join(r.calls, "\n")
+	ref := strings.Join([]string{
+		"Begin(600,600,white)",
+		"BeginGroup(frame)",
+		"MoveTo(120,480)",
+		"LineTo(480,120,red)",
+		"Path(5,true,true,blue)",
+		"BeginGroup(marks)",
+		"Circle(300,300,60,false)",
+		"Arc(300,300,120,0,-90)",
+		"EndGroup",
+		"Text(60,60,label,14)",
+		"Image(60,300,120,120)",
+		"EndGroup",
+		"End",
+	}, "\n")
+	if res != ref {
+		t.Errorf("result is:\n%s\nShould be:\n%s", res, ref)
+	}
+}
@@ -0,0 +1,243 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ===========================================================================
+// Display list of the drawn shapes
+// ===========================================================================
+
+// The sketcher records the list of the drawn shapes (the display list), with
+// the coordinates resolved in the canvas coordinates system and a copy of the
+// pencil used for the drawing. The display list is replayed into a renderer to
+// produce the sketch in a given format (see renderer.go).
+
+type shapeKind int
+
+const (
+	shapeLine       shapeKind = iota // a straight line from points[0] to points[1]
+	shapePolygon                     // a closed polygon defined by the points
+	shapeCircle                      // a circle of center points[0] and given radius
+	shapeText                        // a text whose baseline starts at points[0]
+	shapeImage                       // a raster image whose top left corner is points[0]
+	shapeArc                         // an arc of circle of center points[0] and given radius
+	shapeGroupBegin                  // the beginning of a group whose name is the text
+	shapeGroupEnd                    // the end of the last opened group
+	shapePolyline                    // an open polyline defined by the points
+)
+
+// point is a point in the canvas coordinates system (pixels). It is an alias
+// of the anonymous structure used by the API for the lists of points.
+type point = struct{ X, Y float64 }
+
+type shape struct {
+	kind   shapeKind
+	points []point
+	radius float64 // radius of a circle or an arc (pixels)
+	fill   bool    // fill mode for closed shapes
+	pencil Pencil  // copy of the pencil at the time of the drawing
+	text   string
+
+	// image parameters
+	width, height float64 // size of the image (pixels)
+	href          string  // data URI or link to the image file
+	imagestyle    ImageStyle
+
+	// arc parameters (degrees, in the canvas coordinates system, see
+	// Renderer.Arc)
+	startAngle, sweepAngle float64
+}
+
+// String returns the name of the kind of shape
+func (k shapeKind) String() string {
+	names := [...]string{"line", "polygon", "circle", "text", "image", "arc", "group", "group end", "polyline"}
+	if int(k) < len(names) {
+		return names[k]
+	}
+	return fmt.Sprintf("shape %d", int(k))
+}
+
+func (s *Sketcher) record(sh shape) {
+	s.checkShape(sh)
+	if !s.checkFinite(&sh) {
+		return
+	}
+	sh.pencil = *s.Pencil
+	sh.pencil.Dash = append([]float64(nil), s.Pencil.Dash...)
+	s.shapes = append(s.shapes, sh)
+}
+
+// checkFinite applies the non finite coordinates policy of the sketcher to the
+// shape (its points, sizes and arc angles), and returns false if the shape
+// should not be recorded
+func (s *Sketcher) checkFinite(sh *shape) bool {
+	sizes := []float64{sh.radius, sh.width, sh.height, sh.startAngle, sh.sweepAngle}
+	if finitePoints(sh.points...) && !slices.ContainsFunc(sizes, isNonFinite) {
+		return true
+	}
+	if s.strict || s.nonFinite == NonFiniteError {
+		s.fail(NonFiniteCoordinates, sh.kind.String(), "%v", sh.points)
+	}
+	switch s.nonFinite {
+	case NonFiniteClamp:
+		if slices.ContainsFunc(sizes, isNonFinite) {
+			return false
+		}
+		// the infinite coordinates are clamped to the canvas extended by its
+		// size on each side (then the lines going to the infinity still go
+		// out of the canvas)
+		w, h := float64(s.cs.cnvxsize), float64(s.cs.cnvysize)
+		points := make([]point, len(sh.points))
+		for i, p := range sh.points {
+			if math.IsNaN(p.X) || math.IsNaN(p.Y) {
+				return false
+			}
+			points[i] = point{max(min(p.X, 2*w), -w), max(min(p.Y, 2*h), -h)}
+		}
+		sh.points = points
+		return true
+	}
+	return false
+}
+
+// checkAngles applies the non finite coordinates policy to the angles of an
+// arc (which can not be clamped), and returns false if the shape should not be
+// drawn
+func (s *Sketcher) checkAngles(kind shapeKind, angles ...float64) bool {
+	if !slices.ContainsFunc(angles, isNonFinite) {
+		return true
+	}
+	if s.strict || s.nonFinite == NonFiniteError {
+		s.fail(NonFiniteCoordinates, kind.String(), "angles %v", angles)
+	}
+	return false
+}
+
+// arcSweep returns the arc from startAngle to endAngle (degrees) going
+// counterclockwise: its start angle, brought in (-360, 360) so that huge
+// angles keep their precision, and its sweep endAngle-startAngle, brought in
+// [0, 360) if negative
+func arcSweep(startAngle, endAngle float64) (start, sweep float64) {
+	sweep = endAngle - startAngle
+	if sweep < 0 {
+		sweep = math.Mod(sweep, 360)
+		if sweep < 0 {
+			sweep += 360
+		}
+	}
+	return math.Mod(startAngle, 360), sweep
+}
+
+func isNonFinite(v float64) bool {
+	return math.IsNaN(v) || math.IsInf(v, 0)
+}
+
+// imageRectangle returns the canvas rectangle (top left corner and size) where
+// an image of size imgwidth x imgheight pixels is drawn in the rectangle (x, y,
+// width, height). The aspect ratio is preserved (the image is centered in the
+// rectangle) unless the aspect ratio mode of the style is "none".
+func imageRectangle(x, y, width, height float64, style ImageStyle, imgwidth, imgheight int) (float64, float64, float64, float64) {
+	if style.AspectRatio == ImageAspectRatioNone {
+		return x, y, width, height
+	}
+	k := math.Min(width/float64(imgwidth), height/float64(imgheight))
+	x += 0.5 * (width - k*float64(imgwidth))
+	y += 0.5 * (height - k*float64(imgheight))
+	return x, y, k * float64(imgwidth), k * float64(imgheight)
+}
+
+// arcPoints returns the arc of circle (see Renderer.Arc) as a polyline
+func arcPoints(cx, cy, r, startAngle, sweepAngle float64) []point {
+	n := int(2 * math.Pi * r * math.Abs(sweepAngle) / 360 / circleStepSize)
+	n = min(max(n, 4), 360)
+	points := make([]point, n+1)
+	for i := range points {
+		a := (startAngle + sweepAngle*float64(i)/float64(n)) * math.Pi / 180
+		points[i] = point{cx + r*math.Cos(a), cy + r*math.Sin(a)}
+	}
+	return points
+}
+
+// userArcAngles returns the angles (degrees) of the arc of the canvas (see
+// Renderer.Arc) in the user coordinates system cs, ordered counterclockwise
+// (startAngle < endAngle), as expected by the backends whose arcs are defined
+// in user coordinates
+func userArcAngles(cs *CoordinateSystem, startAngle, sweepAngle float64) (start, end float64) {
+	// The mapping of the angles is its own inverse (see Sketcher.Arc)
+	start = math.Atan2(cs.ysign*sind(startAngle), cs.xsign*cosd(startAngle)) * 180 / math.Pi
+	sweep := sweepAngle * cs.xsign * cs.ysign
+	if sweep < 0 {
+		start, sweep = start+sweep, -sweep
+	}
+	return start, start + sweep
+}
+
+// finitePoints returns true if all the coordinates of the points are finite
+func finitePoints(points ...point) bool {
+	for _, p := range points {
+		if !isFinitePoint(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// dashPolyline splits the polyline into the dashes defined by the dash pattern
+// (lengths of the alternating dashes and gaps). The polyline is returned as a
+// single dash if the pattern is empty or invalid.
+func dashPolyline(points []point, dash []float64, closed bool) [][]point {
+	if closed && len(points) > 2 {
+		points = append(points[:len(points):len(points)], points[0])
+	}
+	var total float64
+	for _, l := range dash {
+		if l < 0 {
+			return [][]point{points}
+		}
+		total += l
+	}
+	if total <= 0 {
+		return [][]point{points}
+	}
+	if len(dash)%2 == 1 {
+		// an odd pattern is repeated to get an even pattern (as in SVG)
+		dash = append(dash[:len(dash):len(dash)], dash...)
+	}
+
+	var dashes [][]point
+	var current []point
+	k := 0            // index of the current dash (even) or gap (odd)
+	remain := dash[0] // remaining length of the current dash or gap
+	if len(points) > 0 {
+		current = []point{points[0]}
+	}
+	for i := 0; i+1 < len(points); i++ {
+		p, q := points[i], points[i+1]
+		l := math.Hypot(q.X-p.X, q.Y-p.Y)
+		pos := 0.
+		for l-pos > remain {
+			pos += remain
+			t := pos / l
+			m := point{p.X + t*(q.X-p.X), p.Y + t*(q.Y-p.Y)}
+			if k%2 == 0 {
+				dashes = append(dashes, append(current, m))
+				current = nil
+			} else {
+				current = []point{m}
+			}
+			k = (k + 1) % len(dash)
+			remain = dash[k]
+		}
+		remain -= l - pos
+		if k%2 == 0 {
+			current = append(current, q)
+		}
+	}
+	if len(current) > 1 {
+		dashes = append(dashes, current)
+	}
+	return dashes
+}
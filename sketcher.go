@@ -1,18 +1,9 @@
 package svg
 
 import (
-	"fmt"
+	"math"
 	"os"
-)
-
-const (
-	headPattern  = "<svg xmlns='http://www.w3.org/2000/svg' width='%d' height='%d'>"
-	linePattern  = "<line x1='%.2f' y1='%.2f' x2='%.2f' y2='%.2f' style='%s'/>"
-	textPattern  = "<text x='%.2f' y='%.2f' style='%s'>%s</text>"
-	rectPattern  = "<rect x='%.2f' y='%.2f' width='%.2f' height='%.2f' style='%s'/>"
-	circPattern  = "<circle cx='%.2f' cy='%.2f' r='%.2f' style='%s'/>"
-	polygPattern = "<polygon points='%s' style='%s'/>"
-	footPattern  = "</svg>"
+	"slices"
 )
 
 // ===========================================================================
@@ -23,22 +14,48 @@ const (
 	NoColor                = "none"
 	Transparent            = NoColor
 	defaultBackgroundColor = Transparent
+	DefaultPrecision       = 2 // number of decimals of the SVG coordinates
+)
+
+// NonFinitePolicy defines how the sketcher handles the shapes whose canvas
+// coordinates are not finite (NaN or infinite), e.g. when a function drawn by
+// the user diverges
+type NonFinitePolicy int
+
+const (
+	NonFiniteSkip  NonFinitePolicy = iota // the shape is not drawn
+	NonFiniteError                        // the shape is not drawn, and an error is recorded (see Err)
+	NonFiniteClamp                        // the infinite coordinates are clamped (the shapes with NaN are not drawn)
 )
 
 var defaultCoordinateSystem = NewCoordinateSystem()
 
 type Sketcher struct {
 	x, y            float64
-	body            string
 	cs              *CoordinateSystem
 	Pencil          *Pencil
 	backgroundColor string
+	theme           *Theme
+	ImageStyle      ImageStyle
+	shapes          []shape
+	groups          int // number of open groups
+
+	precision int  // number of decimals of the SVG coordinates
+	trimZeros bool // if true, the trailing zeros of the SVG coordinates are removed
+	nonFinite NonFinitePolicy
+
+	strict       bool            // if true, the drawing errors are recorded (see WithStrictMode)
+	errs         []error         // recorded drawing errors (see Err)
+	errsDropped  int             // number of errors not recorded (see maxDrawingErrors)
+	colorChecked map[string]bool // colors already checked in strict mode
 }
 
 func NewSketcher() *Sketcher {
 	return &Sketcher{
-		x: 0., y: 0, body: "", cs: defaultCoordinateSystem,
+		x: 0., y: 0, cs: defaultCoordinateSystem,
 		Pencil: defaultPencil.Clone(), backgroundColor: defaultBackgroundColor,
+		theme: DefaultTheme, ImageStyle: defaultImageStyle,
+		precision: DefaultPrecision,
 	}
 }
 
@@ -52,6 +69,58 @@ func (s *Sketcher) WithBackgroundColor(colorname string) *Sketcher {
 	return s
 }
 
+// WithPrecision sets the number of decimals of the coordinates written in the
+// SVG document (DefaultPrecision by default). If trim is true, the trailing
+// zeros are removed (e.g. 120 instead of 120.00).
+func (s *Sketcher) WithPrecision(decimals int, trim bool) *Sketcher {
+	s.precision = max(decimals, 0)
+	s.trimZeros = trim
+	return s
+}
+
+// WithNonFinitePolicy sets how the shapes with non finite coordinates are
+// handled (NonFiniteSkip by default)
+func (s *Sketcher) WithNonFinitePolicy(policy NonFinitePolicy) *Sketcher {
+	s.nonFinite = policy
+	return s
+}
+
+// WithStrictMode enables (or disables) the strict mode: the sketcher records an
+// error for each invalid shape (e.g. a polygon with less than 3 points), each
+// shape with non finite coordinates (whatever the NonFinitePolicy), each
+// unknown color and a degenerate coordinates system. The shapes are still drawn
+// as in the default mode. The errors are returned by Err and Save.
+func (s *Sketcher) WithStrictMode(strict bool) *Sketcher {
+	s.strict = strict
+	return s
+}
+
+// WithTheme applies the theme t to the sketcher: the background color is set to
+// the theme background color, and the pencil is reset to a copy of the theme
+// pencil, with the font parameters of the theme text pencil. The missing
+// pencils of the theme are the ones of DefaultTheme (and DefaultTheme is used
+// if t is nil).
+func (s *Sketcher) WithTheme(t *Theme) *Sketcher {
+	if t == nil {
+		t = DefaultTheme
+	}
+	t = t.complete()
+	s.theme = t
+	s.backgroundColor = t.BackgroundColor
+	s.Pencil = t.Pencil.Clone()
+	s.Pencil.FontFamily = t.TextPencil.FontFamily
+	s.Pencil.FontWeight = t.TextPencil.FontWeight
+	s.Pencil.FontSize = t.TextPencil.FontSize
+	s.Pencil.FontColor = t.TextPencil.FontColor
+	return s
+}
+
+// Theme returns the theme of the sketcher (DefaultTheme if no theme has been
+// specified with WithTheme)
+func (s Sketcher) Theme() *Theme {
+	return s.theme
+}
+
 func (s Sketcher) CoordinatesSystem() *CoordinateSystem {
 	return s.cs
 }
@@ -60,23 +129,17 @@ func (s Sketcher) CoordinatesSystem() *CoordinateSystem {
 // Sketch export and display functions
 
 func (s Sketcher) ToSVG() string {
-	svg := fmt.Sprintf(headPattern, s.cs.cnvxsize, s.cs.cnvysize) + "\n"
-	if s.backgroundColor != Transparent {
-		// Add a full size rectangle as first element with fill color set to
-		// the background color (classical method for SVG background color)
-		svg += fmt.Sprintf(
-			"<rect width='%d' height='%d' fill='%s'/>\n",
-			s.cs.cnvxsize, s.cs.cnvysize, s.backgroundColor)
-	}
-	svg += s.body
-	svg += footPattern
-	return svg
+	r := &svgRenderer{decimals: s.precision, trim: s.trimZeros}
+	s.Render(r)
+	return r.b.String()
 }
 
 func (s Sketcher) String() string {
 	return s.ToSVG()
 }
 
+// Save saves the sketch as an SVG file. The file is saved even if errors were
+// recorded while drawing, then these errors are returned (see Err).
 func (s Sketcher) Save(svgpath string) error {
 	file, err := os.OpenFile(svgpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 	if err != nil {
@@ -88,14 +151,18 @@ func (s Sketcher) Save(svgpath string) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.Err()
 }
 
 // --------------------------------------------------------------------
 // Sketch management functions
 
 func (s *Sketcher) Clear() {
-	s.body = ""
+	s.shapes = nil
+	s.groups = 0
+	s.errs = nil
+	s.errsDropped = 0
+	s.colorChecked = nil
 }
 
 func (s Sketcher) Position() (x, y float64) {
@@ -127,8 +194,7 @@ func (s *Sketcher) MoveTo(x, y float64) {
 func (s *Sketcher) LineTo(x, y float64) {
 	px1, py1 := s.canvasCoordinates(s.x, s.y)
 	px2, py2 := s.canvasCoordinates(x, y)
-	style := s.Pencil.DrawStyle()
-	s.body += fmt.Sprintf(linePattern, px1, py1, px2, py2, style) + "\n"
+	s.record(shape{kind: shapeLine, points: []point{{px1, py1}, {px2, py2}}})
 	s.x = x
 	s.y = y
 }
@@ -149,8 +215,7 @@ func (s *Sketcher) Edge(x1, y1, x2, y2 float64) {
 func (s *Sketcher) Circle(cx, cy, r float64, fill bool) {
 	pcx, pcy := s.canvasCoordinates(cx, cy)
 	pr := s.canvasScaling(r)
-	style := s.Pencil.DrawStyleWithFillMode(fill)
-	s.body += fmt.Sprintf(circPattern, pcx, pcy, pr, style) + "\n"
+	s.record(shape{kind: shapeCircle, points: []point{{pcx, pcy}}, radius: pr, fill: fill})
 	s.x = cx
 	s.y = cy
 }
@@ -159,9 +224,7 @@ func (s *Sketcher) Triangle(x1, y1, x2, y2, x3, y3 float64, fill bool) {
 	px1, py1 := s.canvasCoordinates(x1, y1)
 	px2, py2 := s.canvasCoordinates(x2, y2)
 	px3, py3 := s.canvasCoordinates(x3, y3)
-	coords := fmt.Sprintf("%.2f,%.2f %.2f,%.2f %.2f,%.2f", px1, py1, px2, py2, px3, py3)
-	style := s.Pencil.DrawStyleWithFillMode(fill)
-	s.body += fmt.Sprintf(polygPattern, coords, style) + "\n"
+	s.record(shape{kind: shapePolygon, points: []point{{px1, py1}, {px2, py2}, {px3, py3}}, fill: fill})
 	s.x = x3
 	s.y = y3
 }
@@ -171,9 +234,7 @@ func (s *Sketcher) Quadrangle(x1, y1, x2, y2, x3, y3, x4, y4 float64, fill bool)
 	px2, py2 := s.canvasCoordinates(x2, y2)
 	px3, py3 := s.canvasCoordinates(x3, y3)
 	px4, py4 := s.canvasCoordinates(x4, y4)
-	coords := fmt.Sprintf("%.2f,%.2f %.2f,%.2f %.2f,%.2f %.2f,%.2f", px1, py1, px2, py2, px3, py3, px4, py4)
-	style := s.Pencil.DrawStyleWithFillMode(fill)
-	s.body += fmt.Sprintf(polygPattern, coords, style) + "\n" // this concatenation is time consuming
+	s.record(shape{kind: shapePolygon, points: []point{{px1, py1}, {px2, py2}, {px3, py3}, {px4, py4}}, fill: fill})
 	s.x = x4
 	s.y = y4
 }
@@ -188,14 +249,13 @@ func (s *Sketcher) Rectangle(x, y, width, height float64, fill bool) {
 // point coordinates, each point coordinates is a tuple (x,y).
 func (s *Sketcher) Polygon(points []struct{ X, Y float64 }, fill bool) {
 	var x, y float64
-	var coords string
-	for _, p := range points {
+	cpoints := make([]point, len(points))
+	for i, p := range points {
 		x, y = p.X, p.Y
 		px, py := s.canvasCoordinates(x, y)
-		coords += fmt.Sprintf("%.2f,%.2f ", px, py)
+		cpoints[i] = point{px, py}
 	}
-	style := s.Pencil.DrawStyleWithFillMode(fill)
-	s.body += fmt.Sprintf(polygPattern, coords, style) + "\n"
+	s.record(shape{kind: shapePolygon, points: cpoints, fill: fill})
 	s.x = x
 	s.y = y
 }
@@ -207,6 +267,12 @@ func (s *Sketcher) Polygon(points []struct{ X, Y float64 }, fill bool) {
 // is added to connect the last point to the first, and then create a
 // closed polyline, i.e. a polygone
 func (s *Sketcher) Polyline(points []struct{ X, Y float64 }, closed bool) {
+	if len(points) < 2 && s.strict {
+		s.fail(InvalidGeometry, shapePolyline.String(), "%d points (at least 2 expected)", len(points))
+	}
+	if len(points) == 0 {
+		return
+	}
 	p := points[0]
 	s.MoveTo(p.X, p.Y)
 	for _, p = range points[1:] {
@@ -220,13 +286,118 @@ func (s *Sketcher) Polyline(points []struct{ X, Y float64 }, closed bool) {
 	s.y = p.Y
 }
 
+// Arc draws the arc of the circle of center (cx, cy) and radius r, from the
+// angle startAngle to the angle endAngle (in degrees). The angles are measured
+// counterclockwise from the x axis in the user coordinates system, then the arc
+// goes counterclockwise from startAngle to endAngle (whatever the orientation
+// of the coordinates system axis on the canvas).
+func (s *Sketcher) Arc(cx, cy, r, startAngle, endAngle float64) {
+	if !s.checkAngles(shapeArc, startAngle, endAngle) {
+		return
+	}
+	startAngle, sweep := arcSweep(startAngle, endAngle)
+	endAngle = startAngle + sweep
+	if sweep >= 360 {
+		s.Circle(cx, cy, r, false)
+		return
+	}
+	// The angles in the canvas coordinates system (the orientation of the
+	// angles is reversed if only one of the axis is reversed)
+	pcx, pcy := s.canvasCoordinates(cx, cy)
+	pr := s.canvasScaling(r)
+	xsign, ysign := s.cs.xsign, s.cs.ysign
+	pstart := math.Atan2(ysign*sind(startAngle), xsign*cosd(startAngle)) * 180 / math.Pi
+	psweep := sweep * xsign * ysign
+	s.record(shape{
+		kind: shapeArc, points: []point{{pcx, pcy}}, radius: pr,
+		startAngle: pstart, sweepAngle: psweep,
+	})
+	s.x = cx + r*cosd(endAngle)
+	s.y = cy + r*sind(endAngle)
+}
+
+// arcTolerance is the maximal distance (pixels) between an arc and the
+// polyline approximating the arc in the filled sectors
+const arcTolerance = 0.25
+
+// arcPoints returns the points of the polyline approximating the arc of the
+// circle of center (cx, cy) and radius r from the angle startAngle to the
+// angle endAngle (degrees, user coordinates), end points included
+func (s *Sketcher) arcPoints(cx, cy, r, startAngle, endAngle float64) []struct{ X, Y float64 } {
+	// the step angle for which the sagitta r(1-cos(step/2)) is arcTolerance
+	pr := math.Abs(s.canvasScaling(r))
+	step := 45.
+	if pr > arcTolerance {
+		step = math.Min(step, 2*math.Acos(1-arcTolerance/pr)*180/math.Pi)
+	}
+	n := max(1, int(math.Ceil(math.Abs(endAngle-startAngle)/step)))
+	points := make([]struct{ X, Y float64 }, n+1)
+	for i := range points {
+		a := startAngle + (endAngle-startAngle)*float64(i)/float64(n)
+		points[i].X, points[i].Y = cx+r*cosd(a), cy+r*sind(a)
+	}
+	return points
+}
+
+// Sector draws the sector of the disk of center (cx, cy) and radius r, from
+// the angle startAngle to the angle endAngle (in degrees, counterclockwise as
+// for Arc), i.e. a slice of a pie chart. The sector is drawn as a polygon whose
+// arc is approximated with a precision of a quarter of pixel.
+func (s *Sketcher) Sector(cx, cy, r, startAngle, endAngle float64, fill bool) {
+	s.AnnularSector(cx, cy, 0, r, startAngle, endAngle, fill)
+}
+
+// AnnularSector draws the sector of the ring of center (cx, cy) between the
+// radius r1 and r2, from the angle startAngle to the angle endAngle (in
+// degrees, counterclockwise as for Arc), i.e. a slice of a donut chart. If r1
+// is zero, this is the sector of the disk of radius r2 (see Sector).
+func (s *Sketcher) AnnularSector(cx, cy, r1, r2, startAngle, endAngle float64, fill bool) {
+	if !s.checkAngles(shapePolygon, startAngle, endAngle) {
+		return
+	}
+	startAngle, sweep := arcSweep(startAngle, endAngle)
+	endAngle = startAngle + math.Min(sweep, 360)
+	points := s.arcPoints(cx, cy, r2, startAngle, endAngle)
+	if r1 > 0 {
+		inner := s.arcPoints(cx, cy, r1, startAngle, endAngle)
+		slices.Reverse(inner)
+		points = append(points, inner...)
+	} else if endAngle-startAngle < 360 {
+		points = append(points, struct{ X, Y float64 }{cx, cy})
+	}
+	s.Polygon(points, fill)
+}
+
+func cosd(a float64) float64 { return math.Cos(a * math.Pi / 180) }
+func sind(a float64) float64 { return math.Sin(a * math.Pi / 180) }
+
+// --------------------------------------------------------------------
+// Groups of shapes
+
+// BeginGroup opens a group of shapes named name: all the shapes drawn until the
+// call of EndGroup belong to this group (an SVG element g whose id is the
+// name). The groups can be nested. The backends that support layers (e.g. DXF)
+// draw the shapes of a group on a layer named after the group.
+func (s *Sketcher) BeginGroup(name string) {
+	s.shapes = append(s.shapes, shape{kind: shapeGroupBegin, text: name})
+	s.groups++
+}
+
+// EndGroup closes the last opened group (no effect if no group is open)
+func (s *Sketcher) EndGroup() {
+	if s.groups == 0 {
+		return
+	}
+	s.shapes = append(s.shapes, shape{kind: shapeGroupEnd})
+	s.groups--
+}
+
 // --------------------------------------------------------------------
 // Write text functions
 
 func (s *Sketcher) Text(x, y float64, text string) {
 	px, py := s.canvasCoordinates(x, y)
-	style := s.Pencil.TextStyle()
-	s.body += fmt.Sprintf(textPattern, px, py, style, text) + "\n"
+	s.record(shape{kind: shapeText, points: []point{{px, py}}, text: text})
 }
 
 func (s *Sketcher) PointWithLabel(x, y float64, label string) {
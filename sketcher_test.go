@@ -1,7 +1,11 @@
 package svg
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -244,3 +248,235 @@ func TestSketcher_WithBackgroundColor(t *testing.T) {
 	s.Circle(0, 0, 0.3, true)
 	s.Save("output.TestSketcher_WithBackgroundColor.svg")
 }
+
+func TestSketcher_Dash(t *testing.T) {
+	s := NewSketcher()
+	s.Pencil.Dash = []float64{10, 5}
+	s.Edge(0.1, 0.5, 0.9, 0.5)
+	s.Save("output.TestSketcher_Dash.svg")
+
+	res := s.ToSVG()
+	ref := "style='stroke: black; stroke-width: 2; fill: black; stroke-dasharray: 10,5'"
+	if !strings.Contains(res, ref) {
+		t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+	}
+
+	// The raster line is made of dashes of 10 pixels separated by gaps of 5
+	// pixels: the pixel at x=60+12 is in a gap
+	img := s.ToImage(1)
+	if c := img.RGBAAt(65, 300); c.A != 255 {
+		t.Errorf("the pixel (65,300) should be in a dash, not %v", c)
+	}
+	if c := img.RGBAAt(72, 300); c.A != 0 {
+		t.Errorf("the pixel (72,300) should be in a gap, not %v", c)
+	}
+}
+
+const output_TestSketcher_ArcAndGroups string = `<svg xmlns='http://www.w3.org/2000/svg' width='600' height='600'>
+<g id='walls'>
+<path d='M 420.00 300.00 A 120.00 120.00 0 0 0 300.00 180.00' style='stroke: black; stroke-width: 2; fill: none'/>
+<g id='doors'>
+<path d='M 420.00 300.00 A 120.00 120.00 0 1 0 300.00 420.00' style='stroke: black; stroke-width: 2; fill: none'/>
+</g>
+</g>
+</svg>`
+
+func TestSketcher_ArcAndGroups(t *testing.T) {
+	s := NewSketcher()
+	s.BeginGroup("walls")
+	s.Arc(0.5, 0.5, 0.2, 0, 90)
+	s.BeginGroup("doors")
+	s.Arc(0.5, 0.5, 0.2, 0, -90)
+	s.EndGroup()
+	s.Save("output.TestSketcher_ArcAndGroups.svg")
+
+	// The groups still open are closed at the end of the sketch
+	res := s.ToSVG()
+	ref := output_TestSketcher_ArcAndGroups
+	if res != ref {
+		t.Errorf("result is:\n%s\nShould be:\n%s", res, ref)
+	}
+	x, y := s.Position()
+	if math.Abs(x-0.5) > 1e-9 || math.Abs(y-0.3) > 1e-9 {
+		t.Errorf("the position is (%.2f,%.2f) and should be the end of the arc (0.50,0.30)", x, y)
+	}
+	// The second arc is drawn on the layer of the nested group
+	layer := "  8\nWALLS-DOORS\n"
+	if dxf := s.ToDXF(); !strings.Contains(dxf, "ARC\n"+layer) {
+		t.Errorf("the DXF document should contain an arc on the layer WALLS-DOORS:\n%s", dxf)
+	}
+}
+
+func TestSketcher_ArcAngles(t *testing.T) {
+	// The arcs with non finite angles are skipped, the huge angles are
+	// brought back in one turn (-1e12° is 80° counterclockwise)
+	s := NewSketcher()
+	s.Arc(0.5, 0.5, 0.2, 0, math.Inf(-1))
+	s.Arc(0.5, 0.5, 0.2, math.NaN(), 90)
+	if len(s.shapes) != 0 {
+		t.Errorf("the arcs with non finite angles should be skipped")
+	}
+	s.Arc(0.5, 0.5, 0.2, 0, -1e12)
+	x, y := s.Position()
+	if math.Abs(x-0.5-0.2*cosd(80)) > 1e-9 || math.Abs(y-0.5-0.2*sind(80)) > 1e-9 {
+		t.Errorf("the position is (%.2f,%.2f) and should be the end of the arc at 80°", x, y)
+	}
+
+	s.Arc(0.5, 0.5, 0.2, -1e300, 1e300)
+	if sh := s.shapes[len(s.shapes)-1]; sh.kind != shapeCircle {
+		t.Errorf("the arc of more than a turn should be a circle, not a %s", sh.kind)
+	}
+
+	s = NewSketcher().WithNonFinitePolicy(NonFiniteError)
+	s.Arc(0.5, 0.5, 0.2, 0, math.Inf(1))
+	if err := s.Err(); err == nil || !strings.Contains(err.Error(), "arc") {
+		t.Errorf("the error should be about the arc, not %v", err)
+	}
+}
+
+func TestSketcher_Sector(t *testing.T) {
+	s := NewSketcher().WithCoordinateSystem(NewCoordSysCentered(600, 600, 2))
+	s.Pencil.FillColor = "orange"
+	s.Sector(0, 0, 0.8, 0, 120, true)
+	s.Pencil.FillColor = "steelblue"
+	s.AnnularSector(0, 0, 0.4, 0.8, 120, 360, true)
+	s.Save("output.TestSketcher_Sector.svg")
+
+	res := s.ToSVG()
+	if n := strings.Count(res, "<polygon"); n != 2 {
+		t.Fatalf("the sketch has %d polygons and should have 2:\n%s", n, res)
+	}
+	// The sector is closed at the center, and its arc is flattened finely
+	if !strings.Contains(res, " 300.00,300.00'") {
+		t.Errorf("the sector should end at the center of the disk:\n%s", res)
+	}
+	for _, sh := range s.shapes {
+		if len(sh.points) < 20 {
+			t.Errorf("the sector has %d points and its arc should be flattened finely", len(sh.points))
+		}
+	}
+
+	// The sectors with non finite angles are skipped, and the huge angles do
+	// not sweep more than a turn
+	s.Sector(0, 0, 0.8, 0, math.Inf(-1), true)
+	s.AnnularSector(0, 0, 0.4, 0.8, math.NaN(), 90, true)
+	if len(s.shapes) != 2 {
+		t.Errorf("the sectors with non finite angles should be skipped")
+	}
+	s.Sector(0, 0, 0.8, -1e300, 1e300, true)
+	if n := len(s.shapes[2].points); n < 20 || n > 400 {
+		t.Errorf("the full disk has %d points and should have a few hundreds", n)
+	}
+}
+
+func TestSketcher_WithPrecision(t *testing.T) {
+	s := NewSketcher().WithPrecision(3, true)
+	s.Edge(0.2, 0.2, 0.1234567, 0.5)
+	res := s.ToSVG()
+	ref := "<line x1='120' y1='480' x2='74.074' y2='300' style='stroke: black; stroke-width: 2; fill: black'/>"
+	if !strings.Contains(res, ref) {
+		t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+	}
+
+	s.WithPrecision(1, false)
+	res = s.ToSVG()
+	ref = "<line x1='120.0' y1='480.0' x2='74.1' y2='300.0' style='stroke: black; stroke-width: 2; fill: black'/>"
+	if !strings.Contains(res, ref) {
+		t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+	}
+}
+
+func TestSketcher_NonFinitePolicy(t *testing.T) {
+	draw := func(s *Sketcher) {
+		s.Edge(0.2, 0.2, math.Inf(1), 0.5)
+		s.Circle(math.NaN(), 0.5, 0.1, false)
+		s.Circle(0.5, 0.5, 0.1, false)
+	}
+
+	// The shapes with non finite coordinates are skipped by default
+	s := NewSketcher()
+	draw(s)
+	// an arc whose angles are not finite in the canvas coordinates
+	s.record(shape{kind: shapeArc, points: []point{{300, 300}}, radius: 120, startAngle: math.NaN(), sweepAngle: 90})
+	res := s.ToSVG()
+	if strings.Contains(res, "NaN") || strings.Contains(res, "Inf") || len(s.shapes) != 1 {
+		t.Errorf("the shapes with non finite coordinates should be skipped:\n%s", res)
+	}
+	if err := s.Save("output.TestSketcher_NonFinitePolicy.svg"); err != nil {
+		t.Errorf("the sketch should be saved: %v", err)
+	}
+
+	// The infinite coordinates are clamped to the extended canvas
+	s = NewSketcher().WithNonFinitePolicy(NonFiniteClamp)
+	draw(s)
+	res = s.ToSVG()
+	ref := "<line x1='120.00' y1='480.00' x2='1200.00' y2='300.00'"
+	if !strings.Contains(res, ref) || len(s.shapes) != 2 {
+		t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+	}
+
+	// The first error is returned by Save
+	s = NewSketcher().WithNonFinitePolicy(NonFiniteError)
+	draw(s)
+	err := s.Save("output.TestSketcher_NonFinitePolicy.svg")
+	if err == nil || !strings.Contains(err.Error(), "line") {
+		t.Errorf("the error should be about the line, not %v", err)
+	}
+}
+
+func TestSketcher_StrictMode(t *testing.T) {
+	draw := func(s *Sketcher) {
+		s.Polyline(nil, false)
+		s.Polygon(testpoints()[:2], true)
+		s.Circle(0.5, 0.5, -0.1, false)
+		s.Pencil.LineColor = "nocolor"
+		s.Edge(0.2, 0.2, math.NaN(), 0.5)
+		s.Edge(0.2, 0.2, 0.8, 0.5)
+		s.Pencil.LineColor = "#0a0"
+	}
+
+	// The errors are not recorded by default (and the empty polyline is
+	// ignored)
+	s := NewSketcher()
+	draw(s)
+	if err := s.Err(); err != nil {
+		t.Errorf("no error should be recorded by default, not %v", err)
+	}
+
+	s = NewSketcher().WithStrictMode(true).WithBackgroundColor("blanc")
+	draw(s)
+	err := s.Err()
+	var kinds []ErrorKind
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var derr *DrawingError
+		if !errors.As(e, &derr) {
+			t.Fatalf("the error %v should be a DrawingError", e)
+		}
+		kinds = append(kinds, derr.Kind)
+	}
+	ref := []ErrorKind{UnknownColor, InvalidGeometry, InvalidGeometry, InvalidGeometry, UnknownColor, NonFiniteCoordinates}
+	if fmt.Sprint(kinds) != fmt.Sprint(ref) {
+		t.Errorf("the errors are:\n%v\nShould be of kinds %v", err, ref)
+	}
+	if !strings.Contains(err.Error(), `line: unknown color: "nocolor"`) {
+		t.Errorf("the unknown color should be reported once for the line:\n%v", err)
+	}
+	// The sketch is saved as in the default mode, and the errors are returned
+	os.Remove("output.TestSketcher_StrictMode.svg")
+	if s.Save("output.TestSketcher_StrictMode.svg") == nil {
+		t.Errorf("the errors should be returned by Save")
+	}
+	if data, err := os.ReadFile("output.TestSketcher_StrictMode.svg"); err != nil || string(data) != s.ToSVG() {
+		t.Errorf("the sketch with errors should be saved")
+	}
+
+	// The errors are cleared with the sketch
+	s.Clear()
+	s.WithBackgroundColor("white").Edge(0, 0, 1, 1)
+	if err := s.Save("output.TestSketcher_StrictMode.svg"); err != nil {
+		t.Errorf("the sketch should be saved: %v", err)
+	}
+	if err := NewSketcher().WithCoordinateSystem(NewCoordSysBottomLeft(0, 100, 1)).WithStrictMode(true).Err(); err == nil {
+		t.Errorf("a zero size canvas should be an error")
+	}
+}
@@ -0,0 +1,116 @@
+package svg
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	headPattern  = "<svg xmlns='http://www.w3.org/2000/svg' width='%d' height='%d'>"
+	linePattern  = "<line x1='%s' y1='%s' x2='%s' y2='%s' style='%s'/>"
+	textPattern  = "<text x='%s' y='%s' style='%s'>%s</text>"
+	rectPattern  = "<rect x='%s' y='%s' width='%s' height='%s' style='%s'/>"
+	circPattern  = "<circle cx='%s' cy='%s' r='%s' style='%s'/>"
+	polygPattern = "<polygon points='%s' style='%s'/>"
+	arcPattern   = "<path d='M %s %s A %s %s 0 %d %d %s %s' style='%s'/>"
+	groupPattern = "<g id='%s'>"
+	groupEnd     = "</g>"
+	footPattern  = "</svg>"
+)
+
+// ===========================================================================
+// SVG renderer
+// ===========================================================================
+
+// svgRenderer writes the shapes as the elements of an SVG document
+type svgRenderer struct {
+	b        strings.Builder
+	x, y     float64 // current point
+	decimals int     // number of decimals of the coordinates
+	trim     bool    // if true, the trailing zeros of the coordinates are removed
+}
+
+// num returns the coordinate v with the precision of the renderer (the
+// formatting does not depend on the locale)
+func (r *svgRenderer) num(v float64) string {
+	if r.trim {
+		return fmtnum(v, r.decimals)
+	}
+	return strconv.FormatFloat(v, 'f', r.decimals, 64)
+}
+
+func (r *svgRenderer) printf(format string, args ...any) {
+	fmt.Fprintf(&r.b, format, args...)
+	r.b.WriteString("\n")
+}
+
+func (r *svgRenderer) Begin(width, height int, background string) {
+	r.printf(headPattern, width, height)
+	if background != Transparent {
+		// Add a full size rectangle as first element with fill color set to
+		// the background color (classical method for SVG background color)
+		r.printf("<rect width='%d' height='%d' fill='%s'/>", width, height, background)
+	}
+}
+
+func (r *svgRenderer) End() {
+	r.b.WriteString(footPattern)
+}
+
+func (r *svgRenderer) MoveTo(x, y float64) {
+	r.x, r.y = x, y
+}
+
+func (r *svgRenderer) LineTo(x, y float64, p Pencil) {
+	r.printf(linePattern, r.num(r.x), r.num(r.y), r.num(x), r.num(y), p.DrawStyle())
+	r.x, r.y = x, y
+}
+
+func (r *svgRenderer) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	coords := make([]string, len(points))
+	for i, pt := range points {
+		coords[i] = r.num(pt.X) + "," + r.num(pt.Y)
+	}
+	if closed {
+		r.printf(polygPattern, strings.Join(coords, " "), p.DrawStyleWithFillMode(fill))
+	} else {
+		r.printf("<polyline points='%s' style='%s'/>", strings.Join(coords, " "), p.DrawStyleWithFillMode(false))
+	}
+}
+
+func (r *svgRenderer) Circle(cx, cy, radius float64, fill bool, p Pencil) {
+	r.printf(circPattern, r.num(cx), r.num(cy), r.num(radius), p.DrawStyleWithFillMode(fill))
+}
+
+func (r *svgRenderer) Arc(cx, cy, radius, startAngle, sweepAngle float64, p Pencil) {
+	a1 := startAngle * math.Pi / 180
+	a2 := (startAngle + sweepAngle) * math.Pi / 180
+	largeArc, sweep := 0, 0
+	if math.Abs(sweepAngle) > 180 {
+		largeArc = 1
+	}
+	if sweepAngle > 0 {
+		sweep = 1
+	}
+	r.printf(arcPattern, r.num(cx+radius*math.Cos(a1)), r.num(cy+radius*math.Sin(a1)), r.num(radius), r.num(radius),
+		largeArc, sweep, r.num(cx+radius*math.Cos(a2)), r.num(cy+radius*math.Sin(a2)), p.DrawStyleWithFillMode(false))
+}
+
+func (r *svgRenderer) Text(x, y float64, text string, p Pencil) {
+	r.printf(textPattern, r.num(x), r.num(y), p.TextStyle(), text)
+}
+
+func (r *svgRenderer) Image(x, y, width, height float64, href string, style ImageStyle) {
+	r.printf(imagePattern, r.num(x), r.num(y), r.num(width), r.num(height), style.AspectRatio, style.Opacity, html.EscapeString(href))
+}
+
+func (r *svgRenderer) BeginGroup(name string) {
+	r.printf(groupPattern, html.EscapeString(name))
+}
+
+func (r *svgRenderer) EndGroup() {
+	r.printf(groupEnd)
+}
@@ -0,0 +1,897 @@
+package svg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ===========================================================================
+// SVG import
+// ===========================================================================
+
+// The SVG importer parses an existing SVG document and draws its elements with
+// the sketcher, so that the document can be annotated or exported in another
+// format. The coordinates of the document are canvas coordinates (pixels):
+// they are mapped back into the coordinates system of the sketcher (inverse of
+// canvasCoordinates), and the shapes are drawn with the regular drawing
+// functions (the open polylines are recorded as single shapes, to keep their
+// dash pattern). Then the imported shapes are identical to the shapes that
+// would have been drawn directly in user coordinates.
+//
+// The supported elements are svg, g, line, rect, circle, ellipse, polygon,
+// polyline, path, text and image, with the transform attribute and the
+// presentation attributes (as attributes or in the style attribute) stroke,
+// stroke-width, stroke-dasharray, fill, font-family, font-size and
+// font-weight. The curves (Bézier curves, elliptical arcs, ellipses, and
+// circles under a non uniform transform) are converted into polylines, and
+// the subpaths of a path are drawn as separate shapes (no holes). The other
+// elements (defs, style, use, gradients, etc.) are ignored. The groups with an
+// id attribute are imported as groups (see BeginGroup).
+
+// affine is a 2D affine transformation [a b c d e f], mapping (x, y) to
+// (a*x + c*y + e, b*x + d*y + f) as the SVG matrix transform
+type affine [6]float64
+
+var affineIdentity = affine{1, 0, 0, 1, 0, 0}
+
+func (m affine) apply(x, y float64) point {
+	return point{m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]}
+}
+
+// multiply returns the transformation m followed by the transformation n
+// applied first (i.e. m x n)
+func (m affine) multiply(n affine) affine {
+	return affine{
+		m[0]*n[0] + m[2]*n[1], m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3], m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4], m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+// scale returns the mean scaling factor of the transformation (square root of
+// the absolute value of the determinant)
+func (m affine) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// conformal returns true if the transformation preserves the circles (rotation,
+// uniform scaling, reflection and translation)
+func (m affine) conformal() bool {
+	const eps = 1e-9
+	rotation := math.Abs(m[0]-m[3]) < eps && math.Abs(m[1]+m[2]) < eps
+	reflection := math.Abs(m[0]+m[3]) < eps && math.Abs(m[1]-m[2]) < eps
+	return rotation || reflection
+}
+
+// parseTransform returns the transformation defined by the value of the
+// transform attribute (list of matrix, translate, scale, rotate, skewX and
+// skewY functions). The parsing stops at the first invalid function.
+func parseTransform(value string) affine {
+	m := affineIdentity
+	for {
+		open := strings.IndexByte(value, '(')
+		close := strings.IndexByte(value, ')')
+		if open < 0 || close < open {
+			return m
+		}
+		name := strings.Trim(value[:open], " \t\r\n,")
+		args := parseNumbers(value[open+1 : close])
+		value = value[close+1:]
+		var t affine
+		switch {
+		case name == "matrix" && len(args) == 6:
+			t = affine(args)
+		case name == "translate" && len(args) == 1:
+			t = affine{1, 0, 0, 1, args[0], 0}
+		case name == "translate" && len(args) == 2:
+			t = affine{1, 0, 0, 1, args[0], args[1]}
+		case name == "scale" && len(args) == 1:
+			t = affine{args[0], 0, 0, args[0], 0, 0}
+		case name == "scale" && len(args) == 2:
+			t = affine{args[0], 0, 0, args[1], 0, 0}
+		case name == "rotate" && (len(args) == 1 || len(args) == 3):
+			cos, sin := cosd(args[0]), sind(args[0])
+			t = affine{cos, sin, -sin, cos, 0, 0}
+			if len(args) == 3 {
+				cx, cy := args[1], args[2]
+				t = affine{1, 0, 0, 1, cx, cy}.multiply(t).multiply(affine{1, 0, 0, 1, -cx, -cy})
+			}
+		case name == "skewX" && len(args) == 1:
+			t = affine{1, 0, math.Tan(args[0] * math.Pi / 180), 1, 0, 0}
+		case name == "skewY" && len(args) == 1:
+			t = affine{1, math.Tan(args[0] * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m
+		}
+		m = m.multiply(t)
+	}
+}
+
+// --------------------------------------------------------------------
+// Numbers, lengths and path data
+
+// numberScanner reads the numbers (and the commands of the path data) of an
+// attribute value, separated by white spaces and/or commas
+type numberScanner struct {
+	data string
+	pos  int
+}
+
+func (sc *numberScanner) skipSeparators() {
+	for sc.pos < len(sc.data) && strings.IndexByte(" \t\r\n,", sc.data[sc.pos]) >= 0 {
+		sc.pos++
+	}
+}
+
+// command returns the next path command letter, if any
+func (sc *numberScanner) command() (byte, bool) {
+	sc.skipSeparators()
+	if sc.pos < len(sc.data) && strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", sc.data[sc.pos]) >= 0 {
+		sc.pos++
+		return sc.data[sc.pos-1], true
+	}
+	return 0, false
+}
+
+// number returns the next number. The numbers may be written without
+// separators when there is no ambiguity (e.g. "1-2.5.5").
+func (sc *numberScanner) number() (float64, bool) {
+	sc.skipSeparators()
+	start := sc.pos
+	digits := func() int {
+		n := 0
+		for sc.pos < len(sc.data) && sc.data[sc.pos] >= '0' && sc.data[sc.pos] <= '9' {
+			sc.pos++
+			n++
+		}
+		return n
+	}
+	if sc.pos < len(sc.data) && (sc.data[sc.pos] == '+' || sc.data[sc.pos] == '-') {
+		sc.pos++
+	}
+	n := digits()
+	if sc.pos < len(sc.data) && sc.data[sc.pos] == '.' {
+		sc.pos++
+		n += digits()
+	}
+	if n == 0 {
+		sc.pos = start
+		return 0, false
+	}
+	if sc.pos < len(sc.data) && (sc.data[sc.pos] == 'e' || sc.data[sc.pos] == 'E') {
+		mark := sc.pos
+		sc.pos++
+		if sc.pos < len(sc.data) && (sc.data[sc.pos] == '+' || sc.data[sc.pos] == '-') {
+			sc.pos++
+		}
+		if digits() == 0 {
+			sc.pos = mark
+		}
+	}
+	v, err := strconv.ParseFloat(sc.data[start:sc.pos], 64)
+	return v, err == nil
+}
+
+// flag returns the next flag of an arc command (a single 0 or 1 digit, that
+// may be followed by the next number without separator)
+func (sc *numberScanner) flag() (bool, bool) {
+	sc.skipSeparators()
+	if sc.pos < len(sc.data) && (sc.data[sc.pos] == '0' || sc.data[sc.pos] == '1') {
+		sc.pos++
+		return sc.data[sc.pos-1] == '1', true
+	}
+	return false, false
+}
+
+// parseNumbers returns the list of numbers of the value (the parsing stops at
+// the first invalid number)
+func parseNumbers(value string) []float64 {
+	sc := &numberScanner{data: value}
+	var numbers []float64
+	for {
+		v, ok := sc.number()
+		if !ok {
+			return numbers
+		}
+		numbers = append(numbers, v)
+	}
+}
+
+// parseLength returns the length in pixels of the value, with an optional
+// unit (px, pt, pc, mm, cm, in, or em relatively to the font size fontsize).
+// The percentages are not supported.
+func parseLength(value string, fontsize float64) (float64, bool) {
+	value = strings.TrimSpace(value)
+	units := map[string]float64{
+		"px": 1, "pt": 1 / pointsPerPixel, "pc": 12 / pointsPerPixel,
+		"mm": 96 / 25.4, "cm": 96 / 2.54, "in": 96, "em": fontsize,
+	}
+	k := 1.
+	for unit, factor := range units {
+		if strings.HasSuffix(value, unit) {
+			value, k = strings.TrimSuffix(value, unit), factor
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v * k, true
+}
+
+// subpath is a polyline of a path, in canvas coordinates
+type subpath struct {
+	points []point
+	closed bool
+}
+
+// flattenCount returns the number of segments approximating a curve of length
+// about length pixels (segments of circleStepSize pixels)
+func flattenCount(length float64) int {
+	return min(max(int(math.Ceil(length/circleStepSize)), 4), 256)
+}
+
+// bezierPoints returns the points (except the first) of the Bézier curve
+// (quadratic or cubic) defined by the control points, in canvas coordinates
+func bezierPoints(ctrl ...point) []point {
+	length := 0.
+	for i := 1; i < len(ctrl); i++ {
+		length += math.Hypot(ctrl[i].X-ctrl[i-1].X, ctrl[i].Y-ctrl[i-1].Y)
+	}
+	n := flattenCount(length)
+	points := make([]point, n)
+	for i := range points {
+		// de Casteljau algorithm
+		t := float64(i+1) / float64(n)
+		q := append([]point(nil), ctrl...)
+		for k := len(q) - 1; k > 0; k-- {
+			for j := 0; j < k; j++ {
+				q[j] = point{q[j].X + t*(q[j+1].X-q[j].X), q[j].Y + t*(q[j+1].Y-q[j].Y)}
+			}
+		}
+		points[i] = q[0]
+	}
+	return points
+}
+
+// ellipsePoints returns the points of the elliptical arc of center (cx, cy),
+// radii rx and ry, whose x axis makes the angle phi (radians) with the x axis,
+// from the angle theta over the angle dtheta (radians), transformed by m
+func ellipsePoints(cx, cy, rx, ry, phi, theta, dtheta float64, m affine) []point {
+	n := flattenCount(math.Max(rx, ry) * m.scale() * math.Abs(dtheta))
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	points := make([]point, n+1)
+	for i := range points {
+		a := theta + dtheta*float64(i)/float64(n)
+		x, y := rx*math.Cos(a), ry*math.Sin(a)
+		points[i] = m.apply(cx+x*cos-y*sin, cy+x*sin+y*cos)
+	}
+	return points
+}
+
+// arcPathPoints returns the points (except the first) of the elliptical arc
+// from p1 to p2 of the path data (SVG endpoint parameterization, converted to
+// the center parameterization), transformed by m
+func arcPathPoints(p1, p2 point, rx, ry, angle float64, large, sweep bool, m affine) []point {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || p1 == p2 {
+		return []point{m.apply(p2.X, p2.Y)}
+	}
+	phi := angle * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (p1.X-p2.X)/2, (p1.Y-p2.Y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+	// the radii are scaled up if there is no solution
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	k := math.Sqrt(math.Max(num, 0) / den)
+	if large == sweep {
+		k = -k
+	}
+	cx1, cy1 := k*rx*y1/ry, -k*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (p1.X+p2.X)/2
+	cy := sin*cx1 + cos*cy1 + (p1.Y+p2.Y)/2
+	theta := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	dtheta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - theta
+	if sweep && dtheta < 0 {
+		dtheta += 2 * math.Pi
+	} else if !sweep && dtheta > 0 {
+		dtheta -= 2 * math.Pi
+	}
+	return ellipsePoints(cx, cy, rx, ry, phi, theta, dtheta, m)[1:]
+}
+
+// parsePath returns the subpaths of the path data d, transformed by m. The
+// parsing stops at the first error (the path is drawn up to the error, as
+// specified by SVG).
+func parsePath(d string, m affine) []subpath {
+	sc := &numberScanner{data: d}
+	var subpaths []subpath
+	var current *subpath
+	var cur, start, ctrl point // current point, start of the subpath, last control point
+	var cmd, last byte
+	numbers := func(n int) ([]float64, bool) {
+		args := make([]float64, n)
+		for i := range args {
+			v, ok := sc.number()
+			if !ok {
+				return nil, false
+			}
+			args[i] = v
+		}
+		return args, true
+	}
+	lineTo := func(p point, canvas ...point) {
+		if current == nil {
+			subpaths = append(subpaths, subpath{points: []point{m.apply(cur.X, cur.Y)}})
+			current = &subpaths[len(subpaths)-1]
+		}
+		if len(canvas) == 0 {
+			canvas = []point{m.apply(p.X, p.Y)}
+		}
+		current.points = append(current.points, canvas...)
+		cur = p
+	}
+	for {
+		if c, ok := sc.command(); ok {
+			cmd = c
+		} else if cmd == 0 || cmd == 'Z' || cmd == 'z' {
+			return subpaths
+		}
+		// the coordinates of the relative commands are relative to the
+		// current point
+		rel := cmd >= 'a'
+		offset := func(x, y float64) point {
+			if rel {
+				return point{cur.X + x, cur.Y + y}
+			}
+			return point{x, y}
+		}
+		upper := cmd &^ 0x20
+		switch upper {
+		case 'M':
+			args, ok := numbers(2)
+			if !ok {
+				return subpaths
+			}
+			cur = offset(args[0], args[1])
+			start = cur
+			subpaths = append(subpaths, subpath{points: []point{m.apply(cur.X, cur.Y)}})
+			current = &subpaths[len(subpaths)-1]
+			// the next coordinates pairs are implicit lineto commands
+			cmd = 'L' | (cmd & 0x20)
+		case 'L':
+			args, ok := numbers(2)
+			if !ok {
+				return subpaths
+			}
+			lineTo(offset(args[0], args[1]))
+		case 'H':
+			args, ok := numbers(1)
+			if !ok {
+				return subpaths
+			}
+			x := args[0]
+			if rel {
+				x += cur.X
+			}
+			lineTo(point{x, cur.Y})
+		case 'V':
+			args, ok := numbers(1)
+			if !ok {
+				return subpaths
+			}
+			y := args[0]
+			if rel {
+				y += cur.Y
+			}
+			lineTo(point{cur.X, y})
+		case 'C', 'S':
+			n := 6
+			if upper == 'S' {
+				n = 4
+			}
+			args, ok := numbers(n)
+			if !ok {
+				return subpaths
+			}
+			// the first control point of S is the reflection of the last
+			// control point of the previous cubic curve
+			c1 := cur
+			if upper == 'C' {
+				c1, args = offset(args[0], args[1]), args[2:]
+			} else if last == 'C' || last == 'S' {
+				c1 = point{2*cur.X - ctrl.X, 2*cur.Y - ctrl.Y}
+			}
+			c2, p := offset(args[0], args[1]), offset(args[2], args[3])
+			lineTo(p, bezierPoints(m.apply(cur.X, cur.Y), m.apply(c1.X, c1.Y), m.apply(c2.X, c2.Y), m.apply(p.X, p.Y))...)
+			ctrl = c2
+		case 'Q', 'T':
+			n := 4
+			if upper == 'T' {
+				n = 2
+			}
+			args, ok := numbers(n)
+			if !ok {
+				return subpaths
+			}
+			c := cur
+			if upper == 'Q' {
+				c, args = offset(args[0], args[1]), args[2:]
+			} else if last == 'Q' || last == 'T' {
+				c = point{2*cur.X - ctrl.X, 2*cur.Y - ctrl.Y}
+			}
+			p := offset(args[0], args[1])
+			lineTo(p, bezierPoints(m.apply(cur.X, cur.Y), m.apply(c.X, c.Y), m.apply(p.X, p.Y))...)
+			ctrl = c
+		case 'A':
+			args, ok := numbers(3)
+			if !ok {
+				return subpaths
+			}
+			large, ok1 := sc.flag()
+			sweep, ok2 := sc.flag()
+			end, ok3 := numbers(2)
+			if !ok1 || !ok2 || !ok3 {
+				return subpaths
+			}
+			p := offset(end[0], end[1])
+			lineTo(p, arcPathPoints(cur, p, args[0], args[1], args[2], large, sweep, m)...)
+		case 'Z':
+			if current != nil {
+				current.closed = true
+				current = nil
+			}
+			cur = start
+		}
+		last = upper
+	}
+}
+
+// --------------------------------------------------------------------
+// Styles
+
+// svgStyle is the style of an element, inherited from its parent
+type svgStyle struct {
+	stroke, fill           string
+	strokeWidth            float64
+	dash                   []float64
+	fontFamily, fontWeight string
+	fontSize               float64
+	color                  string // value of currentColor
+	transform              affine // transformation to the canvas coordinates
+}
+
+// initial values of the SVG properties
+var defaultSVGStyle = svgStyle{
+	stroke: NoColor, fill: "black", strokeWidth: 1,
+	fontFamily: "serif", fontWeight: "normal", fontSize: 16,
+	color: "black", transform: affineIdentity,
+}
+
+// paint returns the color of the paint value (the paint servers such as the
+// gradients are replaced by their fallback color, if any)
+func (st svgStyle) paint(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "url(") {
+		fallback := NoColor
+		if i := strings.IndexByte(value, ')'); i > 0 && strings.TrimSpace(value[i+1:]) != "" {
+			fallback = strings.TrimSpace(value[i+1:])
+		}
+		value = fallback
+	}
+	if value == "currentColor" {
+		value = st.color
+	}
+	return value
+}
+
+// inherit returns the style of the element whose attributes are attrs, as a
+// child of the element of style st. It returns false if the element is not
+// displayed.
+func (st svgStyle) inherit(attrs []xml.Attr) (svgStyle, bool) {
+	properties := map[string]string{}
+	for _, attr := range attrs {
+		properties[attr.Name.Local] = attr.Value
+	}
+	// the properties of the style attribute override the attributes
+	for _, declaration := range strings.Split(properties["style"], ";") {
+		name, value, ok := strings.Cut(declaration, ":")
+		if ok {
+			properties[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	if properties["display"] == "none" || properties["visibility"] == "hidden" {
+		return st, false
+	}
+	st.dash = append([]float64(nil), st.dash...)
+	if v, ok := properties["transform"]; ok {
+		st.transform = st.transform.multiply(parseTransform(v))
+	}
+	if v, ok := properties["color"]; ok && v != "inherit" {
+		st.color = v
+	}
+	if v, ok := properties["font-size"]; ok {
+		if size, ok := parseLength(v, st.fontSize); ok {
+			st.fontSize = size
+		}
+	}
+	if v, ok := properties["stroke"]; ok && v != "inherit" {
+		st.stroke = st.paint(v)
+	}
+	if v, ok := properties["fill"]; ok && v != "inherit" {
+		st.fill = st.paint(v)
+	}
+	if v, ok := properties["stroke-width"]; ok {
+		if width, ok := parseLength(v, st.fontSize); ok {
+			st.strokeWidth = width
+		}
+	}
+	if v, ok := properties["stroke-dasharray"]; ok && v != "inherit" {
+		st.dash = parseNumbers(v)
+	}
+	if v, ok := properties["font-family"]; ok && v != "inherit" {
+		st.fontFamily = strings.Trim(v, `'"`)
+	}
+	if v, ok := properties["font-weight"]; ok && v != "inherit" {
+		st.fontWeight = v
+	}
+	return st, true
+}
+
+// pencil returns the pencil of the style (the sizes are scaled by the
+// transformation)
+func (st svgStyle) pencil() *Pencil {
+	scale := st.transform.scale()
+	p := NewPencil(st.stroke, 0)
+	if st.stroke != NoColor && st.strokeWidth > 0 {
+		p.LineWidth = max(int(math.Round(st.strokeWidth*scale)), 1)
+	}
+	p.FillColor = st.fill
+	p.FillMode = st.fill != NoColor
+	for _, l := range st.dash {
+		p.Dash = append(p.Dash, l*scale)
+	}
+	p.FontFamily = st.fontFamily
+	p.FontWeight = st.fontWeight
+	p.FontSize = max(int(math.Round(st.fontSize*scale)), 1)
+	p.FontColor = st.fill
+	return p
+}
+
+// --------------------------------------------------------------------
+// Elements
+
+type svgImporter struct {
+	s       *Sketcher
+	decoder *xml.Decoder
+	shapes  int // number of the drawn shapes
+}
+
+// user returns the user coordinates of the canvas points
+func (im *svgImporter) user(points []point) []point {
+	upoints := make([]point, len(points))
+	for i, p := range points {
+		upoints[i].X, upoints[i].Y = im.s.cs.userCoordinates(p.X, p.Y)
+	}
+	return upoints
+}
+
+// svgLengths returns the numeric attributes names of the element (lengths in
+// pixels, 0 if missing or invalid)
+func svgLengths(attrs []xml.Attr, fontsize float64, names ...string) []float64 {
+	values := make([]float64, len(names))
+	for i, name := range names {
+		for _, attr := range attrs {
+			if attr.Name.Local == name {
+				values[i], _ = parseLength(attr.Value, fontsize)
+			}
+		}
+	}
+	return values
+}
+
+// svgAttribute returns the value of the attribute name, and false if missing
+func svgAttribute(attrs []xml.Attr, name string) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// draw draws the subpaths (canvas coordinates) with the pencil of the style.
+// The subpaths that are not closed are filled as if they were closed, then
+// stroked as polylines.
+func (im *svgImporter) draw(st svgStyle, subpaths ...subpath) {
+	s := im.s
+	saved := s.Pencil
+	defer func() { s.Pencil = saved }()
+	p := st.pencil()
+	s.Pencil = p
+	for _, sp := range subpaths {
+		if len(sp.points) < 2 || !finitePoints(sp.points...) {
+			continue
+		}
+		points := im.user(sp.points)
+		im.shapes++
+		switch {
+		case sp.closed:
+			s.Polygon(points, p.FillMode)
+		case len(points) == 2:
+			s.Edge(points[0].X, points[0].Y, points[1].X, points[1].Y)
+		default:
+			if p.FillMode && len(points) > 2 {
+				fill := *p
+				fill.LineWidth = 0
+				s.Pencil = &fill
+				s.Polygon(points, true)
+				s.Pencil = p
+			}
+			if p.LineWidth > 0 {
+				// the polyline is recorded as a single shape, to keep the
+				// dash pattern along the polyline
+				s.record(shape{kind: shapePolyline, points: sp.points})
+				last := points[len(points)-1]
+				s.MoveTo(last.X, last.Y)
+			}
+		}
+	}
+}
+
+// circle draws the ellipse of center (cx, cy) and radii rx and ry (local
+// coordinates). The circles are kept as circles if the transformation
+// preserves them.
+func (im *svgImporter) circle(st svgStyle, cx, cy, rx, ry float64) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	m := st.transform
+	if rx != ry || !m.conformal() {
+		im.draw(st, subpath{ellipsePoints(cx, cy, rx, ry, 0, 0, 2*math.Pi, m)[1:], true})
+		return
+	}
+	s := im.s
+	c := m.apply(cx, cy)
+	if !finitePoints(c) {
+		return
+	}
+	saved := s.Pencil
+	s.Pencil = st.pencil()
+	center := im.user([]point{c})[0]
+	s.Circle(center.X, center.Y, rx*m.scale()/s.cs.unit2pixel, s.Pencil.FillMode)
+	s.Pencil = saved
+	im.shapes++
+}
+
+// text reads the content of the text element (the text of the tspan
+// children is appended) and draws the text
+func (im *svgImporter) text(st svgStyle, attrs []xml.Attr) error {
+	var content strings.Builder
+	for depth := 1; depth > 0; {
+		token, err := im.decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			content.Write(t)
+		}
+	}
+	text := strings.Join(strings.Fields(content.String()), " ")
+	if text == "" || st.fill == NoColor {
+		return nil
+	}
+	xy := svgLengths(attrs, st.fontSize, "x", "y")
+	c := st.transform.apply(xy[0], xy[1])
+	if !finitePoints(c) {
+		return nil
+	}
+	s := im.s
+	saved := s.Pencil
+	s.Pencil = st.pencil()
+	p := im.user([]point{c})[0]
+	s.Text(p.X, p.Y, text)
+	s.Pencil = saved
+	im.shapes++
+	return nil
+}
+
+// image draws the image element (the transformation is applied to the
+// rectangle of the image, that remains upright)
+func (im *svgImporter) image(st svgStyle, attrs []xml.Attr) {
+	href, ok := svgAttribute(attrs, "href") // xlink:href or href
+	if !ok {
+		return
+	}
+	r := svgLengths(attrs, st.fontSize, "x", "y", "width", "height")
+	m := st.transform
+	p1, p2 := m.apply(r[0], r[1]), m.apply(r[0]+r[2], r[1]+r[3])
+	corners := im.user([]point{p1, p2})
+	s := im.s
+	saved := s.ImageStyle
+	if v, ok := svgAttribute(attrs, "preserveAspectRatio"); ok {
+		s.ImageStyle.AspectRatio = v
+	}
+	if v, ok := svgAttribute(attrs, "opacity"); ok {
+		if opacity, err := strconv.ParseFloat(v, 64); err == nil {
+			s.ImageStyle.Opacity = opacity
+		}
+	}
+	x, y := math.Min(corners[0].X, corners[1].X), math.Min(corners[0].Y, corners[1].Y)
+	s.imageHref(x, y, math.Abs(corners[1].X-corners[0].X), math.Abs(corners[1].Y-corners[0].Y), href)
+	s.ImageStyle = saved
+	im.shapes++
+}
+
+// background returns true if the rect element is the background of the
+// document written by the sketcher (the first shape of the document, a
+// rectangle without stroke covering the canvas), then the background color
+// of the sketcher is set to its fill color
+func (im *svgImporter) background(st svgStyle, r []float64, width, height float64) bool {
+	if im.shapes > 0 || st.transform != affineIdentity || st.stroke != NoColor || st.fill == NoColor {
+		return false
+	}
+	if r[0] != 0 || r[1] != 0 || r[2] != width || r[3] != height {
+		return false
+	}
+	im.s.backgroundColor = st.fill
+	im.shapes++
+	return true
+}
+
+// element imports the element start and its children, with the style of its
+// parent. width and height are the size of the document.
+func (im *svgImporter) element(start xml.StartElement, parent svgStyle, width, height float64) error {
+	st, visible := parent.inherit(start.Attr)
+	if !visible {
+		return im.decoder.Skip()
+	}
+	attrs := start.Attr
+	switch start.Name.Local {
+	case "g", "a", "switch", "svg":
+		id, named := svgAttribute(attrs, "id")
+		named = named && start.Name.Local == "g"
+		if named {
+			im.s.BeginGroup(id)
+		}
+		if err := im.children(st, width, height); err != nil {
+			return err
+		}
+		if named {
+			im.s.EndGroup()
+		}
+		return nil
+	case "text":
+		return im.text(st, attrs)
+	case "line":
+		v := svgLengths(attrs, st.fontSize, "x1", "y1", "x2", "y2")
+		m := st.transform
+		im.draw(st, subpath{points: []point{m.apply(v[0], v[1]), m.apply(v[2], v[3])}})
+	case "rect":
+		r := svgLengths(attrs, st.fontSize, "x", "y", "width", "height")
+		if r[2] > 0 && r[3] > 0 && !im.background(st, r, width, height) {
+			m := st.transform
+			x1, y1, x2, y2 := r[0], r[1], r[0]+r[2], r[1]+r[3]
+			im.draw(st, subpath{[]point{m.apply(x1, y1), m.apply(x2, y1), m.apply(x2, y2), m.apply(x1, y2)}, true})
+		}
+	case "circle":
+		c := svgLengths(attrs, st.fontSize, "cx", "cy", "r")
+		im.circle(st, c[0], c[1], c[2], c[2])
+	case "ellipse":
+		e := svgLengths(attrs, st.fontSize, "cx", "cy", "rx", "ry")
+		im.circle(st, e[0], e[1], e[2], e[3])
+	case "polygon", "polyline":
+		value, _ := svgAttribute(attrs, "points")
+		v := parseNumbers(value)
+		points := make([]point, len(v)/2)
+		for i := range points {
+			points[i] = st.transform.apply(v[2*i], v[2*i+1])
+		}
+		im.draw(st, subpath{points, start.Name.Local == "polygon"})
+	case "path":
+		d, _ := svgAttribute(attrs, "d")
+		im.draw(st, parsePath(d, st.transform)...)
+	case "image":
+		im.image(st, attrs)
+	}
+	return im.decoder.Skip()
+}
+
+// children imports the children of the current element, until its end
+func (im *svgImporter) children(st svgStyle, width, height float64) error {
+	for {
+		token, err := im.decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := im.element(t, st, width, height); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// svgViewport returns the size of the document (the width and height attributes,
+// or the size of the view box) and the transformation of the view box to the
+// viewport (uniform scaling, centered)
+func svgViewport(attrs []xml.Attr) (width, height float64, m affine) {
+	m = affineIdentity
+	viewbox, _ := svgAttribute(attrs, "viewBox")
+	box := parseNumbers(viewbox)
+	w, _ := svgAttribute(attrs, "width")
+	h, _ := svgAttribute(attrs, "height")
+	width, wok := parseLength(w, defaultSVGStyle.fontSize)
+	height, hok := parseLength(h, defaultSVGStyle.fontSize)
+	if len(box) != 4 || box[2] <= 0 || box[3] <= 0 {
+		return width, height, m
+	}
+	if !wok {
+		width = box[2]
+	}
+	if !hok {
+		height = box[3]
+	}
+	k := math.Min(width/box[2], height/box[3])
+	tx := (width-k*box[2])/2 - k*box[0]
+	ty := (height-k*box[3])/2 - k*box[1]
+	return width, height, affine{k, 0, 0, k, tx, ty}
+}
+
+// ImportSVG reads the SVG document from r and draws its elements with the
+// sketcher (see the supported features above). The coordinates of the
+// document are interpreted as canvas coordinates of the coordinates system of
+// the sketcher, that should have the size of the document to get the same
+// picture. The pencil and the image style of the sketcher are left unchanged.
+func (s *Sketcher) ImportSVG(r io.Reader) error {
+	im := &svgImporter{s: s, decoder: xml.NewDecoder(r)}
+	// the documents are expected to be encoded in UTF-8
+	im.decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil }
+	for {
+		token, err := im.decoder.Token()
+		if err == io.EOF {
+			return fmt.Errorf("no svg element found")
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local != "svg" {
+				return fmt.Errorf("the root element is %s and should be svg", start.Name.Local)
+			}
+			width, height, m := svgViewport(start.Attr)
+			st, _ := defaultSVGStyle.inherit(start.Attr)
+			st.transform = m
+			return im.children(st, width, height)
+		}
+	}
+}
+
+// LoadSVG reads the SVG file svgpath and draws its elements with the sketcher
+// (see ImportSVG)
+func (s *Sketcher) LoadSVG(svgpath string) error {
+	file, err := os.Open(svgpath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.ImportSVG(file)
+}
@@ -0,0 +1,125 @@
+package svg
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSVGImport_RoundTrip(t *testing.T) {
+	s := NewSketcher().WithBackgroundColor("white")
+	s.BeginGroup("walls")
+	s.Edge(0.2, 0.2, 0.8, 0.8)
+	s.Pencil.FillColor = "blue"
+	s.Pencil.Dash = []float64{10, 5}
+	s.Polygon(testpoints(), true)
+	s.EndGroup()
+	s.Pencil.Dash = nil
+	s.Circle(0.5, 0.5, 0.1, false)
+	s.Pencil.FontWeight = "bold"
+	s.Text(0.1, 0.9, "label")
+
+	// The imported sketch is identical to the original sketch
+	ref := s.ToSVG()
+	imported := NewSketcher()
+	if err := imported.ImportSVG(strings.NewReader(ref)); err != nil {
+		t.Fatal(err)
+	}
+	imported.Save("output.TestSVGImport_RoundTrip.svg")
+	if res := imported.ToSVG(); res != ref {
+		t.Errorf("result is:\n%s\nShould be:\n%s", res, ref)
+	}
+}
+
+const input_TestSVGImport_Transforms string = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200" viewBox="0 0 100 100">
+<defs><linearGradient id="g"/></defs>
+<g id="box" transform="translate(10,20)" style="stroke: blue">
+<rect x="0" y="0" width="30" height="10" fill="red" stroke-width="1"/>
+</g>
+<polyline points="0,0 50,0 50,50" stroke="green" stroke-dasharray="4" fill="none"/>
+<circle cx="50" cy="50" r="10" fill="none" stroke="black" display="none"/>
+<text x="10" y="90" font-size="10px" fill="url(#g) gray">Hello <tspan>world</tspan></text>
+</svg>`
+
+const output_TestSVGImport_Transforms string = `<svg xmlns='http://www.w3.org/2000/svg' width='200' height='200'>
+<g id='box'>
+<polygon points='20.00,40.00 80.00,40.00 80.00,60.00 20.00,60.00' style='stroke: blue; stroke-width: 2; fill: red'/>
+</g>
+<polyline points='0.00,0.00 100.00,0.00 100.00,100.00' style='stroke: green; stroke-width: 2; fill: none; stroke-dasharray: 8'/>
+<text x='20.00' y='180.00' style='font-family:serif; font-size:20; font-weight:normal; fill: gray'>Hello world</text>
+</svg>`
+
+func TestSVGImport_Transforms(t *testing.T) {
+	// The document is imported in a coordinates system whose origin is the
+	// bottom left corner of the canvas, with 100 pixels per unit
+	cs := NewCoordSysBottomLeft(200, 200, 2)
+	s := NewSketcher().WithCoordinateSystem(cs)
+	if err := s.ImportSVG(strings.NewReader(input_TestSVGImport_Transforms)); err != nil {
+		t.Fatal(err)
+	}
+	res := s.ToSVG()
+	ref := output_TestSVGImport_Transforms
+	if res != ref {
+		t.Errorf("result is:\n%s\nShould be:\n%s", res, ref)
+	}
+
+	// The position is the last point of the polyline, in user coordinates
+	x, y := s.Position()
+	if math.Abs(x-1) > 1e-9 || math.Abs(y-1) > 1e-9 {
+		t.Errorf("the position is (%.2f,%.2f) and should be (1.00,1.00)", x, y)
+	}
+	if s.Pencil.LineColor != DefaultLineColor || s.Pencil.FontFamily != DefaultFontFamily {
+		t.Errorf("the pencil of the sketcher should be left unchanged, not %v", *s.Pencil)
+	}
+}
+
+func TestSVGImport_PathData(t *testing.T) {
+	// the numbers may be written without separators, and the commands may
+	// be repeated implicitly
+	subpaths := parsePath("M10 10h20v20H10z m5,5 1-1.5.5.5", affineIdentity)
+	if len(subpaths) != 2 {
+		t.Fatalf("the path has %d subpaths and should have 2", len(subpaths))
+	}
+	square := []point{{10, 10}, {30, 10}, {30, 30}, {10, 30}}
+	if !subpaths[0].closed || len(subpaths[0].points) != 4 {
+		t.Fatalf("the first subpath should be the closed square %v, not %v", square, subpaths[0])
+	}
+	for i, p := range square {
+		if subpaths[0].points[i] != p {
+			t.Errorf("the point %d of the square is %v and should be %v", i, subpaths[0].points[i], p)
+		}
+	}
+	ref := []point{{15, 15}, {16, 13.5}, {16.5, 14}}
+	if res := subpaths[1].points; len(res) != 3 || res[0] != ref[0] || res[1] != ref[1] || res[2] != ref[2] {
+		t.Errorf("the second subpath is %v and should be %v", res, ref)
+	}
+
+	// The arc goes in the direction of the positive angles (clockwise on the
+	// screen) from (0,0) to (20,0), through (10,-10)
+	points := parsePath("M0 0 A10 10 0 0 1 20 0", affineIdentity)[0].points
+	last := points[len(points)-1]
+	if math.Abs(last.X-20) > 1e-9 || math.Abs(last.Y) > 1e-9 {
+		t.Errorf("the arc ends at %v and should end at (20,0)", last)
+	}
+	ymin := 0.
+	for _, p := range points {
+		ymin = math.Min(ymin, p.Y)
+	}
+	if math.Abs(ymin+10) > 0.1 {
+		t.Errorf("the top of the arc is at y=%.2f and should be at y=-10", ymin)
+	}
+}
+
+func TestSVGImport_Errors(t *testing.T) {
+	s := NewSketcher()
+	if err := s.ImportSVG(strings.NewReader("<html></html>")); err == nil {
+		t.Errorf("the import of a document that is not an SVG document should fail")
+	}
+	if err := s.ImportSVG(strings.NewReader("<svg><line x1='0'")); err == nil {
+		t.Errorf("the import of an invalid XML document should fail")
+	}
+	if err := s.LoadSVG("nofile.svg"); err == nil {
+		t.Errorf("the import of a missing file should fail")
+	}
+}
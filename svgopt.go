@@ -0,0 +1,394 @@
+package svg
+
+import (
+	"compress/gzip"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ===========================================================================
+// Optimized SVG output (minifier)
+// ===========================================================================
+
+// The optimized SVG backend writes the picture of ToSVG with fewer bytes: the
+// numbers are written with the required precision only (without trailing
+// zeros and leading zero), the style declarations equal to the SVG initial
+// values are removed, the styles used by several elements are shared as CSS
+// classes, the connected lines drawn with the same style are merged into
+// polylines (with the intermediate points of collinear segments removed), and
+// the lines, polylines, polygons and arcs are written as compact path data
+// (relative and implicit commands). The picture differs from the one of ToSVG
+// in two ways: the texts are escaped (ToSVG writes them as they are), and the
+// merged lines are joined at their common points, where the separate lines
+// overlap with their ends (which shows with translucent colors).
+
+// SVGOptions defines the optimizations of the SVG output. A negative
+// Precision is the precision of the sketcher (see Sketcher.WithPrecision).
+type SVGOptions struct {
+	Precision    int  // number of decimals of the coordinates (the precision of the sketcher if negative)
+	ShareStyles  bool // if true, the repeated styles are shared as CSS classes
+	MergeLines   bool // if true, the connected lines are merged into polylines
+	CompactPaths bool // if true, the lines, polylines and polygons are paths with relative and implicit commands
+}
+
+var DefaultSVGOptions = SVGOptions{
+	Precision:    -1,
+	ShareStyles:  true,
+	MergeLines:   true,
+	CompactPaths: true,
+}
+
+// svgElement is an element of the optimized document, whose style is given
+// separately to be possibly replaced by a class
+type svgElement struct {
+	head  string // beginning of the element, before the style
+	style string
+	tail  string // end of the element, after the style
+}
+
+type svgOptimizer struct {
+	opts     SVGOptions
+	elements []svgElement
+	x, y     float64 // current point
+
+	// connected lines not yet written
+	lines     []point
+	lineStyle string
+}
+
+// num returns the shortest writing of v with the precision of the options
+// (e.g. ".5" for 0.5)
+func (o *svgOptimizer) num(v float64) string {
+	s := fmtnum(v, o.opts.Precision)
+	if strings.HasPrefix(s, "0.") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "-0.") {
+		s = "-" + s[2:]
+	}
+	return s
+}
+
+// nums returns the numbers separated by a space, except where the separator
+// is not required (before a minus sign, or before a decimal point following a
+// number with decimals)
+func (o *svgOptimizer) nums(values ...float64) string {
+	var b strings.Builder
+	previous := ""
+	for i, v := range values {
+		s := o.num(v)
+		decimal := strings.Contains(previous, ".") && strings.HasPrefix(s, ".")
+		if i > 0 && !strings.HasPrefix(s, "-") && !decimal {
+			b.WriteString(" ")
+		}
+		b.WriteString(s)
+		previous = s
+	}
+	return b.String()
+}
+
+// drawStyle returns the style of the shapes drawn with the pencil p, without
+// the declarations equal to the initial values (stroke: none, stroke-width: 1
+// and fill: black). fill is the fill color, or "" if the fill does not apply.
+func (o *svgOptimizer) drawStyle(p Pencil, fill string) string {
+	var decls []string
+	if p.LineWidth > 0 && p.LineColor != NoColor {
+		decls = append(decls, "stroke:"+p.LineColor)
+		if p.LineWidth != 1 {
+			decls = append(decls, fmt.Sprintf("stroke-width:%d", p.LineWidth))
+		}
+		if len(p.Dash) > 0 {
+			decls = append(decls, "stroke-dasharray:"+p.dashArray())
+		}
+	}
+	if fill != "" && fill != "black" {
+		decls = append(decls, "fill:"+fill)
+	}
+	return strings.Join(decls, ";")
+}
+
+func (o *svgOptimizer) add(head, style, tail string) {
+	o.flushLines()
+	o.elements = append(o.elements, svgElement{head, style, tail})
+}
+
+// flushLines writes the pending connected lines, as a line or a polyline (the
+// lines without stroke are not visible and then removed)
+func (o *svgOptimizer) flushLines() {
+	lines := o.lines
+	o.lines = nil
+	switch {
+	case o.lineStyle == "":
+	case o.opts.CompactPaths && len(lines) > 1:
+		o.elements = append(o.elements, svgElement{"<path d='" + o.pathData(lines, false) + "'", o.lineStyle + ";fill:none", "/>"})
+	case len(lines) == 2:
+		o.elements = append(o.elements, svgElement{
+			fmt.Sprintf("<line x1='%s' y1='%s' x2='%s' y2='%s'", o.num(lines[0].X), o.num(lines[0].Y), o.num(lines[1].X), o.num(lines[1].Y)),
+			o.lineStyle, "/>",
+		})
+	case len(lines) > 2:
+		o.elements = append(o.elements, svgElement{"<polyline points='" + o.points(lines) + "'", o.lineStyle + ";fill:none", "/>"})
+	}
+}
+
+// pathData returns the path data of the polyline of the points (closed if
+// closed is true) with relative commands, the horizontal and vertical moves
+// being written with h and v, and the repeated commands being implicit. The
+// coordinates are rounded before the differences, so that the rounding errors
+// do not add up along the path.
+func (o *svgOptimizer) pathData(points []point, closed bool) string {
+	k := math.Pow10(o.opts.Precision)
+	round := func(v float64) float64 { return math.Round(v*k) / k }
+	x, y := round(points[0].X), round(points[0].Y)
+	var b strings.Builder
+	b.WriteString("M" + o.nums(x, y))
+	// the values of the run of the same command
+	command, values := "", []float64(nil)
+	flush := func() {
+		if command != "" {
+			b.WriteString(command + o.nums(values...))
+		}
+	}
+	for _, p := range points[1:] {
+		px, py := round(p.X), round(p.Y)
+		dx, dy := px-x, py-y
+		x, y = px, py
+		c, v := "l", []float64{dx, dy}
+		switch {
+		case dx == 0 && dy == 0:
+			continue
+		case dy == 0:
+			c, v = "h", []float64{dx}
+		case dx == 0:
+			c, v = "v", []float64{dy}
+		}
+		if c != command {
+			flush()
+			command, values = c, nil
+		}
+		values = append(values, v...)
+	}
+	flush()
+	if closed {
+		b.WriteString("z")
+	}
+	return b.String()
+}
+
+func (o *svgOptimizer) points(points []point) string {
+	values := make([]float64, 0, 2*len(points))
+	for _, p := range points {
+		values = append(values, p.X, p.Y)
+	}
+	return o.nums(values...)
+}
+
+// collinear returns true if the point q is on the half line starting from p
+// in the direction of p - o (the point p can be removed from the polyline
+// o, p, q)
+func collinear(o, p, q point) bool {
+	ux, uy := p.X-o.X, p.Y-o.Y
+	vx, vy := q.X-p.X, q.Y-p.Y
+	cross := ux*vy - uy*vx
+	return math.Abs(cross) <= 1e-9*math.Hypot(ux, uy)*math.Hypot(vx, vy) && ux*vx+uy*vy >= 0
+}
+
+func (o *svgOptimizer) Begin(width, height int, background string) {
+	o.add(fmt.Sprintf("<svg xmlns='http://www.w3.org/2000/svg' width='%d' height='%d'>", width, height), "", "")
+	if background != Transparent {
+		o.add(fmt.Sprintf("<rect width='%d' height='%d' fill='%s'/>", width, height, background), "", "")
+	}
+}
+
+func (o *svgOptimizer) End() {
+	o.add(footPattern, "", "")
+}
+
+func (o *svgOptimizer) MoveTo(x, y float64) {
+	o.x, o.y = x, y
+}
+
+func (o *svgOptimizer) LineTo(x, y float64, p Pencil) {
+	start, end := point{o.x, o.y}, point{x, y}
+	o.x, o.y = x, y
+	style := o.drawStyle(p, "")
+	n := len(o.lines)
+	// the dashed lines are not merged, since the dash pattern restarts at
+	// the beginning of each line
+	if o.opts.MergeLines && len(p.Dash) == 0 && n > 0 && style == o.lineStyle && o.lines[n-1] == start {
+		if n > 1 && collinear(o.lines[n-2], start, end) {
+			o.lines[n-1] = end
+		} else {
+			o.lines = append(o.lines, end)
+		}
+		return
+	}
+	o.flushLines()
+	o.lines, o.lineStyle = []point{start, end}, style
+}
+
+func (o *svgOptimizer) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	if o.opts.CompactPaths && len(points) > 0 {
+		fillcolor := NoColor
+		if closed && fill {
+			fillcolor = p.FillColor
+		}
+		o.add("<path d='"+o.pathData(points, closed)+"'", o.drawStyle(p, fillcolor), "/>")
+		return
+	}
+	if closed {
+		fillcolor := NoColor
+		if fill {
+			fillcolor = p.FillColor
+		}
+		o.add("<polygon points='"+o.points(points)+"'", o.drawStyle(p, fillcolor), "/>")
+	} else {
+		o.add("<polyline points='"+o.points(points)+"'", o.drawStyle(p, NoColor), "/>")
+	}
+}
+
+func (o *svgOptimizer) Circle(cx, cy, r float64, fill bool, p Pencil) {
+	fillcolor := NoColor
+	if fill {
+		fillcolor = p.FillColor
+	}
+	o.add(fmt.Sprintf("<circle cx='%s' cy='%s' r='%s'", o.num(cx), o.num(cy), o.num(r)), o.drawStyle(p, fillcolor), "/>")
+}
+
+func (o *svgOptimizer) Arc(cx, cy, r, startAngle, sweepAngle float64, p Pencil) {
+	a1 := startAngle * math.Pi / 180
+	a2 := (startAngle + sweepAngle) * math.Pi / 180
+	largeArc, sweep := 0, 0
+	if math.Abs(sweepAngle) > 180 {
+		largeArc = 1
+	}
+	if sweepAngle > 0 {
+		sweep = 1
+	}
+	x1, y1 := cx+r*math.Cos(a1), cy+r*math.Sin(a1)
+	x2, y2 := cx+r*math.Cos(a2), cy+r*math.Sin(a2)
+	cmd := "A"
+	if o.opts.CompactPaths {
+		// the end point is written relatively to the start point, as
+		// rounded in the document
+		cmd = "a"
+		k := math.Pow10(o.opts.Precision)
+		x2 -= math.Round(x1*k) / k
+		y2 -= math.Round(y1*k) / k
+	}
+	d := "M" + o.nums(x1, y1) + cmd + o.nums(r, r, 0, float64(largeArc), float64(sweep), x2, y2)
+	o.add("<path d='"+d+"'", o.drawStyle(p, NoColor), "/>")
+}
+
+func (o *svgOptimizer) Text(x, y float64, text string, p Pencil) {
+	decls := []string{"font-family:" + p.FontFamily, fmt.Sprintf("font-size:%dpx", p.FontSize)}
+	if p.FontWeight != "normal" {
+		decls = append(decls, "font-weight:"+p.FontWeight)
+	}
+	if p.FontColor != "black" {
+		decls = append(decls, "fill:"+p.FontColor)
+	}
+	o.add(fmt.Sprintf("<text x='%s' y='%s'", o.num(x), o.num(y)), strings.Join(decls, ";"), ">"+html.EscapeString(text)+"</text>")
+}
+
+func (o *svgOptimizer) Image(x, y, width, height float64, href string, style ImageStyle) {
+	head := fmt.Sprintf("<image xmlns:xlink='%s' x='%s' y='%s' width='%s' height='%s'", xlinkNamespace, o.num(x), o.num(y), o.num(width), o.num(height))
+	if style.AspectRatio != DefaultImageAspectRatio {
+		head += fmt.Sprintf(" preserveAspectRatio='%s'", style.AspectRatio)
+	}
+	if style.Opacity < 1 {
+		head += fmt.Sprintf(" opacity='%s'", o.num(style.Opacity))
+	}
+	o.add(head+fmt.Sprintf(" xlink:href='%s'/>", html.EscapeString(href)), "", "")
+}
+
+func (o *svgOptimizer) BeginGroup(name string) {
+	o.add(fmt.Sprintf(groupPattern, html.EscapeString(name)), "", "")
+}
+
+func (o *svgOptimizer) EndGroup() {
+	o.add(groupEnd, "", "")
+}
+
+// className returns the name of the i-th CSS class (a, b, ..., z, ba, bb, ...)
+func className(i int) string {
+	name := string(rune('a' + i%26))
+	for i /= 26; i > 0; i /= 26 {
+		name = string(rune('a'+i%26)) + name
+	}
+	return name
+}
+
+// document returns the optimized document. The styles used by several
+// elements are replaced by classes, defined in a style element.
+func (o *svgOptimizer) document() string {
+	counts := map[string]int{}
+	var styles []string // shared styles, in the order of their first use
+	for _, e := range o.elements {
+		if e.style == "" {
+			continue
+		}
+		counts[e.style]++
+		if counts[e.style] == 2 && o.opts.ShareStyles {
+			styles = append(styles, e.style)
+		}
+	}
+	classes := map[string]string{}
+	var b strings.Builder
+	for i, e := range o.elements {
+		b.WriteString(e.head)
+		switch name, ok := classes[e.style]; {
+		case ok:
+			fmt.Fprintf(&b, " class='%s'", name)
+		case e.style != "":
+			fmt.Fprintf(&b, " style='%s'", e.style)
+		}
+		b.WriteString(e.tail)
+		if i == 0 && len(styles) > 0 {
+			// style element, just after the svg element
+			b.WriteString("<style>")
+			for j, style := range styles {
+				classes[style] = className(j)
+				fmt.Fprintf(&b, ".%s{%s}", className(j), style)
+			}
+			b.WriteString("</style>")
+		}
+	}
+	return b.String()
+}
+
+// ToOptimizedSVG returns the sketch as a compact SVG document (see
+// SVGOptions). The document draws the picture of ToSVG, but for the escaped
+// texts and the joins of the merged lines.
+func (s Sketcher) ToOptimizedSVG(opts SVGOptions) string {
+	if opts.Precision < 0 {
+		opts.Precision = s.precision
+	}
+	o := &svgOptimizer{opts: opts}
+	s.Render(o)
+	return o.document()
+}
+
+// SaveOptimizedSVG saves the sketch as a compact SVG file (see
+// ToOptimizedSVG). The file is compressed with gzip if its extension is
+// .svgz.
+func (s Sketcher) SaveOptimizedSVG(svgpath string, opts SVGOptions) error {
+	file, err := os.OpenFile(svgpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if !strings.HasSuffix(strings.ToLower(svgpath), ".svgz") {
+		_, err = file.WriteString(s.ToOptimizedSVG(opts))
+		return err
+	}
+	zw := gzip.NewWriter(file)
+	if _, err := io.WriteString(zw, s.ToOptimizedSVG(opts)); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,123 @@
+package svg
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+const output_TestSVGOptimizer_ToOptimizedSVG string = "<svg xmlns='http://www.w3.org/2000/svg' width='600' height='600'>" +
+	"<style>.a{stroke:black;stroke-width:2;fill:none}</style>" +
+	"<rect width='600' height='600' fill='white'/>" +
+	"<g id='walls'>" +
+	"<path d='M120 480h360v-360' class='a'/>" +
+	"<path d='M120 120h60' style='stroke:black;stroke-width:2;stroke-dasharray:10,5;fill:none'/>" +
+	"<path d='M120 480l60-360 180 120 120-120v360z' style='stroke:black;stroke-width:2;fill:blue'/>" +
+	"</g>" +
+	"<circle cx='300' cy='300' r='60' class='a'/>" +
+	"<path d='M420 300a120 120 0 0 0-120-120' class='a'/>" +
+	"<text x='60' y='60' style='font-family:Arial;font-size:20px;fill:red'>a &lt; b</text>" +
+	"</svg>"
+
+func TestSVGOptimizer_ToOptimizedSVG(t *testing.T) {
+	s := NewSketcher().WithBackgroundColor("white")
+	s.BeginGroup("walls")
+	// the connected lines are merged, and the intermediate point of the
+	// collinear lines is removed
+	s.Edge(0.2, 0.2, 0.5, 0.2)
+	s.LineTo(0.8, 0.2)
+	s.LineTo(0.8, 0.8)
+	// the dashed lines are not merged
+	s.Pencil.Dash = []float64{10, 5}
+	s.Edge(0.2, 0.8, 0.3, 0.8)
+	s.Pencil.FillColor = "blue"
+	s.Pencil.Dash = nil
+	s.Polygon(testpoints(), true)
+	s.EndGroup()
+	s.Circle(0.5, 0.5, 0.1, false)
+	s.Arc(0.5, 0.5, 0.2, 0, 90)
+	s.Pencil.FontColor = "red"
+	s.Text(0.1, 0.9, "a < b")
+	s.SaveOptimizedSVG("output.TestSVGOptimizer_ToOptimizedSVG.svg", DefaultSVGOptions)
+
+	res := s.ToOptimizedSVG(DefaultSVGOptions)
+	ref := output_TestSVGOptimizer_ToOptimizedSVG
+	if res != ref {
+		t.Errorf("result is:\n%s\nShould be:\n%s", res, ref)
+	}
+	if len(res) >= len(s.ToSVG()) {
+		t.Errorf("the optimized document (%d bytes) should be smaller than the SVG document (%d bytes)", len(res), len(s.ToSVG()))
+	}
+
+	// The compact paths draw the same polygon
+	imported := NewSketcher()
+	if err := imported.ImportSVG(strings.NewReader(res)); err != nil {
+		t.Fatal(err)
+	}
+	polygon := "<polygon points='120.00,480.00 180.00,120.00 360.00,240.00 480.00,120.00 480.00,480.00'"
+	if !strings.Contains(imported.ToSVG(), polygon) {
+		t.Errorf("the imported document should contain the polygon:\n%s", imported.ToSVG())
+	}
+
+	// Without optimization, the lines are kept, and the arc is written with
+	// absolute coordinates
+	opts := SVGOptions{Precision: 3}
+	res = s.ToOptimizedSVG(opts)
+	refs := []string{
+		"<line x1='120' y1='480' x2='300' y2='480' style='stroke:black;stroke-width:2'/>",
+		"<path d='M420 300A120 120 0 0 0 300 180' style='stroke:black;stroke-width:2;fill:none'/>",
+	}
+	for _, ref := range refs {
+		if !strings.Contains(res, ref) {
+			t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+		}
+	}
+}
+
+func TestSVGOptimizer_Precision(t *testing.T) {
+	s := NewSketcher()
+	s.Circle(0.1234567, 0.5, 0.01, false)
+	res := s.ToOptimizedSVG(SVGOptions{Precision: 1})
+	ref := "<circle cx='74.1' cy='300' r='6'"
+	if !strings.Contains(res, ref) {
+		t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+	}
+	res = s.ToOptimizedSVG(SVGOptions{Precision: 4})
+	ref = "<circle cx='74.074' cy='300' r='6'"
+	if !strings.Contains(res, ref) {
+		t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+	}
+	// The default options use the precision of the sketcher
+	res = s.WithPrecision(3, true).ToOptimizedSVG(DefaultSVGOptions)
+	ref = "<circle cx='74.074' cy='300' r='6'"
+	if !strings.Contains(res, ref) {
+		t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+	}
+}
+
+func TestSVGOptimizer_SaveSVGZ(t *testing.T) {
+	s := NewSketcher()
+	s.Edge(0.2, 0.2, 0.8, 0.8)
+	svgzpath := "output.TestSVGOptimizer_SaveSVGZ.svgz"
+	if err := s.SaveOptimizedSVG(svgzpath, DefaultSVGOptions); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(svgzpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("the file should be compressed with gzip: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, ref := string(data), s.ToOptimizedSVG(DefaultSVGOptions); res != ref {
+		t.Errorf("the uncompressed file is:\n%s\nShould be:\n%s", res, ref)
+	}
+}
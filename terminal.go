@@ -0,0 +1,165 @@
+package svg
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// ===========================================================================
+// Terminal preview (Unicode braille or block characters)
+// ===========================================================================
+
+// The terminal backend rasterizes the sketch (see raster.go) onto a grid of
+// characters, to get a quick preview of the sketch in a terminal. Each
+// character represents a small block of pixels: 2x4 dots with the Unicode
+// braille patterns, or 1x2 pixels with the half block characters. Since the
+// character cells of a terminal are about twice as high as wide, the pixels
+// are roughly square in both modes. The lines are drawn at least one pixel
+// wide so that they remain visible at this low resolution. The background
+// color of the sketch is ignored, and the colors of the shapes can be rendered
+// with the ANSI escape sequences (24 bits colors).
+
+type TerminalMode int
+
+const (
+	TerminalBraille TerminalMode = iota // braille patterns (2x4 dots per character)
+	TerminalBlocks                      // half blocks (1x2 pixels per character)
+)
+
+const DefaultTerminalColumns = 80
+
+// TerminalOptions defines the size and the rendering of the terminal preview
+type TerminalOptions struct {
+	Columns int // width of the preview in characters
+	Mode    TerminalMode
+	Colors  bool // if true, the colors are rendered with ANSI escape sequences
+}
+
+var DefaultTerminalOptions = TerminalOptions{
+	Columns: DefaultTerminalColumns,
+	Mode:    TerminalBraille,
+	Colors:  false,
+}
+
+// terminalThreshold is the minimal opacity (alpha from 0 to 255) of a pixel
+// considered as drawn
+const terminalThreshold = 64
+
+// braillePixels are the bits of the braille pattern of the dots (x, y) of a
+// character (dots of 2 columns and 4 rows)
+var braillePixels = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// terminalCell gathers the drawn pixels of a character cell
+type terminalCell struct {
+	pixels     int // number of drawn pixels
+	r, g, b, a int // sum of the (premultiplied) colors of the drawn pixels
+}
+
+func (c *terminalCell) add(pix []uint8) {
+	c.pixels++
+	c.r += int(pix[0])
+	c.g += int(pix[1])
+	c.b += int(pix[2])
+	c.a += int(pix[3])
+}
+
+// sgr returns the ANSI escape sequence that sets the foreground color (and the
+// background color if bg is not nil) to the mean colors of the cells. The
+// colors are quantized (6 levels per channel) to limit the number of escape
+// sequences along the antialiased lines.
+func sgr(fg, bg *terminalCell) string {
+	quantize := func(v, a int) int { return (v*255/a + 25) / 51 * 51 }
+	seq := "\x1b[0"
+	for i, c := range []*terminalCell{fg, bg} {
+		if c == nil || c.a == 0 {
+			continue
+		}
+		code := 38 + 10*i
+		seq += fmt.Sprintf(";%d;2;%d;%d;%d", code, quantize(c.r, c.a), quantize(c.g, c.a), quantize(c.b, c.a))
+	}
+	return seq + "m"
+}
+
+// ToTerminal returns the preview of the sketch as lines of characters
+func (s Sketcher) ToTerminal(opts TerminalOptions) string {
+	columns := opts.Columns
+	if columns <= 0 {
+		columns = DefaultTerminalColumns
+	}
+	dotsx, dotsy := 2, 4
+	if opts.Mode == TerminalBlocks {
+		dotsx, dotsy = 1, 2
+	}
+	scale := float64(columns*dotsx) / float64(s.cs.cnvxsize)
+	s.backgroundColor = Transparent
+	img := s.rasterize(scale, 1)
+	rows := int(math.Ceil(float64(img.Rect.Dy()) / float64(dotsy)))
+
+	var b strings.Builder
+	for row := range rows {
+		current := "\x1b[0m" // each line starts with the default colors
+		for col := range columns {
+			// the pixels of the cell, and the cells of the top and bottom
+			// pixels for the half blocks
+			var pattern rune
+			var cells [2]terminalCell
+			for j := range dotsy {
+				for i := range dotsx {
+					x, y := col*dotsx+i, row*dotsy+j
+					if x >= img.Rect.Dx() || y >= img.Rect.Dy() {
+						continue
+					}
+					pix := img.Pix[img.PixOffset(x, y):]
+					if pix[3] < terminalThreshold {
+						continue
+					}
+					pattern |= braillePixels[j][i]
+					cells[j*2/dotsy].add(pix)
+				}
+			}
+
+			var char string
+			var fg, bg *terminalCell
+			top, bottom := &cells[0], &cells[1]
+			switch {
+			case pattern == 0:
+				char = " "
+			case opts.Mode != TerminalBlocks:
+				char = string(0x2800 + pattern)
+				fg = &terminalCell{
+					pixels: top.pixels + bottom.pixels,
+					r:      top.r + bottom.r, g: top.g + bottom.g, b: top.b + bottom.b, a: top.a + bottom.a,
+				}
+			case bottom.pixels == 0:
+				char, fg = "▀", top
+			case top.pixels == 0:
+				char, fg = "▄", bottom
+			case opts.Colors && sgr(top, nil) != sgr(bottom, nil):
+				char, fg, bg = "▀", top, bottom
+			default:
+				char, fg = "█", top
+			}
+			if opts.Colors {
+				if seq := sgr(fg, bg); seq != current {
+					b.WriteString(seq)
+					current = seq
+				}
+			}
+			b.WriteString(char)
+		}
+		if opts.Colors && current != "\x1b[0m" {
+			b.WriteString("\x1b[0m")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// WriteTerminal writes the preview of the sketch (see ToTerminal) to the
+// writer w (e.g. os.Stdout)
+func (s Sketcher) WriteTerminal(w io.Writer, opts TerminalOptions) error {
+	_, err := io.WriteString(w, s.ToTerminal(opts))
+	return err
+}
@@ -0,0 +1,58 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTerminal_Braille(t *testing.T) {
+	cs := NewCoordSysBottomLeft(400, 200, 40)
+	s := NewSketcher().WithCoordinateSystem(cs)
+	s.Rectangle(0, 0, 40, 20, false)
+	s.Edge(0, 0, 40, 20)
+
+	opts := DefaultTerminalOptions
+	opts.Columns = 40
+	res := s.ToTerminal(opts)
+	t.Logf("\n%s", res)
+	lines := strings.Split(strings.TrimSuffix(res, "\n"), "\n")
+	// 400x200 pixels -> 80x40 dots -> 40x10 characters
+	if len(lines) != 10 {
+		t.Fatalf("the number of lines is %d and should be %d", len(lines), 10)
+	}
+	for _, line := range lines {
+		if n := len([]rune(line)); n != 40 {
+			t.Errorf("the number of characters of the line %q is %d and should be %d", line, n, 40)
+		}
+	}
+	// The top side of the rectangle is drawn with the top dots (dots 1 and 4)
+	if top := []rune(lines[0])[20]; top&0x09 != 0x09 {
+		t.Errorf("the character %q of the top side should have the top dots", top)
+	}
+	// No escape sequence without colors
+	if strings.Contains(res, "\x1b") {
+		t.Errorf("the preview should not contain ANSI escape sequences")
+	}
+}
+
+func TestTerminal_BlocksWithColors(t *testing.T) {
+	cs := NewCoordSysBottomLeft(400, 200, 40)
+	s := NewSketcher().WithCoordinateSystem(cs).WithBackgroundColor("white")
+	s.Pencil.FillColor = "red"
+	s.Pencil.LineColor = "red"
+	s.Rectangle(10, 5, 20, 10, true)
+
+	opts := TerminalOptions{Columns: 40, Mode: TerminalBlocks, Colors: true}
+	res := s.ToTerminal(opts)
+	t.Logf("\n%s", res)
+	refs := []string{"\x1b[0;38;2;255;0;0m█", "█\x1b[0m         \n"}
+	for _, ref := range refs {
+		if !strings.Contains(res, ref) {
+			t.Errorf("result is:\n%q\nShould contain:\n%q", res, ref)
+		}
+	}
+	// The background color is ignored
+	if lines := strings.Split(res, "\n"); strings.TrimSpace(lines[0]) != "" {
+		t.Errorf("the first line should be empty: %q", lines[0])
+	}
+}
@@ -0,0 +1,188 @@
+package svg
+
+import "slices"
+
+// ===========================================================================
+// Themes
+// ===========================================================================
+
+// Theme bundles the default look of a sketch: the background color, the pencil
+// used for drawing, the pencil used for writing text, an accent palette (e.g.
+// for the series of a chart) and the pencils used for the major and minor
+// lines of a grid. A Theme is applied to a Sketcher with WithTheme. The
+// pencils left nil are the ones of DefaultTheme.
+type Theme struct {
+	Name            string
+	BackgroundColor string
+	Pencil          *Pencil // default pencil for drawing
+	TextPencil      *Pencil // default pencil for text (only the font parameters matter)
+	Palette         []string
+	GridPencil      *Pencil // pencil of the major grid lines
+	SubGridPencil   *Pencil // pencil of the minor grid lines
+
+	// Colors maps a color name to the color to use with this theme. It
+	// can be used to remap the colors of a drawing (e.g. "red" to "Gray"
+	// for a grayscale device). Colors that are not in the map are kept.
+	Colors map[string]string
+}
+
+// Color returns the color to use for the color name with this theme, i.e. the
+// color name remapped by the Colors map if defined, the color name otherwise.
+func (t Theme) Color(name string) string {
+	if c, ok := t.Colors[name]; ok {
+		return c
+	}
+	return name
+}
+
+// PaletteColor returns the i-th color of the accent palette. The palette is
+// cycled if i is greater than the number of colors.
+func (t Theme) PaletteColor(i int) string {
+	if len(t.Palette) == 0 {
+		return themePencil(t.Pencil, DefaultTheme.Pencil).LineColor
+	}
+	n := len(t.Palette)
+	return t.Palette[((i%n)+n)%n]
+}
+
+// Clone returns a deep copy of the theme, so that it can be customized without
+// side effects on the original theme. The missing pencils of the theme are
+// copied from DefaultTheme.
+func (t Theme) Clone() *Theme {
+	palette := make([]string, len(t.Palette))
+	copy(palette, t.Palette)
+	colors := make(map[string]string, len(t.Colors))
+	for k, v := range t.Colors {
+		colors[k] = v
+	}
+	return &Theme{
+		Name:            t.Name,
+		BackgroundColor: t.BackgroundColor,
+		Pencil:          themePencil(t.Pencil, DefaultTheme.Pencil).Clone(),
+		TextPencil:      themePencil(t.TextPencil, DefaultTheme.TextPencil).Clone(),
+		Palette:         palette,
+		GridPencil:      themePencil(t.GridPencil, DefaultTheme.GridPencil).Clone(),
+		SubGridPencil:   themePencil(t.SubGridPencil, DefaultTheme.SubGridPencil).Clone(),
+		Colors:          colors,
+	}
+}
+
+// complete returns the theme t if all its pencils are defined, or a copy of t
+// whose missing pencils are the ones of DefaultTheme
+func (t *Theme) complete() *Theme {
+	if t.Pencil != nil && t.TextPencil != nil && t.GridPencil != nil && t.SubGridPencil != nil {
+		return t
+	}
+	return t.Clone()
+}
+
+// themePencil returns the pencil p, or the pencil fallback if p is nil (the
+// default pencil if both are nil)
+func themePencil(p, fallback *Pencil) *Pencil {
+	switch {
+	case p != nil:
+		return p
+	case fallback != nil:
+		return fallback
+	}
+	return defaultPencil
+}
+
+// newThemePencil creates a pencil with the given line and font colors, the
+// other parameters being the default ones.
+func newThemePencil(linecolor string, linewidth int, fillcolor string, fontcolor string) *Pencil {
+	p := NewPencil(linecolor, linewidth)
+	p.FillColor = fillcolor
+	p.FontColor = fontcolor
+	return p
+}
+
+// Palette of accent colors used by the built-in themes
+var (
+	paletteColors = []string{
+		"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+		"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+	}
+	paletteDarkColors = []string{
+		"#4e9eff", "#ffa040", "#5fd35f", "#ff6060", "#c39bff",
+		"#d9a38c", "#ff9de0", "#c0c0c0", "#e6e64c", "#40e0f0",
+	}
+	paletteGrayColors = []string{
+		"black", "dimgray", "gray", "darkgray", "silver",
+	}
+)
+
+var (
+	// DefaultTheme is the theme of a new Sketcher: transparent background and
+	// the default black pencil.
+	DefaultTheme = &Theme{
+		Name:            "default",
+		BackgroundColor: defaultBackgroundColor,
+		Pencil:          defaultPencil.Clone(),
+		TextPencil:      defaultPencil.Clone(),
+		Palette:         slices.Clone(paletteColors),
+		GridPencil:      newThemePencil("lightgray", 1, DefaultFillColor, DefaultFontColor),
+		SubGridPencil:   newThemePencil("whitesmoke", 1, DefaultFillColor, DefaultFontColor),
+	}
+
+	// LightTheme is a black on white theme for screen display.
+	LightTheme = &Theme{
+		Name:            "light",
+		BackgroundColor: "white",
+		Pencil:          defaultPencil.Clone(),
+		TextPencil:      defaultPencil.Clone(),
+		Palette:         slices.Clone(paletteColors),
+		GridPencil:      newThemePencil("lightgray", 1, DefaultFillColor, DefaultFontColor),
+		SubGridPencil:   newThemePencil("whitesmoke", 1, DefaultFillColor, DefaultFontColor),
+	}
+
+	// DarkTheme is a light on dark theme for screen display.
+	DarkTheme = &Theme{
+		Name:            "dark",
+		BackgroundColor: "#1e1e1e",
+		Pencil:          newThemePencil("gainsboro", DefaultLineWidth, "gainsboro", "gainsboro"),
+		TextPencil:      newThemePencil("gainsboro", DefaultLineWidth, "gainsboro", "gainsboro"),
+		Palette:         paletteDarkColors,
+		GridPencil:      newThemePencil("#505050", 1, "#505050", "gainsboro"),
+		SubGridPencil:   newThemePencil("#303030", 1, "#303030", "gainsboro"),
+		Colors: map[string]string{
+			"black": "gainsboro",
+			"white": "#1e1e1e",
+		},
+	}
+
+	// PrintTheme is a theme for printing on paper: white background, thin
+	// black lines and a serif font.
+	PrintTheme = &Theme{
+		Name:            "print",
+		BackgroundColor: "white",
+		Pencil:          newThemePencil("black", 1, DefaultFillColor, "black"),
+		TextPencil: &Pencil{
+			LineColor: "black", LineWidth: 1, FillColor: "black", FillMode: DefaultFillMode,
+			FontFamily: "Times", FontWeight: DefaultFontWeight, FontSize: DefaultFontSize, FontColor: "black",
+		},
+		Palette:       slices.Clone(paletteColors),
+		GridPencil:    newThemePencil("darkgray", 1, DefaultFillColor, "black"),
+		SubGridPencil: newThemePencil("lightgray", 1, DefaultFillColor, "black"),
+	}
+
+	// EInkTheme is a grayscale theme for e-ink devices (e.g. the reMarkable
+	// tablet) that can only display levels of gray. The colors are remapped to
+	// gray levels (see conversion at https://www.orbworks.com/cedocs/color.htm).
+	EInkTheme = &Theme{
+		Name:            "eink",
+		BackgroundColor: "white",
+		Pencil:          defaultPencil.Clone(),
+		TextPencil:      defaultPencil.Clone(),
+		Palette:         paletteGrayColors,
+		GridPencil:      newThemePencil("Gray", 2, DefaultFillColor, DefaultFontColor),
+		SubGridPencil:   newThemePencil("Gray", 1, DefaultFillColor, DefaultFontColor),
+		Colors: map[string]string{
+			"blue":   "Silver",
+			"red":    "Gray",
+			"green":  "DarkGray",
+			"orange": "DarkGray",
+			"yellow": "Silver",
+		},
+	}
+)
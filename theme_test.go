@@ -0,0 +1,81 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTheme_Color(t *testing.T) {
+	if c := EInkTheme.Color("red"); c != "Gray" {
+		t.Errorf("color is %s (should be %s)", c, "Gray")
+	}
+	if c := EInkTheme.Color("purple"); c != "purple" {
+		t.Errorf("color is %s (should be %s)", c, "purple")
+	}
+	n := len(LightTheme.Palette)
+	if c := LightTheme.PaletteColor(n + 1); c != LightTheme.Palette[1] {
+		t.Errorf("color is %s (should be %s)", c, LightTheme.Palette[1])
+	}
+}
+
+func TestTheme_Clone(t *testing.T) {
+	theme := DarkTheme.Clone()
+	theme.Pencil.LineColor = "red"
+	theme.Palette[0] = "red"
+	if DarkTheme.Pencil.LineColor == "red" || DarkTheme.Palette[0] == "red" {
+		t.Errorf("the modification of the clone should not modify the original theme")
+	}
+	// The built-in themes do not share their palettes
+	if &DefaultTheme.Palette[0] == &LightTheme.Palette[0] || &LightTheme.Palette[0] == &PrintTheme.Palette[0] {
+		t.Errorf("the palettes of the built-in themes should be distinct copies")
+	}
+
+	// The missing pencils are the ones of the default theme
+	theme = (&Theme{Name: "custom", TextPencil: PrintTheme.TextPencil}).Clone()
+	if theme.Pencil == nil || theme.GridPencil == nil || theme.GridPencil.LineColor != DefaultTheme.GridPencil.LineColor {
+		t.Errorf("the missing pencils should be copied from the default theme")
+	}
+	if theme.TextPencil.FontFamily != "Times" {
+		t.Errorf("the pencils of the theme should be kept")
+	}
+}
+
+func TestSketcher_WithTheme(t *testing.T) {
+	themes := []*Theme{DefaultTheme, LightTheme, DarkTheme, PrintTheme, EInkTheme}
+	for _, theme := range themes {
+		s := NewSketcher().WithTheme(theme)
+		s.Pencil.LineColor = theme.PaletteColor(0)
+		s.Circle(0.5, 0.5, 0.2, false)
+		s.Pencil.LineColor = theme.Color("red")
+		s.Edge(0.1, 0.1, 0.9, 0.9)
+		s.Text(0.1, 0.8, "Theme "+theme.Name)
+		s.Save("output.TestSketcher_WithTheme." + theme.Name + ".svg")
+
+		res := s.ToSVG()
+		if theme.BackgroundColor != Transparent {
+			bg := "fill='" + theme.BackgroundColor + "'"
+			if !strings.Contains(res, bg) {
+				t.Errorf("the svg of theme %s should contain the background %s", theme.Name, bg)
+			}
+		}
+		if !strings.Contains(res, "font-family:"+theme.TextPencil.FontFamily) {
+			t.Errorf("the svg of theme %s should use the font %s", theme.Name, theme.TextPencil.FontFamily)
+		}
+	}
+
+	// A theme without pencils draws with the default pencils
+	s := NewSketcher().WithTheme(&Theme{BackgroundColor: "white"})
+	if s.Pencil.LineColor != DefaultTheme.Pencil.LineColor || s.Theme().GridPencil == nil {
+		t.Errorf("the missing pencils of the theme should be the default ones")
+	}
+	if c := s.Theme().PaletteColor(0); c != DefaultTheme.Pencil.LineColor {
+		t.Errorf("the palette color of a theme without palette is %s and should be the pencil color", c)
+	}
+
+	// Modifying the sketcher pencil must not modify the theme
+	s = NewSketcher().WithTheme(LightTheme)
+	s.Pencil.LineWidth = 10
+	if LightTheme.Pencil.LineWidth == 10 {
+		t.Errorf("the sketcher pencil should be a copy of the theme pencil")
+	}
+}
@@ -0,0 +1,308 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ===========================================================================
+// TikZ backend (LaTeX)
+// ===========================================================================
+
+// The TikZ backend translates the recorded shapes into the drawing commands of
+// a tikzpicture environment. The coordinates are written in user coordinates,
+// the size of a user unit being declared by the x and y options of the picture
+// (in cm). The colors are declared with the \definecolor command of the xcolor
+// package, and the texts are written as nodes, with the font of the document
+// (only the font weight is kept).
+
+// centimetersPerPixel is the size of a canvas pixel (1/96 inch) in cm
+const centimetersPerPixel = 2.54 / 96
+
+type tikzWriter struct {
+	b        strings.Builder
+	cs       *CoordinateSystem
+	decimals int
+	colors   map[string]string // color specification -> color name
+	current  point             // current point (canvas coordinates)
+	// saveImage saves the embedded image data of an image and returns the
+	// path to include (or false if the images can not be saved)
+	saveImage func(data string) (string, bool)
+}
+
+func (w *tikzWriter) printf(format string, args ...any) {
+	fmt.Fprintf(&w.b, format, args...)
+}
+
+func (w *tikzWriter) coordinates(p point) string {
+	x, y := w.cs.userCoordinates(p.X, p.Y)
+	return "(" + fmtnum(x, w.decimals) + "," + fmtnum(y, w.decimals) + ")"
+}
+
+// color returns the name of the xcolor color corresponding to the color
+// specification, and its opacity. It returns false if nothing should be painted
+// with this color.
+func (w *tikzWriter) color(colorname string) (name string, opacity float64, ok bool) {
+	c, err := ParseColor(colorname)
+	if err != nil || c.A == 0 {
+		return "", 0, false
+	}
+	a := float64(c.A)
+	key := fmt.Sprintf("%d,%d,%d", int(float64(c.R)*255/a+0.5), int(float64(c.G)*255/a+0.5), int(float64(c.B)*255/a+0.5))
+	name, ok = w.colors[key]
+	if !ok {
+		name = fmt.Sprintf("dingocolor%d", len(w.colors)+1)
+		w.colors[key] = name
+	}
+	return name, a / 255, true
+}
+
+// latexEscape escapes the LaTeX special characters of the text
+func latexEscape(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\textbackslash{}`, `{`, `\{`, `}`, `\}`, `$`, `\$`, `&`, `\&`,
+		`#`, `\#`, `%`, `\%`, `_`, `\_`, `^`, `\textasciicircum{}`, `~`, `\textasciitilde{}`,
+	)
+	return replacer.Replace(text)
+}
+
+// drawOptions returns the options of the draw command for the pencil p, and
+// false if nothing should be drawn
+func (w *tikzWriter) drawOptions(p Pencil, fill bool) (string, bool) {
+	var options []string
+	stroke := false
+	if name, opacity, ok := w.color(p.LineColor); ok && p.LineWidth > 0 {
+		stroke = true
+		options = append(options, "draw="+name, "line width="+psnum(float64(p.LineWidth)*pointsPerPixel)+"pt")
+		if opacity < 1 {
+			options = append(options, "draw opacity="+psnum(opacity))
+		}
+		if len(p.Dash) > 0 {
+			dash := p.Dash
+			if len(dash)%2 == 1 {
+				// an odd pattern is repeated to get an even pattern
+				dash = append(append([]float64{}, dash...), dash...)
+			}
+			var pattern []string
+			for i, l := range dash {
+				onoff := "on"
+				if i%2 == 1 {
+					onoff = "off"
+				}
+				pattern = append(pattern, onoff+" "+psnum(l*pointsPerPixel)+"pt")
+			}
+			options = append(options, "dash pattern="+strings.Join(pattern, " "))
+		}
+	}
+	if fill {
+		if name, opacity, ok := w.color(p.FillColor); ok {
+			options = append(options, "fill="+name)
+			if opacity < 1 {
+				options = append(options, "fill opacity="+psnum(opacity))
+			}
+		} else {
+			fill = false
+		}
+	}
+	return strings.Join(options, ", "), stroke || fill
+}
+
+func (w *tikzWriter) path(points []point, closed bool) string {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = w.coordinates(p)
+	}
+	path := strings.Join(coords, " -- ")
+	if closed {
+		path += " -- cycle"
+	}
+	return path
+}
+
+func (w *tikzWriter) Begin(width, height int, background string) {
+	if name, _, ok := w.color(background); ok && background != Transparent {
+		xmin, xmax, ymin, ymax := w.cs.UserCoordinatesBoundaries()
+		w.printf("\\fill[%s] (%s,%s) rectangle (%s,%s);\n", name,
+			fmtnum(xmin, w.decimals), fmtnum(ymin, w.decimals), fmtnum(xmax, w.decimals), fmtnum(ymax, w.decimals))
+	}
+}
+
+func (w *tikzWriter) End() {}
+
+func (w *tikzWriter) MoveTo(x, y float64) {
+	w.current = point{x, y}
+}
+
+func (w *tikzWriter) LineTo(x, y float64, p Pencil) {
+	points := []point{w.current, {x, y}}
+	w.current = point{x, y}
+	if !finitePoints(points...) {
+		return
+	}
+	if options, ok := w.drawOptions(p, false); ok {
+		w.printf("\\path[%s] %s;\n", options, w.path(points, false))
+	}
+}
+
+func (w *tikzWriter) Path(points []struct{ X, Y float64 }, closed, fill bool, p Pencil) {
+	if !finitePoints(points...) {
+		return
+	}
+	if options, ok := w.drawOptions(p, closed && fill); ok {
+		w.printf("\\path[%s] %s;\n", options, w.path(points, closed))
+	}
+}
+
+func (w *tikzWriter) Circle(cx, cy, r float64, fill bool, p Pencil) {
+	if !finitePoints(point{cx, cy}) {
+		return
+	}
+	if options, ok := w.drawOptions(p, fill); ok {
+		w.printf("\\path[%s] %s circle[radius=%s];\n", options, w.coordinates(point{cx, cy}),
+			fmtnum(r/w.cs.unit2pixel, w.decimals))
+	}
+}
+
+func (w *tikzWriter) Arc(cx, cy, r, startAngle, sweepAngle float64, p Pencil) {
+	if !finitePoints(point{cx, cy}) {
+		return
+	}
+	if options, ok := w.drawOptions(p, false); ok {
+		// The TikZ arc starts at the current point, and its angles are
+		// counterclockwise in the user coordinates system
+		start, end := userArcAngles(w.cs, startAngle, sweepAngle)
+		ur := r / w.cs.unit2pixel
+		ux, uy := w.cs.userCoordinates(cx, cy)
+		ux, uy = ux+ur*cosd(start), uy+ur*sind(start)
+		w.printf("\\path[%s] (%s,%s) arc[start angle=%s, end angle=%s, radius=%s];\n", options,
+			fmtnum(ux, w.decimals), fmtnum(uy, w.decimals), psnum(start), psnum(end), fmtnum(ur, w.decimals))
+	}
+}
+
+func (w *tikzWriter) Text(x, y float64, text string, p Pencil) {
+	if !finitePoints(point{x, y}) {
+		return
+	}
+	name, opacity, ok := w.color(p.FontColor)
+	if !ok {
+		return
+	}
+	options := "anchor=base west, inner sep=0pt, text=" + name
+	if opacity < 1 {
+		options += ", text opacity=" + psnum(opacity)
+	}
+	if p.FontWeight == "bold" || p.FontWeight == "bolder" {
+		options += ", font=\\bfseries"
+	}
+	w.printf("\\node[%s] at %s {%s};\n", options, w.coordinates(point{x, y}), latexEscape(text))
+}
+
+func (w *tikzWriter) Image(x, y, width, height float64, href string, style ImageStyle) {
+	if !finitePoints(point{x, y}) {
+		return
+	}
+	data, ok := href, true
+	if strings.HasPrefix(href, "data:") {
+		href, ok = "", false
+		if w.saveImage != nil {
+			href, ok = w.saveImage(data)
+		}
+	}
+	if !ok {
+		w.printf("%% embedded image not exported\n")
+		return
+	}
+	// bottom left corner of the image rectangle
+	corner := point{x, y + height}
+	options := "anchor=south west, inner sep=0pt"
+	if style.Opacity < 1 {
+		options += ", opacity=" + psnum(style.Opacity)
+	}
+	size := fmt.Sprintf("width=%scm, height=%scm",
+		psnum(width*centimetersPerPixel), psnum(height*centimetersPerPixel))
+	if style.AspectRatio != ImageAspectRatioNone {
+		size += ", keepaspectratio"
+	}
+	w.printf("\\node[%s] at %s {\\includegraphics[%s]{%s}};\n", options, w.coordinates(corner), size, href)
+}
+
+func (w *tikzWriter) BeginGroup(name string) {}
+func (w *tikzWriter) EndGroup()              {}
+
+func (s Sketcher) toTikZ(saveImage func(data string) (string, bool)) string {
+	cs := s.cs
+	w := &tikzWriter{cs: cs, decimals: userDecimals(cs), colors: map[string]string{}, saveImage: saveImage}
+
+	xmin, xmax, ymin, ymax := cs.UserCoordinatesBoundaries()
+	s.Render(w)
+	body := w.b.String()
+
+	var tikz strings.Builder
+	for _, key := range sortedKeys(w.colors) {
+		fmt.Fprintf(&tikz, "\\definecolor{%s}{RGB}{%s}\n", w.colors[key], key)
+	}
+	// The size of the user unit (in cm) gives the x and y vectors of the
+	// picture. The orientation of the user axis is given by the signs.
+	unit := cs.unit2pixel * centimetersPerPixel
+	decimals := max(3, 5-int(math.Floor(math.Log10(unit)))) // 6 significant digits
+	fmt.Fprintf(&tikz, "\\begin{tikzpicture}[x=%scm, y=%scm]\n",
+		fmtnum(unit*cs.xsign, decimals), fmtnum(-unit*cs.ysign, decimals))
+	// The bounding box of the picture is the canvas
+	fmt.Fprintf(&tikz, "\\useasboundingbox (%s,%s) rectangle (%s,%s);\n",
+		fmtnum(xmin, w.decimals), fmtnum(ymin, w.decimals), fmtnum(xmax, w.decimals), fmtnum(ymax, w.decimals))
+	tikz.WriteString(body)
+	tikz.WriteString("\\end{tikzpicture}\n")
+	return tikz.String()
+}
+
+// ToTikZ returns the sketch as a tikzpicture environment, to be included in a
+// LaTeX document that loads the tikz package (and the graphicx package if the
+// sketch contains linked images). The images embedded in the sketch are not
+// exported (use SaveTikZ).
+func (s Sketcher) ToTikZ() string {
+	return s.toTikZ(nil)
+}
+
+// tikzStandalonePattern is a LaTeX document that contains only the picture,
+// and whose page is cropped to the picture
+const tikzStandalonePattern = `\documentclass[tikz]{standalone}
+\usepackage{graphicx}
+\begin{document}
+%s\end{document}
+`
+
+// SaveTikZ saves the tikzpicture of the sketch in the file texpath, to be
+// included in a LaTeX document with \input. If standalone is true, the file is
+// a complete LaTeX document (standalone class) that can be compiled directly.
+// The images embedded in the sketch are saved in PNG files (or JPEG, GIF)
+// beside the file texpath.
+func (s Sketcher) SaveTikZ(texpath string, standalone bool) error {
+	base := strings.TrimSuffix(texpath, filepath.Ext(texpath))
+	var imgerr error
+	nbimages := 0
+	saveImage := func(href string) (string, bool) {
+		data, mimetype, err := decodeDataURI(href)
+		if err != nil {
+			imgerr = err
+			return "", false
+		}
+		nbimages++
+		imgpath := fmt.Sprintf("%s-image%d.%s", base, nbimages, strings.TrimPrefix(mimetype, "image/"))
+		if err := os.WriteFile(imgpath, data, 0600); err != nil {
+			imgerr = err
+			return "", false
+		}
+		return filepath.Base(imgpath), true
+	}
+	tikz := s.toTikZ(saveImage)
+	if imgerr != nil {
+		return imgerr
+	}
+	if standalone {
+		tikz = fmt.Sprintf(tikzStandalonePattern, tikz)
+	}
+	return os.WriteFile(texpath, []byte(tikz), 0600)
+}
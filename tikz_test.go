@@ -0,0 +1,67 @@
+package svg
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTikZ_ToTikZ(t *testing.T) {
+	s := NewSketcher()
+	s.Pencil.LineColor = "red"
+	s.Pencil.Dash = []float64{4}
+	s.Polygon(testpoints(), false)
+	s.Pencil.Dash = nil
+	s.Pencil.FillColor = "rgba(0,0,255,0.5)"
+	s.Circle(0.5, 0.5, 0.1, true)
+	s.Pencil.FontColor = "red"
+	s.Text(0.2, 0.1, "50% of x_1 & {y}")
+
+	res := s.ToTikZ()
+	refs := []string{
+		"\\definecolor{dingocolor1}{RGB}{255,0,0}\n",
+		"\\begin{tikzpicture}[x=15.875cm, y=15.875cm]\n",
+		"\\useasboundingbox (0,0) rectangle (1,1);\n",
+		"\\path[draw=dingocolor1, line width=1.5pt, dash pattern=on 3pt off 3pt] (0.2,0.2) -- (0.3,0.8) -- (0.6,0.6) -- (0.8,0.8) -- (0.8,0.2) -- cycle;\n",
+		"fill=dingocolor2, fill opacity=0.502] (0.5,0.5) circle[radius=0.1];\n",
+		"\\node[anchor=base west, inner sep=0pt, text=dingocolor1] at (0.2,0.1) {50\\% of x\\_1 \\& \\{y\\}};\n",
+		"\\end{tikzpicture}\n",
+	}
+	for _, ref := range refs {
+		if !strings.Contains(res, ref) {
+			t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+		}
+	}
+}
+
+func TestTikZ_SaveTikZ(t *testing.T) {
+	// With a top left coordinates system, the user y axis is oriented top
+	// down, then the y vector of the picture is negative
+	cs := NewCoordSysTopLeft(DefaultCanvasWidth, DefaultCanvasHeight, 10)
+	s := NewSketcher().WithCoordinateSystem(cs).WithBackgroundColor("white")
+	s.Rectangle(1, 1, 5, 3, false)
+	s.Image(6, 6, 3, 3, testimage(10, 10))
+
+	texpath := "output.TestTikZ_SaveTikZ.tex"
+	if err := s.SaveTikZ(texpath, true); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(texpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := string(data)
+	refs := []string{
+		"\\documentclass[tikz]{standalone}",
+		"[x=1.5875cm, y=-1.5875cm]",
+		"\\includegraphics[width=4.763cm, height=4.763cm, keepaspectratio]{output.TestTikZ_SaveTikZ-image1.png}",
+	}
+	for _, ref := range refs {
+		if !strings.Contains(res, ref) {
+			t.Errorf("result is:\n%s\nShould contain:\n%s", res, ref)
+		}
+	}
+	if _, err := os.Stat("output.TestTikZ_SaveTikZ-image1.png"); err != nil {
+		t.Errorf("the embedded image should be saved: %s", err)
+	}
+}